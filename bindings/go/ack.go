@@ -0,0 +1,137 @@
+// Explicit acknowledgment of received messages
+
+package umsbb
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrAlreadySettled is returned when a message is acked or nacked a second time
+var ErrAlreadySettled = errors.New("message already acknowledged")
+
+// AckFunc confirms a message received with ReceiveWithAck
+type AckFunc func() error
+
+// NackFunc returns a message received with ReceiveWithAckNack to the bus
+type NackFunc func() error
+
+// ackTable holds messages that were received but not yet settled, keyed by
+// a per-bus sequence number
+type ackTable struct {
+	mu      sync.Mutex
+	nextSeq uint64
+	pending map[uint64]UniversalData
+}
+
+// track stores msg as pending and returns its sequence number
+func (t *ackTable) track(msg UniversalData) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending == nil {
+		t.pending = make(map[uint64]UniversalData)
+	}
+	t.nextSeq++
+	t.pending[t.nextSeq] = msg
+	return t.nextSeq
+}
+
+// settle removes seq from the pending table and returns its message
+func (t *ackTable) settle(seq uint64) (UniversalData, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	msg, ok := t.pending[seq]
+	if !ok {
+		return UniversalData{}, ErrAlreadySettled
+	}
+	delete(t.pending, seq)
+	return msg, nil
+}
+
+// restore puts a message back into the pending table after a failed requeue
+func (t *ackTable) restore(seq uint64, msg UniversalData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending[seq] = msg
+}
+
+// ReceiveWithAck waits for the next message and holds it pending until acked
+//
+// The message is taken off the bus when it is received and pending messages
+// are held in memory, so with WithWAL it is committed as drained at receive
+// time: a crash before the ack loses it rather than replaying it.
+//
+// Example:
+//
+//	msg, ack, err := bus.ReceiveWithAck(ctx)
+//	if err == nil && process(msg) == nil {
+//	    _ = ack()
+//	}
+func (b *DirectUniversalBus) ReceiveWithAck(ctx context.Context) (*UniversalData, AckFunc, error) {
+	msg, ack, _, err := b.ReceiveWithAckNack(ctx)
+	return msg, ack, err
+}
+
+// ReceiveWithAckNack waits for the next message and holds it pending until
+// it is acked, or nacked back onto the bus for redelivery
+//
+// As with ReceiveWithAck, a crash before the message is settled loses it
+// even with WithWAL. A nacked message is sent again past
+// WithContentDeduplication, which would otherwise drop it as a duplicate.
+//
+// Returns:
+//   - msg: Received message
+//   - ack: Removes the message from the pending table
+//   - nack: Removes the message from the pending table and sends it again
+//   - error: ctx.Err() if ctx is done before a message arrives
+//
+// Example:
+//
+//	msg, ack, nack, err := bus.ReceiveWithAckNack(ctx)
+//	if err != nil {
+//	    return err
+//	}
+//	if err := process(msg); err != nil {
+//	    return nack()
+//	}
+//	return ack()
+func (b *DirectUniversalBus) ReceiveWithAckNack(ctx context.Context) (*UniversalData, AckFunc, NackFunc, error) {
+	msg, err := receiveDataContext(ctx, b)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	seq := b.acks.track(*msg)
+
+	ack := func() error {
+		_, err := b.acks.settle(seq)
+		return err
+	}
+
+	nack := func() error {
+		pending, err := b.acks.settle(seq)
+		if err != nil {
+			return err
+		}
+		// Validated when first sent, and may be framed by SendMessage
+		if err := b.requeue(pending.Data, pending.TypeID); err != nil {
+			b.acks.restore(seq, pending)
+			return err
+		}
+		return nil
+	}
+
+	return msg, ack, nack, nil
+}
+
+// PendingAcks returns the number of received messages not yet acked or nacked
+func (b *DirectUniversalBus) PendingAcks() int {
+	b.acks.mu.Lock()
+	defer b.acks.mu.Unlock()
+
+	return len(b.acks.pending)
+}
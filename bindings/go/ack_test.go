@@ -0,0 +1,54 @@
+package umsbb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+func TestAckNackWithContentDeduplication(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t, umsbb.WithContentDeduplication(100, 0))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := bus.Send([]byte("job"), 1); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	msg, _, nack, err := bus.ReceiveWithAckNack(ctx)
+	if err != nil || string(msg.Data) != "job" {
+		t.Fatalf("ReceiveWithAckNack = %v, %v; want job", msg, err)
+	}
+	if err := nack(); err != nil {
+		t.Fatalf("nack failed: %v", err)
+	}
+	if err := nack(); !errors.Is(err, umsbb.ErrAlreadySettled) {
+		t.Fatalf("second nack = %v, want ErrAlreadySettled", err)
+	}
+
+	// The requeued copy must not be dropped as a duplicate of the original
+	msg, ack, err := bus.ReceiveWithAck(ctx)
+	if err != nil || string(msg.Data) != "job" {
+		t.Fatalf("ReceiveWithAck after nack = %v, %v; want job redelivered", msg, err)
+	}
+	if err := ack(); err != nil {
+		t.Fatalf("ack failed: %v", err)
+	}
+	if n := bus.PendingAcks(); n != 0 {
+		t.Fatalf("PendingAcks = %d, want 0", n)
+	}
+	if dups := bus.Stats().DuplicateCount; dups != 0 {
+		t.Fatalf("DuplicateCount = %d, want 0", dups)
+	}
+
+	// Content deduplication still applies to ordinary sends
+	if err := bus.Send([]byte("job"), 1); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if n, err := bus.Size(); err != nil || n != 0 {
+		t.Fatalf("Size = %d, %v; want the resend dropped", n, err)
+	}
+}
@@ -0,0 +1,74 @@
+// Pinning of type ID ranges to segments
+
+package umsbb
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// segmentAffinity routes type IDs in [low, high] to segment
+type segmentAffinity struct {
+	low, high uint32
+	segment   uint32
+}
+
+// affinityTable is replaced as a whole on every change, so Send reads it without locking
+type affinityTable struct {
+	rules atomic.Pointer[[]segmentAffinity]
+}
+
+// lookup returns the segment pinned for typeID, or -1 if none is
+func (t *affinityTable) lookup(typeID uint32) int {
+	rules := t.rules.Load()
+	if rules == nil {
+		return -1
+	}
+	for _, rule := range *rules {
+		if typeID >= rule.low && typeID <= rule.high {
+			return int(rule.segment)
+		}
+	}
+	return -1
+}
+
+// SetSegmentAffinity sends every type ID in [typeIDLow, typeIDHigh] to segmentID
+//
+// Without a rule the C library picks the segment from the type ID, which can
+// spread related types across segments and interleave them. Pinned types
+// share one segment and keep their relative order. Ranges may not overlap
+// an existing rule.
+//
+// Example:
+//
+//	// Control messages 0-99 share segment 0
+//	err := bus.SetSegmentAffinity(0, 99, 0)
+func (b *DirectUniversalBus) SetSegmentAffinity(typeIDLow, typeIDHigh uint32, segmentID uint32) error {
+	if typeIDLow > typeIDHigh {
+		return fmt.Errorf("invalid type ID range %d-%d", typeIDLow, typeIDHigh)
+	}
+	segments, err := b.activeSegmentCount()
+	if err != nil {
+		return err
+	}
+	if segmentID >= segments {
+		return fmt.Errorf("segment %d out of range, bus has %d", segmentID, segments)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var rules []segmentAffinity
+	if current := b.affinity.rules.Load(); current != nil {
+		rules = append(rules, *current...)
+	}
+	for _, rule := range rules {
+		if typeIDLow <= rule.high && rule.low <= typeIDHigh {
+			return fmt.Errorf("type ID range %d-%d overlaps %d-%d pinned to segment %d",
+				typeIDLow, typeIDHigh, rule.low, rule.high, rule.segment)
+		}
+	}
+	rules = append(rules, segmentAffinity{low: typeIDLow, high: typeIDHigh, segment: segmentID})
+	b.affinity.rules.Store(&rules)
+	return nil
+}
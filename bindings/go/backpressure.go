@@ -0,0 +1,60 @@
+// Alerts for sends held up by a full or unavailable bus
+
+package umsbb
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// BackpressureAlertCooldown is the minimum time between two backpressure alerts
+const BackpressureAlertCooldown = time.Second
+
+// backpressureAlert calls fn for sends blocked longer than threshold
+type backpressureAlert struct {
+	threshold time.Duration
+	fn        func(typeID uint32, blocked time.Duration)
+	lastAlert atomic.Int64 // unix nanoseconds
+}
+
+// observe reports a send of typeID that blocked for blocked, unless it was
+// under the threshold or another alert fired within the cooldown
+func (a *backpressureAlert) observe(typeID uint32, blocked time.Duration) {
+	if a == nil || blocked < a.threshold {
+		return
+	}
+
+	now := time.Now().UnixNano()
+	last := a.lastAlert.Load()
+	if last != 0 && now-last < int64(BackpressureAlertCooldown) {
+		return
+	}
+	if !a.lastAlert.CompareAndSwap(last, now) {
+		return // Another send is raising the alert
+	}
+	go a.fn(typeID, blocked)
+}
+
+// WithBackpressureAlert calls fn when a send blocks for longer than threshold
+//
+// Sends block while the bus refuses them in BusProxy, BusStream and the other
+// bridges that wait for room, and while WithAutoReconnect recreates the
+// handle. fn runs on its own goroutine with the type ID and the time the
+// send was blocked. At most one alert is raised per
+// BackpressureAlertCooldown; alerts within it are dropped.
+//
+// Example:
+//
+//	bus, err := umsbb.NewDirectUniversalBus(1024*1024, 4, false, false,
+//	    umsbb.WithBackpressureAlert(50*time.Millisecond, func(typeID uint32, blocked time.Duration) {
+//	        log.Printf("send of type %d blocked for %v", typeID, blocked)
+//	    }))
+func WithBackpressureAlert(threshold time.Duration, fn func(typeID uint32, blocked time.Duration)) BusOption {
+	return func(o *busOptions) {
+		if fn == nil {
+			o.backpressure = nil
+			return
+		}
+		o.backpressure = &backpressureAlert{threshold: threshold, fn: fn}
+	}
+}
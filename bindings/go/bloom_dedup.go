@@ -0,0 +1,98 @@
+// Content deduplication with a resettable Bloom filter
+
+package umsbb
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+// bloomFalsePositiveRate sizes the filter so a full window wrongly drops
+// about 1 in 100 unique messages
+const bloomFalsePositiveRate = 0.01
+
+// contentFilter is a Bloom filter over message hashes that clears itself
+// after windowSize unique messages
+type contentFilter struct {
+	mu         sync.Mutex
+	bits       []uint64
+	hashes     uint
+	windowSize uint
+	inserted   uint
+}
+
+// newContentFilter returns a filter for windowSize messages, or nil if windowSize is 0
+//
+// A hashFunctions of 0 picks the count that minimises false positives for the window.
+func newContentFilter(windowSize, hashFunctions uint) *contentFilter {
+	if windowSize == 0 {
+		return nil
+	}
+
+	// m = -n ln p / (ln 2)^2 bits, k = m/n ln 2 hash functions
+	bitCount := math.Ceil(-float64(windowSize) * math.Log(bloomFalsePositiveRate) / (math.Ln2 * math.Ln2))
+	if hashFunctions == 0 {
+		hashFunctions = uint(max(1, math.Round(bitCount/float64(windowSize)*math.Ln2)))
+	}
+	return &contentFilter{
+		bits:       make([]uint64, (uint64(bitCount)+63)/64),
+		hashes:     hashFunctions,
+		windowSize: windowSize,
+	}
+}
+
+// contentDigest hashes the type ID and payload, so equal payloads sent with
+// different type IDs are distinct messages
+func contentDigest(data []byte, typeID uint32) [sha256.Size]byte {
+	h := sha256.New()
+	var tag [4]byte
+	binary.LittleEndian.PutUint32(tag[:], typeID)
+	h.Write(tag[:])
+	h.Write(data)
+
+	var digest [sha256.Size]byte
+	h.Sum(digest[:0])
+	return digest
+}
+
+// positions derives the filter bits for digest by double hashing
+func (f *contentFilter) positions(digest [sha256.Size]byte, fn func(bit uint64)) {
+	h1 := binary.LittleEndian.Uint64(digest[0:8])
+	h2 := binary.LittleEndian.Uint64(digest[8:16]) | 1
+	size := uint64(len(f.bits)) * 64
+
+	for i := uint64(0); i < uint64(f.hashes); i++ {
+		fn((h1 + i*h2) % size)
+	}
+}
+
+// seen reports whether digest was probably added since the last reset
+func (f *contentFilter) seen(digest [sha256.Size]byte) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	found := true
+	f.positions(digest, func(bit uint64) {
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			found = false
+		}
+	})
+	return found
+}
+
+// add records digest, clearing the filter first if the window is full
+func (f *contentFilter) add(digest [sha256.Size]byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.inserted >= f.windowSize {
+		clear(f.bits)
+		f.inserted = 0
+	}
+	f.positions(digest, func(bit uint64) {
+		f.bits[bit/64] |= 1 << (bit % 64)
+	})
+	f.inserted++
+}
@@ -0,0 +1,108 @@
+// Bus interface shared by the direct bus and its middleware
+
+package umsbb
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrSubmitFailed is returned by Send when the bus refuses a message, typically
+// because the target segment is full; the send can be retried
+var ErrSubmitFailed = errors.New("failed to submit data")
+
+// receivePollInterval is how long blocking receive helpers sleep between empty drains
+const receivePollInterval = 100 * time.Microsecond
+
+// BusInterface is the minimal transport surface shared by DirectUniversalBus
+// and ChannelBus, so code that only sends and receives payloads can be tested
+// without the C library
+type BusInterface interface {
+	// Send sends data tagged with typeID
+	Send(data []byte, typeID uint32) error
+	// Receive returns the next payload, or nil if nothing is available
+	Receive() ([]byte, error)
+	// Close releases the transport
+	Close() error
+}
+
+// Bus is the message transport implemented by DirectUniversalBus and by
+// every middleware that wraps it
+type Bus interface {
+	BusInterface
+	// ReceiveData returns the next message with its metadata, or nil if nothing is available
+	ReceiveData() (*UniversalData, error)
+}
+
+var (
+	_ BusInterface = (*DirectUniversalBus)(nil)
+	_ Bus          = (*DirectUniversalBus)(nil)
+)
+
+// payloadOf adapts a ReceiveData result to the Receive signature
+func payloadOf(msg *UniversalData, err error) ([]byte, error) {
+	if err != nil || msg == nil {
+		return nil, err
+	}
+	return msg.Data, nil
+}
+
+// receiveContext polls bus until a payload arrives or ctx is done
+func receiveContext(ctx context.Context, bus BusInterface) ([]byte, error) {
+	var data []byte
+	err := pollContext(ctx, func() (bool, error) {
+		var err error
+		data, err = bus.Receive()
+		return data != nil, err
+	})
+	return data, err
+}
+
+// receiveDataContext polls bus until a message arrives or ctx is done
+func receiveDataContext(ctx context.Context, bus Bus) (*UniversalData, error) {
+	var msg *UniversalData
+	err := pollContext(ctx, func() (bool, error) {
+		var err error
+		msg, err = bus.ReceiveData()
+		return msg != nil, err
+	})
+	return msg, err
+}
+
+// sendContext retries a refused send until the bus accepts it or ctx is done
+//
+// A DirectUniversalBus with WithBackpressureAlert is told how long the send waited.
+func sendContext(ctx context.Context, bus BusInterface, data []byte, typeID uint32) error {
+	start := time.Now()
+	err := pollContext(ctx, func() (bool, error) {
+		err := bus.Send(data, typeID)
+		if errors.Is(err, ErrSubmitFailed) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if b, ok := bus.(*DirectUniversalBus); ok {
+		b.opts().backpressure.observe(typeID, time.Since(start))
+	}
+	return err
+}
+
+// pollContext calls poll until it reports success or an error, or ctx is done
+func pollContext(ctx context.Context, poll func() (bool, error)) error {
+	for {
+		ok, err := poll()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(receivePollInterval):
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Byte order normalization middleware for structured binary payloads
+
+package umsbb
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// BinaryField describes one multi-byte field inside a binary payload
+type BinaryField struct {
+	Offset int
+	Width  int
+}
+
+// BinarySchema describes the multi-byte fields of a binary payload
+type BinarySchema struct {
+	Fields []BinaryField
+}
+
+// Validate checks that every field has a supported width and a non-negative offset
+func (s BinarySchema) Validate() error {
+	for i, field := range s.Fields {
+		if field.Offset < 0 {
+			return fmt.Errorf("field %d: negative offset %d", i, field.Offset)
+		}
+		switch field.Width {
+		case 1, 2, 4, 8:
+		default:
+			return fmt.Errorf("field %d: unsupported width %d (want 1, 2, 4 or 8)", i, field.Width)
+		}
+	}
+	return nil
+}
+
+// convert rewrites every schema field of data from one byte order to another
+func (s BinarySchema) convert(data []byte, from, to binary.ByteOrder) ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(data))
+	copy(out, data)
+
+	for i, field := range s.Fields {
+		end := field.Offset + field.Width
+		if end > len(out) {
+			return nil, fmt.Errorf("field %d: bytes %d..%d outside %d byte payload", i, field.Offset, end, len(out))
+		}
+
+		buf := out[field.Offset:end]
+		switch field.Width {
+		case 2:
+			to.PutUint16(buf, from.Uint16(buf))
+		case 4:
+			to.PutUint32(buf, from.Uint32(buf))
+		case 8:
+			to.PutUint64(buf, from.Uint64(buf))
+		}
+	}
+	return out, nil
+}
+
+// byteOrderBus converts schema fields between host and network byte order
+type byteOrderBus struct {
+	bus    Bus
+	schema BinarySchema
+}
+
+// ByteOrderNormalizationMiddleware converts the multi-byte fields described by
+// schema from host to network (big-endian) byte order on Send, and back to host
+// byte order on Receive. Bytes outside the schema are passed through untouched.
+//
+// Example:
+//
+//	schema := umsbb.BinarySchema{Fields: []umsbb.BinaryField{
+//	    {Offset: 0, Width: 4}, // sensor ID
+//	    {Offset: 4, Width: 8}, // timestamp
+//	}}
+//	normalized := umsbb.ByteOrderNormalizationMiddleware(bus, schema)
+func ByteOrderNormalizationMiddleware(bus Bus, schema BinarySchema) Bus {
+	return &byteOrderBus{
+		bus:    bus,
+		schema: schema,
+	}
+}
+
+// Send converts schema fields to network byte order and forwards the payload
+func (m *byteOrderBus) Send(data []byte, typeID uint32) error {
+	normalized, err := m.schema.convert(data, binary.NativeEndian, binary.BigEndian)
+	if err != nil {
+		return fmt.Errorf("byte order normalization failed: %w", err)
+	}
+	return m.bus.Send(normalized, typeID)
+}
+
+// Receive returns the next payload with schema fields in host byte order
+func (m *byteOrderBus) Receive() ([]byte, error) {
+	return payloadOf(m.ReceiveData())
+}
+
+// ReceiveData returns the next message with schema fields in host byte order
+func (m *byteOrderBus) ReceiveData() (*UniversalData, error) {
+	msg, err := m.bus.ReceiveData()
+	if err != nil || msg == nil {
+		return msg, err
+	}
+
+	host, err := m.schema.convert(msg.Data, binary.BigEndian, binary.NativeEndian)
+	if err != nil {
+		return nil, fmt.Errorf("byte order normalization failed: %w", err)
+	}
+	msg.Data = host
+	return msg, nil
+}
+
+// Close closes the wrapped bus
+func (m *byteOrderBus) Close() error {
+	return m.bus.Close()
+}
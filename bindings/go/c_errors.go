@@ -0,0 +1,56 @@
+// Typed errors for failed calls into the C library
+
+package umsbb
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// BusOSError reports a C call that failed with errno set
+//
+// It unwraps to the syscall.Errno, so callers can test for specific causes:
+//
+//	if errors.Is(err, syscall.ENOMEM) {
+//	    // shrink the buffer or shed load
+//	}
+//
+// It also unwraps to the error the call would otherwise have returned, such
+// as ErrSubmitFailed, so existing checks keep working.
+type BusOSError struct {
+	// Errno is the C errno value read right after the call
+	Errno int
+	// Syscall is the C function that failed
+	Syscall string
+	// Op is the bus operation that made the call
+	Op string
+
+	cause error
+}
+
+// Error implements the error interface
+func (e *BusOSError) Error() string {
+	return fmt.Sprintf("umsbb %s: %s: %v (errno %d)", e.Op, e.Syscall, syscall.Errno(e.Errno), e.Errno)
+}
+
+// Unwrap returns the errno and the underlying bus error
+func (e *BusOSError) Unwrap() []error {
+	errs := []error{syscall.Errno(e.Errno)}
+	if e.cause != nil {
+		errs = append(errs, e.cause)
+	}
+	return errs
+}
+
+// cError converts the errno returned by a two-value cgo call into a
+// *BusOSError, or returns fallback if the call left errno unset
+//
+// cgo clears errno before the call, so a non-zero value was set by fn.
+func cError(op, fn string, errno error, fallback error) error {
+	var e syscall.Errno
+	if !errors.As(errno, &e) || e == 0 {
+		return fallback
+	}
+	return &BusOSError{Errno: int(e), Syscall: fn, Op: op, cause: fallback}
+}
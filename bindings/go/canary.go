@@ -0,0 +1,199 @@
+// Periodic self-addressed canary messages for liveness checks
+
+package umsbb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CanaryTypeID is reserved for canary messages, which receives never return
+const CanaryTypeID uint32 = 0xFFFFFFFF
+
+// ErrReservedTypeID is returned when sending with CanaryTypeID
+var ErrReservedTypeID = errors.New("type ID is reserved for canaries")
+
+// ErrCanaryTimeout is passed to the OnCanaryFailure callback when a canary
+// is not received within twice the canary interval
+var ErrCanaryTimeout = errors.New("canary not received in time")
+
+// canaryPayloadSize is the uint64 sequence number plus the uint64 send time
+const canaryPayloadSize = 16
+
+// canaryAck reports a received canary
+type canaryAck struct {
+	seq uint64
+	rtt time.Duration
+}
+
+// canaryMonitor sends canaries and waits for them to come back
+type canaryMonitor struct {
+	acks chan canaryAck
+	stop chan struct{}
+	done chan struct{}
+}
+
+// canaryState is the canary configuration of a bus
+type canaryState struct {
+	mu        sync.Mutex
+	monitor   *canaryMonitor
+	onFailure atomic.Pointer[func(error)]
+	lastRTT   atomic.Int64
+	active    atomic.Pointer[canaryMonitor]
+}
+
+// StartCanary sends a canary message every interval and checks that it is
+// received within 2*interval
+//
+// Canaries travel through the bus segments like any other message and are
+// taken out when a consumer receives them, so they only come back while
+// something is receiving: a failure means messages are not flowing end to
+// end. They are left out of the WAL, retention, statistics and type
+// frequencies. Calling StartCanary again restarts it with the new interval.
+//
+// Example:
+//
+//	bus.OnCanaryFailure(func(err error) {
+//	    log.Printf("bus liveness check failed: %v", err)
+//	})
+//	bus.StartCanary(time.Second)
+//	defer bus.StopCanary()
+func (b *DirectUniversalBus) StartCanary(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	c := &b.canary
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b.stopCanaryLocked()
+	m := &canaryMonitor{
+		acks: make(chan canaryAck, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	c.monitor = m
+	c.active.Store(m)
+	go b.runCanary(m, interval)
+}
+
+// StopCanary stops sending canaries and waits for the sender to exit
+func (b *DirectUniversalBus) StopCanary() {
+	b.canary.mu.Lock()
+	defer b.canary.mu.Unlock()
+	b.stopCanaryLocked()
+}
+
+// stopCanaryLocked stops the running monitor, if any; callers hold b.canary.mu
+func (b *DirectUniversalBus) stopCanaryLocked() {
+	c := &b.canary
+	if c.monitor == nil {
+		return
+	}
+	c.active.Store(nil)
+	close(c.monitor.stop)
+	<-c.monitor.done
+	c.monitor = nil
+}
+
+// OnCanaryFailure sets the callback for canary send failures and timeouts
+func (b *DirectUniversalBus) OnCanaryFailure(fn func(err error)) {
+	if fn == nil {
+		b.canary.onFailure.Store(nil)
+		return
+	}
+	b.canary.onFailure.Store(&fn)
+}
+
+// CanaryRoundTripLatency returns the send-to-receive time of the last
+// canary that came back, or 0 if none has
+func (b *DirectUniversalBus) CanaryRoundTripLatency() time.Duration {
+	return time.Duration(b.canary.lastRTT.Load())
+}
+
+// runCanary sends one canary per interval and waits for each in turn
+func (b *DirectUniversalBus) runCanary(m *canaryMonitor, interval time.Duration) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for seq := uint64(1); ; seq++ {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+
+		payload := make([]byte, canaryPayloadSize)
+		binary.BigEndian.PutUint64(payload[0:8], seq)
+		binary.BigEndian.PutUint64(payload[8:16], uint64(time.Now().UnixNano()))
+		if err := b.submit(payload, CanaryTypeID); err != nil {
+			b.canaryFailed(fmt.Errorf("canary %d send failed: %w", seq, err))
+			continue
+		}
+
+		if !b.awaitCanary(m, seq, 2*interval) {
+			return
+		}
+	}
+}
+
+// awaitCanary waits for canary seq, reporting a timeout, and returns false
+// if the monitor was stopped
+func (b *DirectUniversalBus) awaitCanary(m *canaryMonitor, seq uint64, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return false
+		case ack := <-m.acks:
+			if ack.seq != seq {
+				continue // A late canary from an earlier round
+			}
+			b.canary.lastRTT.Store(int64(ack.rtt))
+			return true
+		case <-deadline.C:
+			b.canaryFailed(fmt.Errorf("%w: canary %d after %v", ErrCanaryTimeout, seq, timeout))
+			return true
+		}
+	}
+}
+
+// canaryFailed calls the failure callback, if any
+func (b *DirectUniversalBus) canaryFailed(err error) {
+	if fn := b.canary.onFailure.Load(); fn != nil {
+		(*fn)(err)
+	}
+}
+
+// interceptCanary consumes msg if it is a canary, acknowledging it to the
+// running monitor, and reports whether it did
+func (b *DirectUniversalBus) interceptCanary(msg *UniversalData) bool {
+	if msg.TypeID != CanaryTypeID {
+		return false
+	}
+
+	m := b.canary.active.Load()
+	if m == nil || len(msg.Data) != canaryPayloadSize {
+		return true // Stale canary from a stopped monitor
+	}
+
+	sentAt := int64(binary.BigEndian.Uint64(msg.Data[8:16]))
+	ack := canaryAck{
+		seq: binary.BigEndian.Uint64(msg.Data[0:8]),
+		rtt: time.Duration(time.Now().UnixNano() - sentAt),
+	}
+	select {
+	case m.acks <- ack:
+	default:
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package umsbb_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+func TestSendRejectsCanaryTypeID(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t)
+
+	if err := bus.Send([]byte("x"), umsbb.CanaryTypeID); !errors.Is(err, umsbb.ErrReservedTypeID) {
+		t.Fatalf("Send = %v, want ErrReservedTypeID", err)
+	}
+	if n, err := bus.Size(); err != nil || n != 0 {
+		t.Fatalf("Size = %d, %v; want nothing queued", n, err)
+	}
+}
+
+func TestCanariesStayOutOfBookkeeping(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t, umsbb.WithRetention(10))
+
+	bus.StartCanary(5 * time.Millisecond)
+	defer bus.StopCanary()
+
+	deadline := time.Now().Add(time.Second)
+	for bus.CanaryRoundTripLatency() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no canary came back")
+		}
+		if msg, err := bus.ReceiveData(); err != nil || msg != nil {
+			t.Fatalf("ReceiveData = %v, %v; want canaries hidden", msg, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	bus.StopCanary()
+
+	stats := bus.Stats()
+	if stats.TotalSent != 0 || stats.TotalReceived != 0 {
+		t.Fatalf("Stats sent/received = %d/%d, want 0/0", stats.TotalSent, stats.TotalReceived)
+	}
+	if freq := bus.TypeIDFrequency(); len(freq) != 0 {
+		t.Fatalf("TypeIDFrequency = %v, want empty", freq)
+	}
+	if retained := bus.Retained(); len(retained) != 0 {
+		t.Fatalf("Retained = %d messages, want none", len(retained))
+	}
+}
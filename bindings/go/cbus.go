@@ -0,0 +1,327 @@
+//go:build !nocgo
+
+// Bus handles backed by the C library
+//
+// Every call into the C bus goes through this file; build or test with
+// -tags nocgo to use the in-memory backend in cbus_stub.go instead.
+
+package umsbb
+
+/*
+#cgo CFLAGS: -I../../include
+#cgo LDFLAGS: -L../../lib -luniversal_multi_segmented_bi_buffer_bus
+
+#include <stdlib.h>
+#include <string.h>
+#include <stdint.h>
+#include <stdbool.h>
+
+// Language types
+typedef enum {
+    LANG_C = 0,
+    LANG_CPP,
+    LANG_PYTHON,
+    LANG_JAVASCRIPT,
+    LANG_RUST,
+    LANG_GO,
+    LANG_JAVA,
+    LANG_CSHARP,
+    LANG_KOTLIN,
+    LANG_SWIFT
+} language_type_t;
+
+// Universal data structure
+typedef struct {
+    void* data;
+    size_t size;
+    uint32_t type_id;
+    language_type_t source_lang;
+} universal_data_t;
+
+// Scaling configuration
+typedef struct {
+    uint32_t min_producers;
+    uint32_t max_producers;
+    uint32_t min_consumers;
+    uint32_t max_consumers;
+    uint32_t scale_threshold_percent;
+    uint32_t scale_cooldown_ms;
+    bool gpu_preferred;
+    bool auto_balance_load;
+} scaling_config_t;
+
+// Core functions
+void* umsbb_create_direct(size_t buffer_size, uint32_t segment_count, language_type_t lang);
+bool umsbb_submit_direct(void* handle, const universal_data_t* data);
+bool umsbb_submit_direct_to(void* handle, const universal_data_t* data, uint32_t segment_id);
+uint32_t umsbb_segment_count_direct(void* handle);
+int umsbb_submit_direct_if_absent(void* handle, const universal_data_t* data, const void* key, size_t key_len);
+universal_data_t* umsbb_drain_direct(void* handle, language_type_t target_lang);
+universal_data_t* umsbb_drain_direct_from(void* handle, uint32_t segment_id, language_type_t target_lang);
+void umsbb_destroy_direct(void* handle);
+bool umsbb_set_language_priority_direct(void* handle, const language_type_t* order, uint32_t count);
+
+// Optional: older libraries do not export the message count
+__attribute__((weak)) size_t umsbb_message_count(void* handle);
+
+static int64_t umsbb_message_count_or_unknown(void* handle) {
+    if (!umsbb_message_count) return -1;
+    return (int64_t)umsbb_message_count(handle);
+}
+
+// Optional: older libraries do not export the segment limit
+__attribute__((weak)) uint32_t umsbb_max_segment_count(void);
+
+static uint32_t umsbb_max_segment_count_or_unknown(void) {
+    if (!umsbb_max_segment_count) return 0;
+    return umsbb_max_segment_count();
+}
+
+// Optional: older libraries cannot scan segments
+__attribute__((weak)) universal_data_t* umsbb_scan_segment(void* handle, uint32_t segment_id, size_t* cursor,
+                                                           uint32_t type_id, language_type_t target_lang);
+
+static int umsbb_scan_segment_supported(void) {
+    return umsbb_scan_segment != NULL;
+}
+
+// Optional: older libraries cannot pre-fault segments
+__attribute__((weak)) size_t umsbb_warm_segments(void* handle);
+
+static int64_t umsbb_warm_segments_or_unsupported(void* handle) {
+    if (!umsbb_warm_segments) return -1;
+    return (int64_t)umsbb_warm_segments(handle);
+}
+
+// Optional: older libraries cannot compact segments
+__attribute__((weak)) int umsbb_compact_direct(void* handle, size_t segment_capacity);
+
+static int umsbb_compact_direct_or_unsupported(void* handle, size_t segment_capacity) {
+    if (!umsbb_compact_direct) return -2;
+    return umsbb_compact_direct(handle, segment_capacity);
+}
+
+// Scaling functions
+bool configure_auto_scaling(const scaling_config_t* config);
+uint32_t get_optimal_producer_count();
+uint32_t get_optimal_consumer_count();
+void trigger_scale_evaluation();
+
+// Memory management
+universal_data_t* create_universal_data(void* data, size_t size, uint32_t type_id, language_type_t lang);
+void free_universal_data(universal_data_t* data);
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// busHandle is a C bus handle; nil once destroyed
+type busHandle = unsafe.Pointer
+
+// createHandle creates a C bus of segmentCount segments, each bufferSize
+// bytes, and gives each language in order its own segment
+func createHandle(op string, bufferSize uint64, segmentCount uint32, order []LanguageType) (busHandle, error) {
+	handle, errno := C.umsbb_create_direct(C.size_t(bufferSize), C.uint32_t(segmentCount), C.LANG_GO)
+	if handle == nil {
+		return nil, cError(op, "umsbb_create_direct", errno, errors.New("failed to create Universal Bus"))
+	}
+	if err := setLanguagePriority(handle, order); err != nil {
+		C.umsbb_destroy_direct(handle)
+		return nil, err
+	}
+	return handle, nil
+}
+
+// setLanguagePriority gives each language in order its own segment on handle
+func setLanguagePriority(handle busHandle, order []LanguageType) error {
+	if len(order) == 0 {
+		return nil
+	}
+
+	langs := make([]C.language_type_t, len(order))
+	for i, lang := range order {
+		langs[i] = C.language_type_t(lang)
+	}
+	ok, errno := C.umsbb_set_language_priority_direct(handle, &langs[0], C.uint32_t(len(langs)))
+	if !bool(ok) {
+		return cError("create", "umsbb_set_language_priority_direct", errno, errors.New("failed to set language priority"))
+	}
+	return nil
+}
+
+// destroyHandle frees handle and every message still queued on it
+func destroyHandle(handle busHandle) {
+	C.umsbb_destroy_direct(handle)
+}
+
+// handleSegmentCount returns the number of segments the C library created
+func handleSegmentCount(handle busHandle) uint32 {
+	return uint32(C.umsbb_segment_count_direct(handle))
+}
+
+// submitHandle copies data into C memory and enqueues it on segment, or
+// routes it by type ID when segment is negative
+//
+// A full or invalid segment is reported as ErrSubmitFailed.
+func submitHandle(handle busHandle, data []byte, typeID uint32, segment int) error {
+	cData := C.malloc(C.size_t(len(data)))
+	if cData == nil {
+		return errors.New("memory allocation failed")
+	}
+	defer C.free(cData)
+	C.memcpy(cData, unsafe.Pointer(&data[0]), C.size_t(len(data)))
+
+	udata, errno := C.create_universal_data(cData, C.size_t(len(data)), C.uint32_t(typeID), C.LANG_GO)
+	if udata == nil {
+		return cError("submit", "create_universal_data", errno, errors.New("failed to create universal data"))
+	}
+	defer C.free_universal_data(udata)
+
+	var submitted C.bool
+	fn := "umsbb_submit_direct"
+	if segment < 0 {
+		submitted, errno = C.umsbb_submit_direct(handle, udata)
+	} else {
+		fn = "umsbb_submit_direct_to"
+		submitted, errno = C.umsbb_submit_direct_to(handle, udata, C.uint32_t(segment))
+	}
+	if !bool(submitted) {
+		return cError("submit", fn, errno, ErrSubmitFailed)
+	}
+	return nil
+}
+
+// submitHandleIfAbsent enqueues data unless a message with key is pending,
+// reporting a duplicate as false with a nil error
+func submitHandleIfAbsent(handle busHandle, data []byte, typeID uint32, key []byte) (bool, error) {
+	cData := C.CBytes(data)
+	defer C.free(cData)
+	cKey := C.CBytes(key)
+	defer C.free(cKey)
+
+	udata, errno := C.create_universal_data(cData, C.size_t(len(data)), C.uint32_t(typeID), C.LANG_GO)
+	if udata == nil {
+		return false, cError("submit", "create_universal_data", errno, errors.New("failed to create universal data"))
+	}
+	defer C.free_universal_data(udata)
+
+	result, errno := C.umsbb_submit_direct_if_absent(handle, udata, cKey, C.size_t(len(key)))
+	switch result {
+	case 1:
+		return true, nil
+	case 0:
+		return false, nil
+	default:
+		return false, cError("submit", "umsbb_submit_direct_if_absent", errno, ErrSubmitFailed)
+	}
+}
+
+// drainHandle copies the next message out of one segment, or out of any
+// segment when segment is negative, returning nil when none is queued
+func drainHandle(handle busHandle, segment int) *UniversalData {
+	var udataPtr *C.universal_data_t
+	if segment < 0 {
+		udataPtr = C.umsbb_drain_direct(handle, C.LANG_GO)
+	} else {
+		udataPtr = C.umsbb_drain_direct_from(handle, C.uint32_t(segment), C.LANG_GO)
+	}
+	if udataPtr == nil {
+		return nil
+	}
+	defer C.free_universal_data(udataPtr)
+
+	udata := *udataPtr
+	if udata.data == nil || udata.size == 0 {
+		return nil
+	}
+	return copyUniversalData(udata)
+}
+
+// copyUniversalData copies a C message into Go memory
+func copyUniversalData(udata C.universal_data_t) *UniversalData {
+	result := make([]byte, udata.size)
+	if udata.size > 0 {
+		C.memcpy(unsafe.Pointer(&result[0]), udata.data, udata.size)
+	}
+	return &UniversalData{
+		Data:       result,
+		TypeID:     uint32(udata.type_id),
+		SourceLang: LanguageType(udata.source_lang),
+	}
+}
+
+// scanSupported reports whether the C library exports umsbb_scan_segment
+func scanSupported() bool {
+	return C.umsbb_scan_segment_supported() != 0
+}
+
+// scanHandle copies the next message of typeID at or after cursor in
+// segment without draining it, returning nil when none remain
+func scanHandle(handle busHandle, segment uint32, cursor *uint64, typeID uint32) *UniversalData {
+	pos := C.size_t(*cursor)
+	udataPtr := C.umsbb_scan_segment(handle, C.uint32_t(segment), &pos, C.uint32_t(typeID), C.LANG_GO)
+	*cursor = uint64(pos)
+	if udataPtr == nil {
+		return nil
+	}
+	defer C.free_universal_data(udataPtr)
+	return copyUniversalData(*udataPtr)
+}
+
+// handleMessageCount returns the messages queued on handle, or -1 if the C
+// library does not export umsbb_message_count
+func handleMessageCount(handle busHandle) int64 {
+	return int64(C.umsbb_message_count_or_unknown(handle))
+}
+
+// librarySegmentLimit returns the C library's segment limit, or 0 if it does
+// not report one
+func librarySegmentLimit() uint32 {
+	return uint32(C.umsbb_max_segment_count_or_unknown())
+}
+
+// warmHandle faults in every segment page and returns the number touched,
+// or -1 if the C library cannot pre-fault segments
+func warmHandle(handle busHandle) int64 {
+	return int64(C.umsbb_warm_segments_or_unsupported(handle))
+}
+
+// compactHandle resizes every empty segment to segmentCapacity bytes and
+// returns how many it resized, -1 for an invalid capacity, or -2 if the C
+// library cannot compact
+func compactHandle(handle busHandle, segmentCapacity uint64) int {
+	return int(C.umsbb_compact_direct_or_unsupported(handle, C.size_t(segmentCapacity)))
+}
+
+// configureAutoScaling passes cfg to the C library's auto-scaler
+func configureAutoScaling(cfg ScalingConfig) bool {
+	config := C.scaling_config_t{
+		min_producers:           C.uint32_t(cfg.MinProducers),
+		max_producers:           C.uint32_t(cfg.MaxProducers),
+		min_consumers:           C.uint32_t(cfg.MinConsumers),
+		max_consumers:           C.uint32_t(cfg.MaxConsumers),
+		scale_threshold_percent: C.uint32_t(cfg.ScaleThresholdPercent),
+		scale_cooldown_ms:       C.uint32_t(cfg.ScaleCooldownMs),
+		gpu_preferred:           C.bool(cfg.GPUPreferred),
+		auto_balance_load:       C.bool(cfg.AutoBalanceLoad),
+	}
+	return bool(C.configure_auto_scaling(&config))
+}
+
+// optimalProducerCount returns the auto-scaler's producer count
+func optimalProducerCount() uint32 {
+	return uint32(C.get_optimal_producer_count())
+}
+
+// optimalConsumerCount returns the auto-scaler's consumer count
+func optimalConsumerCount() uint32 {
+	return uint32(C.get_optimal_consumer_count())
+}
+
+// triggerScaleEvaluation asks the auto-scaler to re-evaluate now
+func triggerScaleEvaluation() {
+	C.trigger_scale_evaluation()
+}
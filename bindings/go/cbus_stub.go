@@ -0,0 +1,308 @@
+//go:build nocgo
+
+// In-memory bus handles for builds without the C library
+//
+// Build or test with -tags nocgo to run the bus without cgo. Segments are
+// Go queues that follow the C library's routing, capacity and draining
+// rules closely enough for tests; GPU calls are stubbed as with nogpu.
+
+package umsbb
+
+import (
+	"errors"
+	"sync"
+	"syscall"
+)
+
+// memorySegmentLimit matches MAX_AGENTS in segment_ring.h
+const memorySegmentLimit = 16
+
+// memoryMessage is one queued message with its pending key, if any
+type memoryMessage struct {
+	data   []byte
+	typeID uint32
+	lang   LanguageType
+	key    string
+	hasKey bool
+}
+
+// memorySegment is one segment's queue
+type memorySegment struct {
+	queue    []memoryMessage
+	drained  uint64 // messages drained so far; scan cursors count from 0
+	bytes    uint64
+	capacity uint64
+}
+
+// memoryBus stands in for a C bus handle
+type memoryBus struct {
+	mu       sync.Mutex
+	segments []memorySegment
+	lanes    []LanguageType
+	pending  map[string]bool
+	count    int64
+	destroys int // calls to destroyHandle, for tests
+}
+
+// busHandle is an in-memory bus; nil once destroyed
+type busHandle = *memoryBus
+
+// memoryScaling holds the configuration passed to configureAutoScaling
+var memoryScaling struct {
+	mu     sync.Mutex
+	config ScalingConfig
+}
+
+// createHandle creates an in-memory bus of segmentCount segments, each
+// holding up to bufferSize bytes, and gives each language in order its own
+// segment
+func createHandle(op string, bufferSize uint64, segmentCount uint32, order []LanguageType) (busHandle, error) {
+	if segmentCount == 0 {
+		segmentCount = optimalProducerCount() + optimalConsumerCount()
+	}
+	if bufferSize == 0 || segmentCount > memorySegmentLimit {
+		return nil, errors.New("failed to create Universal Bus")
+	}
+	if len(order) > memorySegmentLimit {
+		return nil, errors.New("failed to set language priority")
+	}
+
+	handle := &memoryBus{
+		segments: make([]memorySegment, segmentCount),
+		lanes:    append([]LanguageType(nil), order...),
+		pending:  make(map[string]bool),
+	}
+	for i := range handle.segments {
+		handle.segments[i].capacity = bufferSize
+	}
+	return handle, nil
+}
+
+// destroyHandle drops every message still queued on handle
+func destroyHandle(handle busHandle) {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+
+	handle.segments = nil
+	handle.pending = nil
+	handle.count = 0
+	handle.destroys++
+}
+
+// handleSegmentCount returns the number of segments in handle
+func handleSegmentCount(handle busHandle) uint32 {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	return uint32(len(handle.segments))
+}
+
+// route picks the segment for a Go producer the way direct_route does
+//
+// Callers must hold handle.mu.
+func (h *memoryBus) route(typeID uint32) int {
+	for i, lang := range h.lanes {
+		if lang == LangGo {
+			return i % len(h.segments)
+		}
+	}
+	return int(typeID % uint32(len(h.segments)))
+}
+
+// enqueue appends msg to segment if it fits
+//
+// Callers must hold handle.mu.
+func (h *memoryBus) enqueue(segment int, msg memoryMessage) bool {
+	if segment < 0 || segment >= len(h.segments) {
+		return false
+	}
+	seg := &h.segments[segment]
+	if seg.bytes+uint64(len(msg.data)) > seg.capacity {
+		return false
+	}
+	seg.queue = append(seg.queue, msg)
+	seg.bytes += uint64(len(msg.data))
+	h.count++
+	return true
+}
+
+// submitHandle copies data and enqueues it on segment, or routes it by type
+// ID when segment is negative
+//
+// A full or invalid segment is reported as ErrSubmitFailed, and a destroyed
+// handle as EBADF, as the C library does.
+func submitHandle(handle busHandle, data []byte, typeID uint32, segment int) error {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+
+	if handle.segments == nil {
+		return cError("submit", "umsbb_submit_direct", syscall.EBADF, ErrSubmitFailed)
+	}
+	if segment < 0 {
+		segment = handle.route(typeID)
+	}
+	msg := memoryMessage{data: append([]byte(nil), data...), typeID: typeID, lang: LangGo}
+	if !handle.enqueue(segment, msg) {
+		return ErrSubmitFailed
+	}
+	return nil
+}
+
+// submitHandleIfAbsent enqueues data unless a message with key is pending,
+// reporting a duplicate as false with a nil error
+func submitHandleIfAbsent(handle busHandle, data []byte, typeID uint32, key []byte) (bool, error) {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+
+	if handle.segments == nil {
+		return false, cError("submit", "umsbb_submit_direct_if_absent", syscall.EBADF, ErrSubmitFailed)
+	}
+	if handle.pending[string(key)] {
+		return false, nil
+	}
+	msg := memoryMessage{
+		data:   append([]byte(nil), data...),
+		typeID: typeID,
+		lang:   LangGo,
+		key:    string(key),
+		hasKey: true,
+	}
+	if !handle.enqueue(handle.route(typeID), msg) {
+		return false, ErrSubmitFailed
+	}
+	handle.pending[msg.key] = true
+	return true, nil
+}
+
+// drainHandle takes the next message out of one segment, or out of the
+// lowest-numbered non-empty segment when segment is negative, returning nil
+// when none is queued
+func drainHandle(handle busHandle, segment int) *UniversalData {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+
+	if segment >= len(handle.segments) {
+		return nil
+	}
+	if segment >= 0 {
+		return handle.drainSegment(segment)
+	}
+	for i := range handle.segments {
+		if msg := handle.drainSegment(i); msg != nil {
+			return msg
+		}
+	}
+	return nil
+}
+
+// drainSegment pops the head of segment, releasing its pending key
+//
+// Callers must hold handle.mu.
+func (h *memoryBus) drainSegment(segment int) *UniversalData {
+	seg := &h.segments[segment]
+	if len(seg.queue) == 0 {
+		return nil
+	}
+	msg := seg.queue[0]
+	seg.queue[0] = memoryMessage{}
+	seg.queue = seg.queue[1:]
+	seg.drained++
+	seg.bytes -= uint64(len(msg.data))
+	h.count--
+	if msg.hasKey {
+		delete(h.pending, msg.key)
+	}
+	return &UniversalData{Data: msg.data, TypeID: msg.typeID, SourceLang: msg.lang}
+}
+
+// scanSupported reports that in-memory buses can always be scanned
+func scanSupported() bool {
+	return true
+}
+
+// scanHandle copies the next message of typeID at or after cursor in
+// segment without draining it, returning nil when none remain
+func scanHandle(handle busHandle, segment uint32, cursor *uint64, typeID uint32) *UniversalData {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+
+	if int(segment) >= len(handle.segments) {
+		return nil
+	}
+	seg := &handle.segments[segment]
+	pos := max(*cursor, seg.drained) // drains may have passed the cursor
+	for ; pos < seg.drained+uint64(len(seg.queue)); pos++ {
+		msg := seg.queue[pos-seg.drained]
+		if msg.typeID != typeID {
+			continue
+		}
+		*cursor = pos + 1
+		return &UniversalData{Data: append([]byte(nil), msg.data...), TypeID: msg.typeID, SourceLang: msg.lang}
+	}
+	*cursor = pos
+	return nil
+}
+
+// handleMessageCount returns the messages queued on handle
+func handleMessageCount(handle busHandle) int64 {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	return handle.count
+}
+
+// librarySegmentLimit returns the in-memory segment limit
+func librarySegmentLimit() uint32 {
+	return memorySegmentLimit
+}
+
+// warmHandle reports no pages touched; Go memory is faulted in on use
+func warmHandle(handle busHandle) int64 {
+	return 0
+}
+
+// compactHandle sets the capacity of every empty segment to
+// segmentCapacity bytes and returns how many it changed, or -1 for a zero
+// capacity
+func compactHandle(handle busHandle, segmentCapacity uint64) int {
+	if segmentCapacity == 0 {
+		return -1
+	}
+
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+
+	resized := 0
+	for i := range handle.segments {
+		seg := &handle.segments[i]
+		if seg.capacity == segmentCapacity || len(seg.queue) > 0 {
+			continue
+		}
+		seg.capacity = segmentCapacity
+		resized++
+	}
+	return resized
+}
+
+// configureAutoScaling records cfg; the in-memory backend never rescales
+func configureAutoScaling(cfg ScalingConfig) bool {
+	memoryScaling.mu.Lock()
+	defer memoryScaling.mu.Unlock()
+	memoryScaling.config = cfg
+	return true
+}
+
+// optimalProducerCount returns the configured minimum producers, at least 1
+func optimalProducerCount() uint32 {
+	memoryScaling.mu.Lock()
+	defer memoryScaling.mu.Unlock()
+	return max(memoryScaling.config.MinProducers, 1)
+}
+
+// optimalConsumerCount returns the configured minimum consumers, at least 1
+func optimalConsumerCount() uint32 {
+	memoryScaling.mu.Lock()
+	defer memoryScaling.mu.Unlock()
+	return max(memoryScaling.config.MinConsumers, 1)
+}
+
+// triggerScaleEvaluation does nothing; the in-memory backend never rescales
+func triggerScaleEvaluation() {}
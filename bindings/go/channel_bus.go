@@ -0,0 +1,116 @@
+// Channel-backed bus for exercising bus consumers without CGo
+
+package umsbb
+
+import (
+	"errors"
+	"sync"
+)
+
+// ChannelBus implements Bus over buffered Go channels
+//
+// Messages sent on a ChannelBus are delivered to the receive channel it was
+// built with, so tests can stand in for DirectUniversalBus without linking
+// the C library.
+type ChannelBus struct {
+	send   chan<- UniversalData
+	recv   <-chan UniversalData
+	mu     sync.RWMutex
+	closed bool
+}
+
+var _ Bus = (*ChannelBus)(nil)
+
+// NewChannelBus creates a bus that sends on send and receives from recv
+//
+// Example:
+//
+//	ch := make(chan umsbb.UniversalData, 64)
+//	bus := umsbb.NewChannelBus(ch, ch)
+//	_ = bus.Send([]byte("hello"), 1)
+func NewChannelBus(send chan<- UniversalData, recv <-chan UniversalData) *ChannelBus {
+	return &ChannelBus{
+		send: send,
+		recv: recv,
+	}
+}
+
+// NewLoopbackChannelBus creates a bus whose sends are received by itself
+func NewLoopbackChannelBus(capacity int) *ChannelBus {
+	ch := make(chan UniversalData, capacity)
+	return NewChannelBus(ch, ch)
+}
+
+// NewChannelBusPair creates two buses where each receives what the other sends
+//
+// Example:
+//
+//	client, server := umsbb.NewChannelBusPair(64)
+//	_ = client.Send([]byte("ping"), 1)
+//	data, _ := server.Receive()
+func NewChannelBusPair(capacity int) (*ChannelBus, *ChannelBus) {
+	aToB := make(chan UniversalData, capacity)
+	bToA := make(chan UniversalData, capacity)
+	return NewChannelBus(aToB, bToA), NewChannelBus(bToA, aToB)
+}
+
+// Send copies data onto the send channel, failing if the channel is full
+func (cb *ChannelBus) Send(data []byte, typeID uint32) error {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	if cb.closed {
+		return errors.New("bus is closed")
+	}
+
+	if len(data) == 0 {
+		return errors.New("data cannot be empty")
+	}
+
+	msg := UniversalData{
+		Data:       append([]byte(nil), data...),
+		TypeID:     typeID,
+		SourceLang: LangGo,
+	}
+
+	select {
+	case cb.send <- msg:
+		return nil
+	default:
+		return ErrSubmitFailed
+	}
+}
+
+// Receive returns the next payload, or nil if nothing is available
+func (cb *ChannelBus) Receive() ([]byte, error) {
+	return payloadOf(cb.ReceiveData())
+}
+
+// ReceiveData returns the next message, or nil if nothing is available
+func (cb *ChannelBus) ReceiveData() (*UniversalData, error) {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	if cb.closed {
+		return nil, errors.New("bus is closed")
+	}
+
+	select {
+	case msg, ok := <-cb.recv:
+		if !ok {
+			return nil, errors.New("bus is closed")
+		}
+		return &msg, nil
+	default:
+		return nil, nil
+	}
+}
+
+// Close marks the bus closed; the channels are left open for their owner
+func (cb *ChannelBus) Close() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.closed = true
+	return nil
+}
@@ -0,0 +1,201 @@
+// Message checksums for detecting corrupted payloads
+
+package umsbb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"math/bits"
+)
+
+// ErrChecksumMismatch is returned when a received payload fails its checksum
+var ErrChecksumMismatch = errors.New("message checksum mismatch")
+
+// ChecksumAlgorithm selects the checksum appended by ChecksumMiddleware
+type ChecksumAlgorithm int
+
+const (
+	// CRC32 appends a 4-byte IEEE CRC-32
+	CRC32 ChecksumAlgorithm = iota
+	// FNV64 appends an 8-byte FNV-1a hash
+	FNV64
+	// XXH64 appends an 8-byte xxHash64 with seed 0
+	XXH64
+)
+
+// String returns the algorithm name
+func (a ChecksumAlgorithm) String() string {
+	switch a {
+	case CRC32:
+		return "CRC32"
+	case FNV64:
+		return "FNV64"
+	case XXH64:
+		return "XXH64"
+	default:
+		return fmt.Sprintf("ChecksumAlgorithm(%d)", int(a))
+	}
+}
+
+// size returns the number of checksum bytes appended to each payload
+func (a ChecksumAlgorithm) size() int {
+	if a == CRC32 {
+		return 4
+	}
+	return 8
+}
+
+// sum returns the big-endian checksum of data
+func (a ChecksumAlgorithm) sum(data []byte) []byte {
+	switch a {
+	case CRC32:
+		return binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(data))
+	case FNV64:
+		h := fnv.New64a()
+		h.Write(data)
+		return h.Sum(nil)
+	default:
+		return binary.BigEndian.AppendUint64(nil, xxh64(data))
+	}
+}
+
+var _ Bus = (*ChecksumBus)(nil)
+
+// ChecksumBus appends a checksum to every payload it sends and verifies it
+// on every payload it receives
+type ChecksumBus struct {
+	bus        Bus
+	alg        ChecksumAlgorithm
+	deadLetter Bus
+}
+
+// ChecksumMiddleware wraps bus so payloads carry a checksum computed with alg
+//
+// Both ends of the bus must use the middleware with the same algorithm. A
+// payload that fails verification is returned as ErrChecksumMismatch and,
+// if a dead-letter bus is set, sent there exactly as received.
+//
+// Example:
+//
+//	checked := umsbb.ChecksumMiddleware(bus, umsbb.CRC32).WithDeadLetter(dlq)
+//	data, err := checked.Receive()
+//	if errors.Is(err, umsbb.ErrChecksumMismatch) {
+//	    log.Printf("dropped corrupted message: %v", err)
+//	}
+func ChecksumMiddleware(bus Bus, alg ChecksumAlgorithm) *ChecksumBus {
+	return &ChecksumBus{bus: bus, alg: alg}
+}
+
+// WithDeadLetter sends payloads that fail verification to bus
+func (c *ChecksumBus) WithDeadLetter(bus Bus) *ChecksumBus {
+	c.deadLetter = bus
+	return c
+}
+
+// Send appends the checksum to data and forwards it
+func (c *ChecksumBus) Send(data []byte, typeID uint32) error {
+	framed := make([]byte, 0, len(data)+c.alg.size())
+	framed = append(framed, data...)
+	framed = append(framed, c.alg.sum(data)...)
+	if direct, ok := c.bus.(*DirectUniversalBus); ok {
+		return direct.sendFramed(data, framed, typeID) // schemas describe data, not the checksum
+	}
+	return c.bus.Send(framed, typeID)
+}
+
+// Receive returns the next verified payload
+func (c *ChecksumBus) Receive() ([]byte, error) {
+	return payloadOf(c.ReceiveData())
+}
+
+// ReceiveData returns the next message with its checksum verified and removed
+func (c *ChecksumBus) ReceiveData() (*UniversalData, error) {
+	msg, err := c.bus.ReceiveData()
+	if err != nil || msg == nil {
+		return msg, err
+	}
+
+	n := len(msg.Data) - c.alg.size()
+	if n < 0 || string(c.alg.sum(msg.Data[:n])) != string(msg.Data[n:]) {
+		err := fmt.Errorf("%w: %s, type ID %d", ErrChecksumMismatch, c.alg, msg.TypeID)
+		if c.deadLetter != nil {
+			if dlqErr := c.deadLetter.Send(msg.Data, msg.TypeID); dlqErr != nil {
+				return nil, errors.Join(err, fmt.Errorf("dead-letter send failed: %w", dlqErr))
+			}
+		}
+		return nil, err
+	}
+
+	msg.Data = msg.Data[:n]
+	return msg, nil
+}
+
+// Close closes the wrapped bus
+func (c *ChecksumBus) Close() error {
+	return c.bus.Close()
+}
+
+// xxHash64 primes
+const (
+	xxhPrime1 uint64 = 11400714785074694791
+	xxhPrime2 uint64 = 14029467366897019727
+	xxhPrime3 uint64 = 1609587929392839161
+	xxhPrime4 uint64 = 9650029242287828579
+	xxhPrime5 uint64 = 2870177450012600261
+)
+
+// xxh64 returns the xxHash64 of data with seed 0
+func xxh64(data []byte) uint64 {
+	n := uint64(len(data))
+
+	var seed, h uint64
+	if len(data) >= 32 {
+		v1 := seed + xxhPrime1 + xxhPrime2
+		v2 := seed + xxhPrime2
+		v3 := seed
+		v4 := seed - xxhPrime1
+		for ; len(data) >= 32; data = data[32:] {
+			v1 = xxhRound(v1, binary.LittleEndian.Uint64(data[0:8]))
+			v2 = xxhRound(v2, binary.LittleEndian.Uint64(data[8:16]))
+			v3 = xxhRound(v3, binary.LittleEndian.Uint64(data[16:24]))
+			v4 = xxhRound(v4, binary.LittleEndian.Uint64(data[24:32]))
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		for _, v := range [4]uint64{v1, v2, v3, v4} {
+			h ^= xxhRound(0, v)
+			h = h*xxhPrime1 + xxhPrime4
+		}
+	} else {
+		h = seed + xxhPrime5
+	}
+	h += n
+
+	for ; len(data) >= 8; data = data[8:] {
+		h ^= xxhRound(0, binary.LittleEndian.Uint64(data))
+		h = bits.RotateLeft64(h, 27)*xxhPrime1 + xxhPrime4
+	}
+	if len(data) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(data)) * xxhPrime1
+		h = bits.RotateLeft64(h, 23)*xxhPrime2 + xxhPrime3
+		data = data[4:]
+	}
+	for _, b := range data {
+		h ^= uint64(b) * xxhPrime5
+		h = bits.RotateLeft64(h, 11) * xxhPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxhPrime2
+	h ^= h >> 29
+	h *= xxhPrime3
+	h ^= h >> 32
+	return h
+}
+
+// xxhRound mixes one 8-byte lane into acc
+func xxhRound(acc, lane uint64) uint64 {
+	return bits.RotateLeft64(acc+lane*xxhPrime2, 31) * xxhPrime1
+}
@@ -0,0 +1,42 @@
+//go:build nocgo
+
+package umsbb
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestConcurrentCloseDestroysHandleOnce(t *testing.T) {
+	bus, err := NewDirectUniversalBus(64*1024, 1, false, false)
+	if err != nil {
+		t.Fatalf("NewDirectUniversalBus failed: %v", err)
+	}
+	handle := bus.handle
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make([]error, 100)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = bus.Close()
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != errs[0] {
+			t.Errorf("Close %d returned %v, want the first result %v", i, err, errs[0])
+		}
+	}
+	if handle.destroys != 1 {
+		t.Fatalf("handle destroyed %d times, want 1", handle.destroys)
+	}
+	if bus.handle != nil {
+		t.Fatal("handle still set after Close")
+	}
+}
@@ -0,0 +1,114 @@
+// Payload codecs used by the typed send and receive helpers
+
+package umsbb
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Codec converts Go values to and from bus payloads
+type Codec interface {
+	// Marshal encodes v into a payload
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes payload into the value pointed to by v
+	Unmarshal(payload []byte, v any) error
+}
+
+// GobCodec encodes values with encoding/gob for Go-to-Go communication
+//
+// Every payload is a self-contained gob stream carrying its own type
+// definitions, so any Go process can decode it without shared encoder state.
+// Concrete types carried in interface fields must be registered with
+// gob.Register on both sides.
+type GobCodec struct {
+	buffers sync.Pool
+}
+
+var _ Codec = (*GobCodec)(nil)
+
+// defaultGobCodec backs SendGob and ReceiveGob
+var defaultGobCodec = &GobCodec{}
+
+// getBuffer returns an empty pooled buffer
+func (c *GobCodec) getBuffer() *bytes.Buffer {
+	if buf, ok := c.buffers.Get().(*bytes.Buffer); ok {
+		buf.Reset()
+		return buf
+	}
+	return &bytes.Buffer{}
+}
+
+// Marshal encodes v as a standalone gob stream
+func (c *GobCodec) Marshal(v any) ([]byte, error) {
+	buf := c.getBuffer()
+	defer c.buffers.Put(buf)
+
+	// gob encoders only describe a type the first time they see it, so each
+	// payload needs its own encoder; only the backing buffer is reused
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to gob-encode %T: %w", v, err)
+	}
+	return append([]byte(nil), buf.Bytes()...), nil
+}
+
+// Unmarshal decodes a standalone gob stream into the value pointed to by v
+func (c *GobCodec) Unmarshal(payload []byte, v any) error {
+	if rv := reflect.ValueOf(v); rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("gob target must be a non-nil pointer, got %T", v)
+	}
+
+	buf := c.getBuffer()
+	defer c.buffers.Put(buf)
+	buf.Write(payload)
+
+	if err := gob.NewDecoder(buf).Decode(v); err != nil {
+		return fmt.Errorf("gob payload does not decode into %T: %w", v, err)
+	}
+	if buf.Len() > 0 {
+		return errors.New("unexpected data after gob value")
+	}
+	return nil
+}
+
+// SendGob encodes v with encoding/gob and sends it tagged with typeID
+//
+// Example:
+//
+//	err := bus.SendGob(ctx, SensorReading{Celsius: 21.5}, 1)
+func (b *DirectUniversalBus) SendGob(ctx context.Context, v any, typeID uint32) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	payload, err := defaultGobCodec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Send(payload, typeID)
+}
+
+// ReceiveGob waits for the next message and decodes its gob payload into target
+//
+// ReceiveGob polls the bus until a message arrives or ctx is done. An error
+// naming the target type is returned when the payload was encoded from an
+// incompatible type.
+//
+// Example:
+//
+//	var reading SensorReading
+//	if err := bus.ReceiveGob(ctx, &reading); err != nil {
+//	    log.Printf("ReceiveGob failed: %v", err)
+//	}
+func (b *DirectUniversalBus) ReceiveGob(ctx context.Context, target any) error {
+	data, err := receiveContext(ctx, b)
+	if err != nil {
+		return err
+	}
+	return defaultGobCodec.Unmarshal(data, target)
+}
@@ -0,0 +1,168 @@
+// ScalingConfig serialization for configuration management tools
+
+package umsbb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Supported ScalingConfig serialization formats
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Validate checks that the scaling bounds are consistent
+//
+// The returned error joins every ConfigError from ValidateScalingConfig.
+func (c ScalingConfig) Validate() error {
+	var errs []error
+	for _, err := range ValidateScalingConfig(c) {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// MarshalScalingConfig validates cfg and encodes it as "json" or "yaml"
+//
+// Example:
+//
+//	data, err := umsbb.MarshalScalingConfig(cfg, umsbb.FormatYAML)
+//	if err == nil {
+//	    _ = os.WriteFile("scaling.yaml", data, 0o644)
+//	}
+func MarshalScalingConfig(cfg ScalingConfig, format string) ([]byte, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scaling config: %w", err)
+	}
+
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return json.MarshalIndent(cfg, "", "  ")
+	case FormatYAML:
+		return marshalScalingYAML(cfg), nil
+	default:
+		return nil, fmt.Errorf("unsupported scaling config format %q", format)
+	}
+}
+
+// UnmarshalScalingConfig decodes a "json" or "yaml" scaling config and validates it
+//
+// Unknown keys are rejected so typos in managed configuration files are caught.
+func UnmarshalScalingConfig(data []byte, format string) (ScalingConfig, error) {
+	var cfg ScalingConfig
+
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return ScalingConfig{}, fmt.Errorf("failed to decode scaling config: %w", err)
+		}
+	case FormatYAML:
+		if err := unmarshalScalingYAML(data, &cfg); err != nil {
+			return ScalingConfig{}, fmt.Errorf("failed to decode scaling config: %w", err)
+		}
+	default:
+		return ScalingConfig{}, fmt.Errorf("unsupported scaling config format %q", format)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return ScalingConfig{}, fmt.Errorf("invalid scaling config: %w", err)
+	}
+	return cfg, nil
+}
+
+// scalingFields maps each serialized key to its ScalingConfig field
+func scalingFields(cfg *ScalingConfig) ([]string, map[string]any) {
+	keys := []string{
+		"min_producers", "max_producers", "min_consumers", "max_consumers",
+		"scale_threshold_percent", "scale_cooldown_ms", "gpu_preferred", "auto_balance_load",
+	}
+	fields := map[string]any{
+		"min_producers":           &cfg.MinProducers,
+		"max_producers":           &cfg.MaxProducers,
+		"min_consumers":           &cfg.MinConsumers,
+		"max_consumers":           &cfg.MaxConsumers,
+		"scale_threshold_percent": &cfg.ScaleThresholdPercent,
+		"scale_cooldown_ms":       &cfg.ScaleCooldownMs,
+		"gpu_preferred":           &cfg.GPUPreferred,
+		"auto_balance_load":       &cfg.AutoBalanceLoad,
+	}
+	return keys, fields
+}
+
+// marshalScalingYAML writes cfg as a flat YAML mapping
+func marshalScalingYAML(cfg ScalingConfig) []byte {
+	keys, fields := scalingFields(&cfg)
+
+	var buf bytes.Buffer
+	for _, key := range keys {
+		switch v := fields[key].(type) {
+		case *uint32:
+			fmt.Fprintf(&buf, "%s: %d\n", key, *v)
+		case *bool:
+			fmt.Fprintf(&buf, "%s: %t\n", key, *v)
+		}
+	}
+	return buf.Bytes()
+}
+
+// unmarshalScalingYAML reads the flat YAML mapping written by marshalScalingYAML
+//
+// Only the subset needed for ScalingConfig is supported: one "key: value"
+// scalar per line, optional quoting, comments and a leading document marker.
+func unmarshalScalingYAML(data []byte, cfg *ScalingConfig) error {
+	_, fields := scalingFields(cfg)
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || line == "---" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		field, known := fields[key]
+		if !known {
+			return fmt.Errorf("line %d: unknown key %q", lineNo, key)
+		}
+		if seen[key] {
+			return fmt.Errorf("line %d: duplicate key %q", lineNo, key)
+		}
+		seen[key] = true
+
+		switch f := field.(type) {
+		case *uint32:
+			n, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return fmt.Errorf("line %d: %s must be an unsigned integer: %w", lineNo, key, err)
+			}
+			*f = uint32(n)
+		case *bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("line %d: %s must be true or false: %w", lineNo, key, err)
+			}
+			*f = b
+		}
+	}
+	return scanner.Err()
+}
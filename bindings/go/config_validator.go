@@ -0,0 +1,69 @@
+// Up-front validation of bus and scaling configuration
+
+package umsbb
+
+import "fmt"
+
+// maxSegmentCount is the largest segment count the C library handles reliably,
+// used when the library does not report its own limit
+const maxSegmentCount = 64
+
+// ConfigError describes one invalid configuration value
+type ConfigError struct {
+	Field  string
+	Value  any
+	Reason string
+}
+
+// Error implements the error interface
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("%s = %v: %s", e.Field, e.Value, e.Reason)
+}
+
+// ValidateScalingConfig returns every problem found in cfg, or nil if it is valid
+//
+// Example:
+//
+//	for _, problem := range umsbb.ValidateScalingConfig(cfg) {
+//	    log.Printf("scaling config: %v", problem)
+//	}
+func ValidateScalingConfig(cfg ScalingConfig) []ConfigError {
+	var errs []ConfigError
+	if cfg.ScaleThresholdPercent < 1 || cfg.ScaleThresholdPercent > 100 {
+		errs = append(errs, ConfigError{"scale_threshold_percent", cfg.ScaleThresholdPercent, "must be in 1..100"})
+	}
+	if cfg.MinProducers < 1 {
+		errs = append(errs, ConfigError{"min_producers", cfg.MinProducers, "must be at least 1"})
+	}
+	if cfg.MaxProducers == 0 {
+		errs = append(errs, ConfigError{"max_producers", cfg.MaxProducers, "must be at least 1"})
+	}
+	if cfg.MinProducers > cfg.MaxProducers {
+		errs = append(errs, ConfigError{"min_producers", cfg.MinProducers, fmt.Sprintf("exceeds max_producers (%d)", cfg.MaxProducers)})
+	}
+	if cfg.MaxConsumers == 0 {
+		errs = append(errs, ConfigError{"max_consumers", cfg.MaxConsumers, "must be at least 1"})
+	}
+	if cfg.MinConsumers > cfg.MaxConsumers {
+		errs = append(errs, ConfigError{"min_consumers", cfg.MinConsumers, fmt.Sprintf("exceeds max_consumers (%d)", cfg.MaxConsumers)})
+	}
+	return errs
+}
+
+// ValidateBusConfig checks NewDirectUniversalBus arguments before they reach the C library
+//
+// A segmentCount of 0 is valid and lets the library pick the count.
+func ValidateBusConfig(bufferSize uint64, segmentCount uint32) []ConfigError {
+	var errs []ConfigError
+	if bufferSize == 0 {
+		errs = append(errs, ConfigError{"buffer_size", bufferSize, "must be greater than 0"})
+	}
+	if limit, err := maxSupportedSegments(); err == nil {
+		if segmentCount > limit {
+			errs = append(errs, ConfigError{"segment_count", segmentCount, fmt.Sprintf("must be at most %d", limit)})
+		}
+	} else if segmentCount >= maxSegmentCount {
+		errs = append(errs, ConfigError{"segment_count", segmentCount, fmt.Sprintf("must be less than %d", maxSegmentCount)})
+	}
+	return errs
+}
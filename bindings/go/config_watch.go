@@ -0,0 +1,211 @@
+// Runtime option updates and config file hot reload
+
+package umsbb
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// configWatchInterval is how often WatchConfigFile checks the file for changes
+const configWatchInterval = time.Second
+
+// ApplyOptions updates the options of a running bus
+//
+// Header limits, the tracer and the auto-reconnect policy take effect for
+// the next call. Options fixed when the bus was built, such as the buffer
+// geometry, fanout, the WAL, retention, deduplication, retry budgets and
+// language priority, are left unchanged and logged as warnings; changing
+// them needs a new bus.
+//
+// Example:
+//
+//	bus.ApplyOptions(umsbb.WithMaxHeaderSize(32 * 1024))
+func (b *DirectUniversalBus) ApplyOptions(opts ...BusOption) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	current := b.opts()
+	next := *current
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&next)
+		}
+	}
+
+	for _, field := range b.immutableChanges(current, &next) {
+		log.Printf("[Go Config] %s cannot change on a running bus; ignoring", field)
+	}
+	next.fanout, next.fanoutQueueSize = current.fanout, current.fanoutQueueSize
+	next.walPath = current.walPath
+	next.journalDir = current.journalDir
+	next.retainMessages, next.retainBytes = current.retainMessages, current.retainBytes
+	next.retainPerType = current.retainPerType
+	next.dedupWindow = current.dedupWindow
+	next.contentWindow, next.contentHashes = current.contentWindow, current.contentHashes
+	next.retryBudgets = current.retryBudgets
+	next.languagePriority = current.languagePriority
+	next.orderedTypeIDs = current.orderedTypeIDs
+	next.initFuncs = current.initFuncs
+	next.bufferSize, next.segmentCount, next.gpuPreferred = current.bufferSize, current.segmentCount, current.gpuPreferred
+	next.maxGoroutines = current.maxGoroutines
+
+	b.options.Store(&next)
+}
+
+// immutableChanges names the construction-time options that differ between current and next
+func (b *DirectUniversalBus) immutableChanges(current, next *busOptions) []string {
+	var changed []string
+	if next.bufferSize != nil && *next.bufferSize != b.bufferSize {
+		changed = append(changed, "buffer size")
+	}
+	if next.segmentCount != nil && *next.segmentCount != b.segmentCount {
+		changed = append(changed, "segment count")
+	}
+	if next.gpuPreferred != current.gpuPreferred &&
+		(next.gpuPreferred == nil || current.gpuPreferred == nil || *next.gpuPreferred != *current.gpuPreferred) {
+		changed = append(changed, "GPU preference")
+	}
+	if next.fanout != current.fanout || next.fanoutQueueSize != current.fanoutQueueSize {
+		changed = append(changed, "fanout")
+	}
+	if next.walPath != current.walPath {
+		changed = append(changed, "WAL path")
+	}
+	if next.journalDir != current.journalDir {
+		changed = append(changed, "segment journal")
+	}
+	if next.retainMessages != current.retainMessages || next.retainBytes != current.retainBytes ||
+		!maps.Equal(next.retainPerType, current.retainPerType) {
+		changed = append(changed, "retention")
+	}
+	if next.dedupWindow != current.dedupWindow ||
+		next.contentWindow != current.contentWindow || next.contentHashes != current.contentHashes {
+		changed = append(changed, "deduplication")
+	}
+	if !slices.Equal(next.retryBudgets, current.retryBudgets) {
+		changed = append(changed, "retry budgets")
+	}
+	if !slices.Equal(next.languagePriority, current.languagePriority) {
+		changed = append(changed, "language priority")
+	}
+	if !slices.Equal(next.orderedTypeIDs, current.orderedTypeIDs) {
+		changed = append(changed, "ordered delivery")
+	}
+	if len(next.initFuncs) != len(current.initFuncs) {
+		changed = append(changed, "init functions")
+	}
+	if next.maxGoroutines != current.maxGoroutines {
+		changed = append(changed, "max goroutines")
+	}
+	return changed
+}
+
+// parseConfigFile reads NAME=value lines using the names of BusOptionsFromEnv
+//
+// Blank lines and lines starting with '#' are skipped. Unknown names are
+// logged and ignored.
+func parseConfigFile(path string) ([]BusOption, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var opts []BusOption
+	var errs []error
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(text, "=")
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s:%d: expected NAME=value", path, line))
+			continue
+		}
+		name = strings.ToUpper(strings.TrimSpace(name))
+
+		env := envOptionNamed(name)
+		if env == nil {
+			log.Printf("[Go Config] %s:%d: ignoring unknown option %s", path, line, name)
+			continue
+		}
+		opt, err := env.parse(strings.TrimSpace(value))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %s: %w", path, line, name, err))
+			continue
+		}
+		opts = append(opts, opt)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// WatchConfigFile reapplies the options in path whenever the file changes
+//
+// The file holds NAME=value lines with the names read by BusOptionsFromEnv,
+// such as MAX_HEADER_SIZE=32768. It is checked every second by modification
+// time and size; each change is parsed and passed to ApplyOptions, so only
+// hot-reloadable options take effect. A file that fails to parse is logged
+// and skipped until it changes again. WatchConfigFile blocks until ctx is
+// done and returns ctx.Err(), or returns an error if path cannot be read
+// when watching starts.
+//
+// Example:
+//
+//	go func() {
+//	    if err := bus.WatchConfigFile(ctx, "/etc/umsbb/bus.conf"); !errors.Is(err, context.Canceled) {
+//	        log.Printf("config watch stopped: %v", err)
+//	    }
+//	}()
+func (b *DirectUniversalBus) WatchConfigFile(ctx context.Context, path string) error {
+	last, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			// Editors often replace files by rename; wait for it to reappear
+			continue
+		}
+		if info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+			continue
+		}
+		last = info
+
+		opts, err := parseConfigFile(path)
+		if err != nil {
+			log.Printf("[Go Config] reload of %s failed: %v", path, err)
+			continue
+		}
+		b.ApplyOptions(opts...)
+		log.Printf("[Go Config] reloaded %s", path)
+	}
+}
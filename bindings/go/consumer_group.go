@@ -0,0 +1,283 @@
+// Round-robin dispatch of received messages to a changing set of workers
+
+package umsbb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// consumerGroupInboxSize is how many messages each worker can have queued
+const consumerGroupInboxSize = 16
+
+// RebalanceEvent describes a change to the workers of a ConsumerGroup
+type RebalanceEvent struct {
+	WorkerID  int
+	Added     bool // false when the worker was removed
+	GroupSize int  // workers after the change
+	// InFlight holds the messages queued for a removed worker that it never
+	// handled, for the application to reassign
+	InFlight []UniversalData
+}
+
+// consumerWorker is one handler goroutine with its queue
+type consumerWorker struct {
+	id       int
+	handle   func(UniversalData)
+	inbox    chan UniversalData
+	freed    chan<- struct{} // the group's, signalled after each message
+	quit     chan struct{}
+	done     chan struct{}
+	received atomic.Uint64
+}
+
+// run handles messages from the inbox until quit is closed
+func (w *consumerWorker) run() {
+	defer close(w.done)
+	for {
+		select {
+		case <-w.quit:
+			return
+		case msg := <-w.inbox:
+			select {
+			case w.freed <- struct{}{}:
+			default:
+			}
+			w.handle(msg)
+			w.received.Add(1)
+		}
+	}
+}
+
+// ConsumerGroup receives from a bus and hands each message to one of its
+// workers in turn
+//
+// Workers can be added and removed while messages flow; each change calls
+// the rebalance hook. Messages are only received while the group has at
+// least one worker.
+type ConsumerGroup struct {
+	bus         Bus
+	onRebalance func(RebalanceEvent)
+	cancel      context.CancelFunc
+	done        chan struct{}
+	freed       chan struct{} // an inbox has room again
+
+	mu      sync.Mutex
+	workers []*consumerWorker
+	next    int // round-robin position in workers
+	nextID  int
+}
+
+// NewConsumerGroup starts dispatching messages from bus, calling onRebalance,
+// if not nil, after every AddWorker and RemoveWorker
+//
+// Example:
+//
+//	cg := umsbb.NewConsumerGroup(bus, func(ev umsbb.RebalanceEvent) {
+//	    for _, msg := range ev.InFlight {
+//	        _ = bus.Send(msg.Data, msg.TypeID) // hand back to the remaining workers
+//	    }
+//	})
+//	defer cg.Stop()
+//	id := cg.AddWorker(func(msg umsbb.UniversalData) { process(msg) })
+//	cg.AddWorker(func(msg umsbb.UniversalData) { process(msg) })
+//	// ... scale down ...
+//	_ = cg.RemoveWorker(id)
+func NewConsumerGroup(bus Bus, onRebalance func(RebalanceEvent)) *ConsumerGroup {
+	ctx, cancel := context.WithCancel(context.Background())
+	cg := &ConsumerGroup{
+		bus:         bus,
+		onRebalance: onRebalance,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+		freed:       make(chan struct{}, 1),
+	}
+	go cg.dispatch(ctx)
+	return cg
+}
+
+// dispatch receives messages and queues each on the next worker
+func (cg *ConsumerGroup) dispatch(ctx context.Context) {
+	defer close(cg.done)
+
+	for {
+		if cg.GroupSize() == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(receivePollInterval):
+				continue
+			}
+		}
+
+		msg, err := receiveDataContext(ctx, cg.bus)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			fmt.Printf("[Go ConsumerGroup] receive failed: %v\n", err)
+			continue
+		}
+		if !cg.deliver(ctx, *msg) {
+			// Stopped before any worker had room; leave it for the next reader
+			if err := cg.bus.Send(msg.Data, msg.TypeID); err != nil {
+				fmt.Printf("[Go ConsumerGroup] message lost on stop: %v\n", err)
+			}
+			return
+		}
+	}
+}
+
+// deliver queues msg on the next worker with room, waiting while every
+// queue is full, and returns false once ctx is done
+func (cg *ConsumerGroup) deliver(ctx context.Context, msg UniversalData) bool {
+	for {
+		if cg.offer(msg) {
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-cg.freed:
+		case <-time.After(receivePollInterval): // for workers added meanwhile
+		}
+	}
+}
+
+// offer queues msg on the first worker with room, in round-robin order,
+// reporting false if there is none
+//
+// The queue is written under cg.mu, so once RemoveWorker has taken a worker
+// out of the group nothing more reaches its inbox and the messages it
+// passes to the rebalance hook are all that were left.
+func (cg *ConsumerGroup) offer(msg UniversalData) bool {
+	cg.mu.Lock()
+	defer cg.mu.Unlock()
+
+	for range cg.workers {
+		cg.next %= len(cg.workers)
+		w := cg.workers[cg.next]
+		cg.next++
+
+		select {
+		case w.inbox <- msg:
+			return true
+		default:
+		}
+	}
+	return false
+}
+
+// AddWorker starts a worker that calls handle for each message it is given
+// and returns its ID
+func (cg *ConsumerGroup) AddWorker(handle func(UniversalData)) int {
+	w := &consumerWorker{
+		handle: handle,
+		inbox:  make(chan UniversalData, consumerGroupInboxSize),
+		freed:  cg.freed,
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	cg.mu.Lock()
+	w.id = cg.nextID
+	cg.nextID++
+	cg.workers = append(cg.workers, w)
+	size := len(cg.workers)
+	cg.mu.Unlock()
+
+	go w.run()
+	cg.rebalanced(RebalanceEvent{WorkerID: w.id, Added: true, GroupSize: size})
+	return w.id
+}
+
+// RemoveWorker stops worker id after its current message and passes the
+// messages still queued for it to the rebalance hook
+func (cg *ConsumerGroup) RemoveWorker(id int) error {
+	cg.mu.Lock()
+	var w *consumerWorker
+	for i, candidate := range cg.workers {
+		if candidate.id == id {
+			w = candidate
+			cg.workers = append(cg.workers[:i], cg.workers[i+1:]...)
+			break
+		}
+	}
+	size := len(cg.workers)
+	cg.mu.Unlock()
+
+	if w == nil {
+		return fmt.Errorf("consumer group has no worker %d", id)
+	}
+
+	close(w.quit)
+	<-w.done
+	cg.rebalanced(RebalanceEvent{WorkerID: id, GroupSize: size, InFlight: drainInbox(w.inbox)})
+	return nil
+}
+
+// drainInbox returns the messages left in inbox
+func drainInbox(inbox chan UniversalData) []UniversalData {
+	var msgs []UniversalData
+	for {
+		select {
+		case msg := <-inbox:
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+// rebalanced calls the rebalance hook, if any
+func (cg *ConsumerGroup) rebalanced(ev RebalanceEvent) {
+	if cg.onRebalance != nil {
+		cg.onRebalance(ev)
+	}
+}
+
+// GroupSize returns the number of workers
+func (cg *ConsumerGroup) GroupSize() int {
+	cg.mu.Lock()
+	defer cg.mu.Unlock()
+	return len(cg.workers)
+}
+
+// PerWorkerReceivedCount returns how many messages each worker has handled,
+// in the order the current workers were added
+func (cg *ConsumerGroup) PerWorkerReceivedCount() []uint64 {
+	cg.mu.Lock()
+	defer cg.mu.Unlock()
+
+	counts := make([]uint64, len(cg.workers))
+	for i, w := range cg.workers {
+		counts[i] = w.received.Load()
+	}
+	return counts
+}
+
+// Stop stops receiving and stops every worker after its current message
+//
+// Messages still queued for workers are passed to the rebalance hook as
+// each worker is removed, and a message received but not yet queued is sent
+// back into the bus. The bus is not closed.
+func (cg *ConsumerGroup) Stop() {
+	cg.cancel()
+	<-cg.done
+
+	for {
+		cg.mu.Lock()
+		if len(cg.workers) == 0 {
+			cg.mu.Unlock()
+			return
+		}
+		id := cg.workers[0].id
+		cg.mu.Unlock()
+
+		_ = cg.RemoveWorker(id) // fails only if a concurrent RemoveWorker won
+	}
+}
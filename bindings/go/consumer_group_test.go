@@ -0,0 +1,53 @@
+package umsbb_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+func TestConsumerGroupRemoveWorkerLosesNothing(t *testing.T) {
+	const total = 2000
+	bus := umsbbtest.NewTestBus(t)
+
+	var handled, inFlight atomic.Int64
+	cg := umsbb.NewConsumerGroup(bus, func(ev umsbb.RebalanceEvent) {
+		inFlight.Add(int64(len(ev.InFlight)))
+	})
+	handle := func(umsbb.UniversalData) {
+		handled.Add(1)
+		time.Sleep(10 * time.Microsecond) // let inboxes fill
+	}
+	cg.AddWorker(handle)
+
+	sent := 0
+	for sent < total {
+		if err := bus.Send([]byte("work"), 1); err != nil {
+			break // full; the churn below drains it
+		}
+		sent++
+	}
+
+	// Churn workers while messages flow
+	deadline := time.Now().Add(2 * time.Second)
+	for handled.Load()+inFlight.Load() < int64(sent)/2 && time.Now().Before(deadline) {
+		id := cg.AddWorker(handle)
+		time.Sleep(time.Millisecond)
+		if err := cg.RemoveWorker(id); err != nil {
+			t.Fatalf("RemoveWorker failed: %v", err)
+		}
+	}
+	cg.Stop()
+
+	left, err := bus.Size()
+	if err != nil {
+		t.Fatalf("Size failed: %v", err)
+	}
+	if got := handled.Load() + inFlight.Load() + int64(left); got != int64(sent) {
+		t.Fatalf("handled %d + in flight %d + left on bus %d = %d, want %d",
+			handled.Load(), inFlight.Load(), left, got, sent)
+	}
+}
@@ -0,0 +1,88 @@
+// Receive-side content filtering by regular expression
+
+package umsbb
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ContentFilterOption configures a content filter
+type ContentFilterOption func(*contentFilterBus)
+
+// WithRejectBus forwards messages that fail their pattern to reject instead of dropping them
+func WithRejectBus(reject Bus) ContentFilterOption {
+	return func(f *contentFilterBus) {
+		f.reject = reject
+	}
+}
+
+// contentFilterBus drops or diverts received messages whose data does not
+// match the pattern registered for their type ID
+type contentFilterBus struct {
+	bus      Bus
+	patterns map[uint32]*regexp.Regexp
+	reject   Bus
+}
+
+// ContentFilter applies the regexp registered for each received message's type
+// ID to its data. Matching messages, and messages whose type ID has no pattern,
+// are returned; the rest are dropped or forwarded to the bus set with
+// WithRejectBus. The pattern map is copied when the filter is created.
+//
+// Example:
+//
+//	filtered := umsbb.ContentFilter(bus, map[uint32]*regexp.Regexp{
+//	    1: regexp.MustCompile(`^\{"level":"(warn|error)"`),
+//	}, umsbb.WithRejectBus(rejects))
+func ContentFilter(bus Bus, patterns map[uint32]*regexp.Regexp, opts ...ContentFilterOption) Bus {
+	f := &contentFilterBus{
+		bus:      bus,
+		patterns: make(map[uint32]*regexp.Regexp, len(patterns)),
+	}
+	for typeID, re := range patterns {
+		if re != nil {
+			f.patterns[typeID] = re
+		}
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Send forwards the payload unfiltered
+func (f *contentFilterBus) Send(data []byte, typeID uint32) error {
+	return f.bus.Send(data, typeID)
+}
+
+// Receive returns the next payload that passes the filter
+func (f *contentFilterBus) Receive() ([]byte, error) {
+	return payloadOf(f.ReceiveData())
+}
+
+// ReceiveData returns the next message that passes the filter, or nil if none is available
+func (f *contentFilterBus) ReceiveData() (*UniversalData, error) {
+	for {
+		msg, err := f.bus.ReceiveData()
+		if err != nil || msg == nil {
+			return msg, err
+		}
+
+		re, ok := f.patterns[msg.TypeID]
+		if !ok || re.Match(msg.Data) {
+			return msg, nil
+		}
+
+		if f.reject != nil {
+			if err := f.reject.Send(msg.Data, msg.TypeID); err != nil {
+				return nil, fmt.Errorf("failed to forward rejected message: %w", err)
+			}
+		}
+	}
+}
+
+// Close closes the wrapped bus
+func (f *contentFilterBus) Close() error {
+	return f.bus.Close()
+}
@@ -0,0 +1,91 @@
+// CSV codec and convenience methods for analytics pipelines
+
+package umsbb
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+)
+
+// CSVCodec encodes [][]string records with encoding/csv
+//
+// Marshal accepts [][]string and Unmarshal expects a *[][]string target.
+type CSVCodec struct{}
+
+var _ Codec = CSVCodec{}
+
+// Marshal encodes records as CSV
+func (CSVCodec) Marshal(v any) ([]byte, error) {
+	records, ok := v.([][]string)
+	if !ok {
+		return nil, fmt.Errorf("CSV codec cannot encode %T, want [][]string", v)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(records); err != nil {
+		return nil, fmt.Errorf("failed to encode CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes CSV records into the *[][]string pointed to by v
+func (CSVCodec) Unmarshal(payload []byte, v any) error {
+	target, ok := v.(*[][]string)
+	if !ok || target == nil {
+		return fmt.Errorf("CSV codec cannot decode into %T, want *[][]string", v)
+	}
+
+	// Rows may legitimately differ in length, so field counts are not enforced
+	r := csv.NewReader(bytes.NewReader(payload))
+	r.FieldsPerRecord = -1
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to decode CSV: %w", err)
+	}
+	*target = records
+	return nil
+}
+
+// SendCSV encodes records as CSV and sends them tagged with typeID
+//
+// Example:
+//
+//	err := bus.SendCSV(ctx, [][]string{{"sensor", "celsius"}, {"a1", "21.5"}}, 3)
+func (b *DirectUniversalBus) SendCSV(ctx context.Context, records [][]string, typeID uint32) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	payload, err := CSVCodec{}.Marshal(records)
+	if err != nil {
+		return err
+	}
+	return b.Send(payload, typeID)
+}
+
+// ReceiveCSV waits for the next message and decodes its CSV records
+//
+// ReceiveCSV polls the bus until a message arrives or ctx is done.
+//
+// Example:
+//
+//	records, err := bus.ReceiveCSV(ctx)
+//	if err == nil {
+//	    fmt.Printf("Received %d rows\n", len(records))
+//	}
+func (b *DirectUniversalBus) ReceiveCSV(ctx context.Context) ([][]string, error) {
+	data, err := receiveContext(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	var records [][]string
+	if err := (CSVCodec{}).Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
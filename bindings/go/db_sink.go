@@ -0,0 +1,198 @@
+// Database sink that inserts received messages column by column
+
+package umsbb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ColumnMapper extracts the value of one table column from a message
+type ColumnMapper struct {
+	Column string
+	Value  func(UniversalData) (any, error)
+}
+
+// DBSink batches messages from a bus and inserts them into a table
+type DBSink struct {
+	db      *sql.DB
+	table   string
+	columns []ColumnMapper
+
+	// BatchSize is the number of rows buffered before an insert (default: 100)
+	BatchSize int
+	// FlushInterval bounds how long a partial batch waits (default: 1s)
+	FlushInterval time.Duration
+	// Placeholder returns the bind parameter for column n, starting at 1
+	// (default: "?"; use "$n" style for PostgreSQL)
+	Placeholder func(n int) string
+
+	mu      sync.Mutex
+	batch   [][]any
+	lastErr error
+	started bool
+	done    chan struct{}
+}
+
+// DatabaseSink creates a sink inserting into table with one mapper per column
+//
+// Example:
+//
+//	sink := umsbb.DatabaseSink(db, "readings", []umsbb.ColumnMapper{
+//	    {Column: "type_id", Value: func(m umsbb.UniversalData) (any, error) { return m.TypeID, nil }},
+//	    {Column: "payload", Value: func(m umsbb.UniversalData) (any, error) { return m.Data, nil }},
+//	})
+//	if err := sink.Start(ctx, bus); err != nil {
+//	    log.Fatal(err)
+//	}
+func DatabaseSink(db *sql.DB, table string, columns []ColumnMapper) *DBSink {
+	return &DBSink{
+		db:      db,
+		table:   table,
+		columns: columns,
+		done:    make(chan struct{}),
+	}
+}
+
+// Start validates the sink and drains bus in a background goroutine until ctx is done
+//
+// The pending batch is flushed when ctx is done; Wait blocks until then.
+func (s *DBSink) Start(ctx context.Context, bus Bus) error {
+	if !sqlIdentifier.MatchString(s.table) {
+		return fmt.Errorf("invalid table name %q", s.table)
+	}
+	if len(s.columns) == 0 {
+		return errors.New("database sink needs at least one column")
+	}
+	for _, col := range s.columns {
+		if !sqlIdentifier.MatchString(col.Column) || col.Value == nil {
+			return fmt.Errorf("invalid column mapper %q", col.Column)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return errors.New("database sink already started")
+	}
+	s.started = true
+
+	go s.run(ctx, bus)
+	return nil
+}
+
+// run receives messages, maps them to rows and flushes full or stale batches
+func (s *DBSink) run(ctx context.Context, bus Bus) {
+	defer close(s.done)
+
+	batchSize := s.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	flushInterval := s.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for ctx.Err() == nil {
+		msg, err := bus.ReceiveData()
+		if err != nil {
+			s.recordErr(err)
+			break
+		}
+
+		if msg != nil {
+			row, err := s.mapRow(*msg)
+			if err != nil {
+				s.recordErr(err)
+				continue
+			}
+
+			s.mu.Lock()
+			s.batch = append(s.batch, row)
+			full := len(s.batch) >= batchSize
+			s.mu.Unlock()
+			if full {
+				s.flush(ctx)
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+			s.flush(ctx)
+		case <-time.After(receivePollInterval):
+		}
+	}
+
+	s.flush(context.WithoutCancel(ctx))
+}
+
+// mapRow applies every column mapper to msg
+func (s *DBSink) mapRow(msg UniversalData) ([]any, error) {
+	row := make([]any, len(s.columns))
+	for i, col := range s.columns {
+		v, err := col.Value(msg)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", col.Column, err)
+		}
+		row[i] = v
+	}
+	return row, nil
+}
+
+// recordErr keeps err for Err
+func (s *DBSink) recordErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = err
+}
+
+// flush inserts the pending batch, keeping the error for Err
+func (s *DBSink) flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.batch) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(s.columns))
+	for i, col := range s.columns {
+		names[i] = col.Column
+	}
+	query := insertQuery(s.table, names, len(names), s.Placeholder)
+
+	err := insertBatch(ctx, s.db, query, s.batch)
+	s.batch = nil
+	if err != nil {
+		s.lastErr = err
+	}
+	return err
+}
+
+// Flush immediately inserts the current batch
+func (s *DBSink) Flush() error {
+	return s.flush(context.Background())
+}
+
+// Wait blocks until the sink goroutine has stopped and flushed
+func (s *DBSink) Wait() {
+	<-s.done
+}
+
+// Err returns the most recent receive, mapping or insert error
+func (s *DBSink) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastErr
+}
@@ -0,0 +1,123 @@
+// Send-side deduplication of retried message IDs
+
+package umsbb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// dedupWindow remembers the most recent message IDs in a ring indexed by
+// ID modulo the window size, so membership is a single slot comparison
+//
+// IDs whose send is still running are tracked separately, so a concurrent
+// duplicate waits for that send instead of racing it.
+type dedupWindow struct {
+	nextID   atomic.Uint64
+	mu       sync.Mutex
+	ring     []uint64
+	inFlight map[uint64]*dedupFlight
+}
+
+// dedupFlight is a send in progress; err is set before done is closed
+type dedupFlight struct {
+	done chan struct{}
+	err  error
+}
+
+// newDedupWindow returns a window of size IDs, or nil if size is not positive
+func newDedupWindow(size int) *dedupWindow {
+	if size <= 0 {
+		return nil
+	}
+	return &dedupWindow{ring: make([]uint64, size), inFlight: make(map[uint64]*dedupFlight)}
+}
+
+// claim starts a send of id and returns its flight with true, or returns
+// the flight of a send of id already running with false, or nil and false
+// if id was already sent
+func (d *dedupWindow) claim(id uint64) (*dedupFlight, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if f := d.inFlight[id]; f != nil {
+		return f, false
+	}
+	if d.ring[id%uint64(len(d.ring))] == id {
+		return nil, false
+	}
+	f := &dedupFlight{done: make(chan struct{})}
+	d.inFlight[id] = f
+	return f, true
+}
+
+// finish ends the send of id claimed with f, recording id only if the send
+// succeeded so a failed one can be retried, and releases its waiters
+func (d *dedupWindow) finish(id uint64, f *dedupFlight, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.inFlight, id)
+	if err == nil {
+		d.ring[id%uint64(len(d.ring))] = id
+	}
+	f.err = err
+	close(f.done)
+}
+
+// NextMessageID returns a new monotonic message ID for SendWithID
+//
+// IDs start at 1 and are unique for the lifetime of the bus.
+func (b *DirectUniversalBus) NextMessageID() uint64 {
+	if b.dedup == nil {
+		return 0
+	}
+	return b.dedup.nextID.Add(1)
+}
+
+// SendWithID sends data under a message ID from NextMessageID
+//
+// If id was already sent within the deduplication window, for example when
+// a caller retries after a timeout, the submit is skipped and SendWithID
+// returns nil. If a send of id is still running, SendWithID waits for it and
+// returns its result. A failed submit releases the ID so it can be retried.
+// IDs older than the window are no longer recognised.
+//
+// Example:
+//
+//	bus, _ := umsbb.NewDirectUniversalBus(1024*1024, 0, false, false, umsbb.WithDeduplication(4096))
+//	id := bus.NextMessageID()
+//	for attempt := 0; attempt < 3; attempt++ {
+//	    if err := bus.SendWithID(id, payload, 1); err == nil {
+//	        break
+//	    }
+//	}
+func (b *DirectUniversalBus) SendWithID(id uint64, data []byte, typeID uint32) error {
+	if b.dedup == nil {
+		return errors.New("bus was created without WithDeduplication")
+	}
+	if id == 0 {
+		return errors.New("message ID must come from NextMessageID")
+	}
+
+	if err := b.validatePayload(data, typeID); err != nil {
+		return err
+	}
+
+	flight, first := b.dedup.claim(id)
+	if !first {
+		if flight == nil {
+			return nil
+		}
+		<-flight.done
+		return flight.err
+	}
+
+	_, span := b.opts().tracer.StartSpan(ContextWithTypeID(context.Background(), typeID), "umsbb.SendWithID")
+	err := b.send(data, typeID, -1)
+	endSpan(span, err)
+	b.dedup.finish(id, flight, err)
+	return err
+}
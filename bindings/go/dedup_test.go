@@ -0,0 +1,63 @@
+package umsbb_test
+
+import (
+	"sync"
+	"testing"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+// sendConcurrently calls SendWithID(id) from n goroutines at once
+func sendConcurrently(bus *umsbb.DirectUniversalBus, id uint64, n int) []error {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make([]error, n)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = bus.SendWithID(id, []byte("once"), 1)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+func TestSendWithIDConcurrentDuplicatesSendOnce(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t, umsbb.WithDeduplication(64))
+
+	for i, err := range sendConcurrently(bus, bus.NextMessageID(), 50) {
+		if err != nil {
+			t.Fatalf("call %d: SendWithID failed: %v", i, err)
+		}
+	}
+	if n, err := bus.Size(); err != nil || n != 1 {
+		t.Fatalf("Size = %d, %v; want exactly one message", n, err)
+	}
+}
+
+func TestSendWithIDDuplicatesShareAFailure(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t, umsbb.WithDeduplication(64))
+	// Fill the bus with payloads the size of those sent below
+	for bus.Send([]byte("once"), 1) == nil {
+	}
+
+	// No duplicate may report success for a send that failed
+	id := bus.NextMessageID()
+	for i, err := range sendConcurrently(bus, id, 50) {
+		if err == nil {
+			t.Fatalf("call %d: SendWithID succeeded on a full bus", i)
+		}
+	}
+
+	// The failure released the ID for a retry
+	if _, err := bus.Receive(); err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	if err := bus.SendWithID(id, []byte("once"), 1); err != nil {
+		t.Fatalf("retry SendWithID failed: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+// Human and machine readable bus state dumps
+
+package umsbb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// DumpFormat selects the output format of Dump
+type DumpFormat int
+
+const (
+	DumpText DumpFormat = iota
+	DumpJSON
+	DumpYAML
+)
+
+// BusDump is the state written by Dump
+type BusDump struct {
+	Closed           bool     `json:"closed"`
+	BufferSize       uint64   `json:"buffer_size"`
+	SegmentCount     uint32   `json:"segment_count"`
+	GPUEnabled       bool     `json:"gpu_enabled"`
+	QueuedMessages   int      `json:"queued_messages"`
+	PendingAcks      int      `json:"pending_acks"`
+	RetainedMessages int      `json:"retained_messages"`
+	RetainedBytes    uint64   `json:"retained_bytes"`
+	Stats            BusStats `json:"stats"`
+}
+
+// snapshotDump collects the current bus state
+func (b *DirectUniversalBus) snapshotDump() BusDump {
+	d := BusDump{
+		BufferSize:       b.bufferSize,
+		GPUEnabled:       b.gpuEnabled,
+		PendingAcks:      b.PendingAcks(),
+		RetainedMessages: len(b.Retained()),
+		RetainedBytes:    b.MemUsage(),
+		Stats:            b.Stats(),
+	}
+
+	segments, err := b.activeSegmentCount()
+	if err != nil {
+		d.Closed = true
+		return d
+	}
+	d.SegmentCount = segments
+	d.QueuedMessages, _ = b.Size()
+	return d
+}
+
+// Dump writes the bus configuration, queue depth and counters to w
+//
+// DumpJSON and DumpYAML produce stable, machine-parseable keys for
+// monitoring scripts; DumpText is meant for people.
+//
+// Example:
+//
+//	_ = bus.Dump(os.Stdout, umsbb.DumpYAML)
+func (b *DirectUniversalBus) Dump(w io.Writer, format DumpFormat) error {
+	d := b.snapshotDump()
+
+	switch format {
+	case DumpText:
+		_, err := fmt.Fprintf(w,
+			"Bus: closed=%t buffer=%d bytes segments=%d gpu=%t\n"+
+				"Queue: messages=%d pending_acks=%d\n"+
+				"Retention: messages=%d bytes=%d\n"+
+				"Stats: sent=%d received=%d errors=%d dropped=%d\n",
+			d.Closed, d.BufferSize, d.SegmentCount, d.GPUEnabled,
+			d.QueuedMessages, d.PendingAcks,
+			d.RetainedMessages, d.RetainedBytes,
+			d.Stats.TotalSent, d.Stats.TotalReceived, d.Stats.TotalErrors, d.Stats.TotalDropped)
+		return err
+	case DumpJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	case DumpYAML:
+		_, err := fmt.Fprintf(w,
+			"closed: %t\nbuffer_size: %d\nsegment_count: %d\ngpu_enabled: %t\n"+
+				"queued_messages: %d\npending_acks: %d\nretained_messages: %d\nretained_bytes: %d\n"+
+				"stats:\n  total_sent: %d\n  total_received: %d\n  total_errors: %d\n  total_dropped: %d\n",
+			d.Closed, d.BufferSize, d.SegmentCount, d.GPUEnabled,
+			d.QueuedMessages, d.PendingAcks, d.RetainedMessages, d.RetainedBytes,
+			d.Stats.TotalSent, d.Stats.TotalReceived, d.Stats.TotalErrors, d.Stats.TotalDropped)
+		return err
+	default:
+		return fmt.Errorf("unsupported dump format %d", format)
+	}
+}
+
+// DumpToFile creates path and writes the bus dump to it in format
+func (b *DirectUniversalBus) DumpToFile(path string, format DumpFormat) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create dump file: %w", err)
+	}
+
+	if err := b.Dump(f, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,123 @@
+// Bus options read from environment variables
+
+package umsbb
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envOption parses one environment variable into a BusOption
+type envOption struct {
+	name  string
+	parse func(value string) (BusOption, error)
+}
+
+// envOptions lists the variables read by BusOptionsFromEnv, without prefix
+var envOptions = []envOption{
+	{"BUFFER_SIZE", func(v string) (BusOption, error) {
+		n, err := strconv.ParseUint(v, 10, 64)
+		return WithBufferSize(n), err
+	}},
+	{"SEGMENT_COUNT", func(v string) (BusOption, error) {
+		n, err := strconv.ParseUint(v, 10, 32)
+		return WithSegmentCount(uint32(n)), err
+	}},
+	{"GPU_PREFERRED", func(v string) (BusOption, error) {
+		b, err := strconv.ParseBool(v)
+		return WithGPUPreferred(b), err
+	}},
+	{"FANOUT", func(v string) (BusOption, error) {
+		b, err := strconv.ParseBool(v)
+		return WithFanout(b), err
+	}},
+	{"MAX_HEADER_SIZE", func(v string) (BusOption, error) {
+		n, err := strconv.Atoi(v)
+		return WithMaxHeaderSize(n), err
+	}},
+	{"MAX_HEADER_COUNT", func(v string) (BusOption, error) {
+		n, err := strconv.Atoi(v)
+		return WithMaxHeaderCount(n), err
+	}},
+	{"WAL_PATH", func(v string) (BusOption, error) {
+		return WithWAL(v), nil
+	}},
+	{"RETENTION", func(v string) (BusOption, error) {
+		n, err := strconv.Atoi(v)
+		return WithRetention(n), err
+	}},
+	{"RETENTION_MEMORY_LIMIT", func(v string) (BusOption, error) {
+		n, err := strconv.ParseUint(v, 10, 64)
+		return WithRetentionMemoryLimit(n), err
+	}},
+	{"DEDUP_WINDOW", func(v string) (BusOption, error) {
+		n, err := strconv.Atoi(v)
+		return WithDeduplication(n), err
+	}},
+}
+
+// envOptionNamed returns the option parsed from name, without prefix, or nil
+func envOptionNamed(name string) *envOption {
+	for i := range envOptions {
+		if envOptions[i].name == name {
+			return &envOptions[i]
+		}
+	}
+	return nil
+}
+
+// BusOptionsFromEnv builds bus options from variables named PREFIX_NAME
+//
+// Recognised names are BUFFER_SIZE, SEGMENT_COUNT, GPU_PREFERRED, FANOUT,
+// MAX_HEADER_SIZE, MAX_HEADER_COUNT, WAL_PATH, RETENTION,
+// RETENTION_MEMORY_LIMIT and DEDUP_WINDOW. Unset variables keep their
+// defaults. Other variables with the prefix are logged and ignored, since
+// operators often share a prefix with other tools; values that fail to
+// parse are returned as errors.
+//
+// Example:
+//
+//	// UMSBB_BUFFER_SIZE=4194304 UMSBB_SEGMENT_COUNT=8
+//	opts, err := umsbb.BusOptionsFromEnv("UMSBB")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	bus, err := umsbb.NewDirectUniversalBus(1024*1024, 0, false, false, opts...)
+func BusOptionsFromEnv(prefix string) ([]BusOption, error) {
+	prefix = strings.TrimSuffix(prefix, "_") + "_"
+
+	var opts []BusOption
+	var errs []error
+	known := make(map[string]bool, len(envOptions))
+	for _, env := range envOptions {
+		name := prefix + env.name
+		known[name] = true
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		opt, err := env.parse(strings.TrimSpace(value))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		opts = append(opts, opt)
+	}
+
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, prefix) && !known[name] {
+			log.Printf("[Go Env] ignoring unknown variable %s", name)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
@@ -0,0 +1,97 @@
+// In-process log of bus operations for debugging
+
+package umsbb
+
+import (
+	"sync"
+	"time"
+)
+
+// Event log operation names
+const (
+	EventSend    = "send"
+	EventReceive = "receive"
+	EventClose   = "close"
+)
+
+// EventLogEntry records one bus operation
+type EventLogEntry struct {
+	Seq    uint64    `json:"seq"`
+	At     time.Time `json:"at"`
+	Op     string    `json:"op"`
+	TypeID uint32    `json:"type_id"`
+	Size   int       `json:"size"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// eventLog is a fixed-size ring of the most recent operations
+type eventLog struct {
+	mu      sync.Mutex
+	entries []EventLogEntry
+	next    uint64 // sequence number of the next entry
+}
+
+// record appends an entry, overwriting the oldest when the ring is full
+//
+// The sequence number is taken under the same lock that orders the ring, so
+// Seq order is the order operations were logged.
+func (l *eventLog) record(op string, typeID uint32, size int, err error) {
+	if l == nil {
+		return
+	}
+
+	entry := EventLogEntry{At: time.Now(), Op: op, TypeID: typeID, Size: size}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Seq = l.next
+	l.entries[l.next%uint64(len(l.entries))] = entry
+	l.next++
+}
+
+// EnableEventLog starts recording every Send, Receive and Close in a ring of
+// the last maxEntries operations
+//
+// Receives that find the bus empty are not logged. Calling it again starts
+// a new, empty log; a maxEntries of 0 or less stops logging.
+//
+// Example:
+//
+//	bus.EnableEventLog(1000)
+//	// ... reproduce the race ...
+//	for _, e := range bus.EventLog() {
+//	    fmt.Printf("%d %s %s type=%d size=%d %s\n", e.Seq, e.At.Format(time.StampMicro), e.Op, e.TypeID, e.Size, e.Error)
+//	}
+func (b *DirectUniversalBus) EnableEventLog(maxEntries int) {
+	if maxEntries <= 0 {
+		b.events.Store(nil)
+		return
+	}
+	b.events.Store(&eventLog{entries: make([]EventLogEntry, maxEntries)})
+}
+
+// EventLog returns the logged operations, oldest first
+func (b *DirectUniversalBus) EventLog() []EventLogEntry {
+	l := b.events.Load()
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	size := uint64(len(l.entries))
+	first := uint64(0)
+	if l.next > size {
+		first = l.next - size
+	}
+	entries := make([]EventLogEntry, 0, l.next-first)
+	for seq := first; seq < l.next; seq++ {
+		entries = append(entries, l.entries[seq%size])
+	}
+	return entries
+}
@@ -0,0 +1,189 @@
+// Consumers and Go-level fan-out delivery
+
+package umsbb
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrFanoutEnabled is returned by direct receives on a bus created with WithFanout
+var ErrFanoutEnabled = errors.New("bus is in fanout mode; receive through a consumer")
+
+// ErrConsumerClosed is returned when receiving on a closed consumer
+var ErrConsumerClosed = errors.New("consumer is closed")
+
+// DefaultFanoutQueueSize is how many messages each fanout consumer can have
+// queued without WithFanoutQueueSize
+const DefaultFanoutQueueSize = 1024
+
+// fanoutHub copies every drained message into each registered consumer's queue
+type fanoutHub struct {
+	mu        sync.Mutex
+	consumers map[*Consumer]struct{}
+	queueSize int
+}
+
+// newFanoutHub creates a hub with no consumers whose queues hold up to
+// queueSize messages, or DefaultFanoutQueueSize for 0 or less
+func newFanoutHub(queueSize int) *fanoutHub {
+	if queueSize <= 0 {
+		queueSize = DefaultFanoutQueueSize
+	}
+	return &fanoutHub{
+		consumers: make(map[*Consumer]struct{}),
+		queueSize: queueSize,
+	}
+}
+
+// pull drains the bus and queues a copy of each message for every consumer,
+// stopping when the bus is empty or any consumer's queue is full
+//
+// Callers must hold h.mu.
+func (h *fanoutHub) pull(b *DirectUniversalBus) error {
+	for !h.anyFull() {
+		msg, err := b.drainData()
+		if err != nil {
+			return err
+		}
+		if msg == nil {
+			return nil
+		}
+
+		for consumer := range h.consumers {
+			copied := *msg
+			copied.Data = append([]byte(nil), msg.Data...)
+			consumer.pending = append(consumer.pending, copied)
+		}
+	}
+	return nil
+}
+
+// anyFull reports whether some consumer has queueSize messages queued
+//
+// Callers must hold h.mu.
+func (h *fanoutHub) anyFull() bool {
+	for consumer := range h.consumers {
+		if len(consumer.pending) >= h.queueSize {
+			return true
+		}
+	}
+	return false
+}
+
+// Consumer reads messages from a DirectUniversalBus
+//
+// Without fanout, consumers share the bus queue and each message reaches one
+// of them. With WithFanout(true), every consumer receives its own copy of
+// every message drained after it was added; see WithFanoutQueueSize for how
+// a slow consumer holds the others back.
+type Consumer struct {
+	bus     *DirectUniversalBus
+	pending []UniversalData // guarded by bus.fanout.mu
+	closed  bool            // guarded by bus.fanout.mu
+}
+
+// AddConsumer registers a new consumer on the bus
+//
+// Example:
+//
+//	consumer := bus.AddConsumer()
+//	defer consumer.Close()
+//	msgs, err := consumer.ReceiveBatch(32)
+func (b *DirectUniversalBus) AddConsumer() *Consumer {
+	c := &Consumer{bus: b}
+	if b.fanout != nil {
+		b.fanout.mu.Lock()
+		b.fanout.consumers[c] = struct{}{}
+		b.fanout.mu.Unlock()
+	}
+	return c
+}
+
+// ReceiveBatch drains up to max messages from the bus
+//
+// Returns:
+//   - messages: Received messages, empty if nothing available
+//   - error: ErrFanoutEnabled in fanout mode, or any drain error
+func (b *DirectUniversalBus) ReceiveBatch(max int) ([]UniversalData, error) {
+	if b.fanout != nil {
+		return nil, ErrFanoutEnabled
+	}
+	return b.drainBatch(max)
+}
+
+// drainBatch takes up to max messages out of the C bus
+func (b *DirectUniversalBus) drainBatch(max int) ([]UniversalData, error) {
+	var batch []UniversalData
+	for len(batch) < max {
+		msg, err := b.drainData()
+		if err != nil {
+			return batch, err
+		}
+		if msg == nil {
+			break
+		}
+		batch = append(batch, *msg)
+	}
+	return batch, nil
+}
+
+// ReceiveBatch returns up to max messages for this consumer
+func (c *Consumer) ReceiveBatch(max int) ([]UniversalData, error) {
+	hub := c.bus.fanout
+	if hub == nil {
+		return c.bus.drainBatch(max)
+	}
+	if max <= 0 {
+		return nil, nil
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if c.closed {
+		return nil, ErrConsumerClosed
+	}
+
+	var err error
+	if len(c.pending) < max {
+		err = hub.pull(c.bus)
+	}
+
+	n := min(max, len(c.pending))
+	batch := make([]UniversalData, n)
+	copy(batch, c.pending)
+	clear(c.pending[:n])
+	c.pending = c.pending[n:]
+	return batch, err
+}
+
+// ReceiveData returns the next message for this consumer, or nil if nothing available
+func (c *Consumer) ReceiveData() (*UniversalData, error) {
+	batch, err := c.ReceiveBatch(1)
+	if len(batch) == 0 {
+		return nil, err
+	}
+	return &batch[0], nil
+}
+
+// Receive returns the next payload for this consumer, or nil if nothing available
+func (c *Consumer) Receive() ([]byte, error) {
+	return payloadOf(c.ReceiveData())
+}
+
+// Close unregisters the consumer and discards its queued messages
+func (c *Consumer) Close() error {
+	hub := c.bus.fanout
+	if hub == nil {
+		return nil
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	c.closed = true
+	c.pending = nil
+	delete(hub.consumers, c)
+	return nil
+}
@@ -0,0 +1,59 @@
+package umsbb_test
+
+import (
+	"testing"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+func TestFanoutQueueSizeHoldsBackFastConsumers(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t, umsbb.WithFanout(true), umsbb.WithFanoutQueueSize(2))
+	fast := bus.AddConsumer()
+	slow := bus.AddConsumer()
+	defer fast.Close()
+	defer slow.Close()
+
+	for _, data := range []string{"a", "b", "c", "d"} {
+		if err := bus.Send([]byte(data), 1); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	batch, err := fast.ReceiveBatch(4)
+	if err != nil || len(batch) != 2 {
+		t.Fatalf("fast ReceiveBatch = %d messages, %v; want 2 while slow is full", len(batch), err)
+	}
+	if n, err := bus.Size(); err != nil || n != 2 {
+		t.Fatalf("bus Size = %d, %v; want 2 left undrained", n, err)
+	}
+
+	for _, want := range []string{"a", "b", "c", "d"} {
+		if got, err := slow.Receive(); err != nil || string(got) != want {
+			t.Fatalf("slow Receive = %q, %v; want %q", got, err, want)
+		}
+	}
+	for _, want := range []string{"c", "d"} {
+		if got, err := fast.Receive(); err != nil || string(got) != want {
+			t.Fatalf("fast Receive = %q, %v; want %q", got, err, want)
+		}
+	}
+}
+
+func TestFanoutReceiveBatchNonPositiveMax(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t, umsbb.WithFanout(true))
+	consumer := bus.AddConsumer()
+	defer consumer.Close()
+
+	if err := bus.Send([]byte("kept"), 1); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	for _, max := range []int{0, -1} {
+		if batch, err := consumer.ReceiveBatch(max); err != nil || len(batch) != 0 {
+			t.Fatalf("ReceiveBatch(%d) = %d messages, %v; want none", max, len(batch), err)
+		}
+	}
+	if got, err := consumer.Receive(); err != nil || string(got) != "kept" {
+		t.Fatalf("Receive = %q, %v; want kept", got, err)
+	}
+}
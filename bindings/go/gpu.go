@@ -0,0 +1,48 @@
+//go:build !nogpu && !nocgo
+
+// GPU capability queries backed by the C library
+
+package umsbb
+
+/*
+#include <stdbool.h>
+#include <stddef.h>
+
+// GPU capabilities
+typedef struct {
+    bool has_cuda;
+    bool has_opencl;
+    bool has_compute;
+    bool has_memory_pool;
+    size_t memory_size;
+    int compute_capability;
+    size_t max_threads;
+} gpu_capabilities_t;
+
+// GPU functions
+bool initialize_gpu();
+bool gpu_available();
+gpu_capabilities_t get_gpu_capabilities();
+*/
+import "C"
+
+// initializeGPU initializes the CUDA or OpenCL backend and reports whether one is usable
+func initializeGPU() bool {
+	return bool(C.initialize_gpu())
+}
+
+// gpuInfo queries the C library for GPU capabilities
+func gpuInfo() GPUInfo {
+	caps := C.get_gpu_capabilities()
+	available := bool(C.gpu_available())
+
+	return GPUInfo{
+		Available:         available,
+		HasCUDA:           bool(caps.has_cuda),
+		HasOpenCL:         bool(caps.has_opencl),
+		HasCompute:        bool(caps.has_compute),
+		MemorySize:        uint64(caps.memory_size),
+		ComputeCapability: int(caps.compute_capability),
+		MaxThreads:        uint64(caps.max_threads),
+	}
+}
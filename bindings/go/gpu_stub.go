@@ -0,0 +1,18 @@
+//go:build nogpu || nocgo
+
+// GPU stubs for environments without CUDA or OpenCL drivers
+//
+// Build or test with -tags nogpu to skip every GPU call into the C library;
+// nocgo builds, which have no C library, use these stubs too.
+
+package umsbb
+
+// initializeGPU reports that no GPU backend is available
+func initializeGPU() bool {
+	return false
+}
+
+// gpuInfo reports an unavailable GPU
+func gpuInfo() GPUInfo {
+	return GPUInfo{}
+}
@@ -0,0 +1,159 @@
+// Consumer groups: competing consumers within a group, a copy per group
+
+package umsbb
+
+import "sync"
+
+// DefaultGroupQueueSize is how many messages a group can have queued when
+// NewGroupHub is given 0
+const DefaultGroupQueueSize = 1024
+
+// GroupHub copies every message drained from a bus into each group's queue
+//
+// Members of the same group compete: each message reaches one of them.
+// Every group receives its own copy of each message, like Kafka consumer
+// groups. A group only sees messages drained after its first member
+// joined, and a group's queued messages are discarded when its last member
+// closes. All access to the bus should go through group members, since a
+// direct receive takes the message from every group.
+//
+// Each group queues at most the hub's queue size. Once any group's queue is
+// full the hub stops draining the bus, so the slowest group holds the others
+// back rather than dropping messages.
+type GroupHub struct {
+	bus       Bus
+	queueSize int
+
+	mu     sync.Mutex
+	queues map[string][]UniversalData
+	refs   map[string]int // open members per group
+}
+
+// NewGroupHub returns a hub that shares bus between consumer groups, each
+// queueing up to queueSize messages; 0 or less uses DefaultGroupQueueSize
+//
+// Example:
+//
+//	hub := umsbb.NewGroupHub(bus, 0)
+//	billing1 := hub.Join("billing")
+//	billing2 := hub.Join("billing") // shares messages with billing1
+//	audit := hub.Join("audit")      // gets a copy of every message
+//	defer billing1.Close()
+func NewGroupHub(bus Bus, queueSize int) *GroupHub {
+	if queueSize <= 0 {
+		queueSize = DefaultGroupQueueSize
+	}
+	return &GroupHub{
+		bus:       bus,
+		queueSize: queueSize,
+		queues:    make(map[string][]UniversalData),
+		refs:      make(map[string]int),
+	}
+}
+
+var _ Bus = (*GroupBus)(nil)
+
+// GroupBus is one member of a consumer group on a GroupHub
+type GroupBus struct {
+	hub     *GroupHub
+	groupID string
+	closed  bool // guarded by hub.mu
+}
+
+// Join adds a member to groupID
+func (h *GroupHub) Join(groupID string) *GroupBus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.refs[groupID] == 0 {
+		h.queues[groupID] = nil
+	}
+	h.refs[groupID]++
+	return &GroupBus{hub: h, groupID: groupID}
+}
+
+// Send sends data on the shared bus, to be delivered to every group
+func (g *GroupBus) Send(data []byte, typeID uint32) error {
+	return g.hub.bus.Send(data, typeID)
+}
+
+// Receive returns the next payload for this member's group
+func (g *GroupBus) Receive() ([]byte, error) {
+	return payloadOf(g.ReceiveData())
+}
+
+// ReceiveData returns the next message for this member's group, or nil if
+// nothing is available
+func (g *GroupBus) ReceiveData() (*UniversalData, error) {
+	h := g.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if g.closed {
+		return nil, ErrConsumerClosed
+	}
+
+	var err error
+	if len(h.queues[g.groupID]) == 0 {
+		err = h.pull()
+	}
+
+	queue := h.queues[g.groupID]
+	if len(queue) == 0 {
+		return nil, err
+	}
+	msg := queue[0]
+	queue[0] = UniversalData{}
+	h.queues[g.groupID] = queue[1:]
+	return &msg, err
+}
+
+// pull drains the bus and queues a copy of each message for every group,
+// stopping when the bus is empty or any group's queue is full
+//
+// Callers must hold h.mu.
+func (h *GroupHub) pull() error {
+	for !h.anyFull() {
+		msg, err := h.bus.ReceiveData()
+		if err != nil || msg == nil {
+			return err
+		}
+
+		for groupID, queue := range h.queues {
+			copied := *msg
+			copied.Data = append([]byte(nil), msg.Data...)
+			h.queues[groupID] = append(queue, copied)
+		}
+	}
+	return nil
+}
+
+// anyFull reports whether some group has queueSize messages queued
+//
+// Callers must hold h.mu.
+func (h *GroupHub) anyFull() bool {
+	for _, queue := range h.queues {
+		if len(queue) >= h.queueSize {
+			return true
+		}
+	}
+	return false
+}
+
+// Close leaves the group without closing the shared bus
+func (g *GroupBus) Close() error {
+	h := g.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if g.closed {
+		return nil
+	}
+	g.closed = true
+
+	if h.refs[g.groupID]--; h.refs[g.groupID] == 0 {
+		delete(h.refs, g.groupID)
+		delete(h.queues, g.groupID)
+	}
+	return nil
+}
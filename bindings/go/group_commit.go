@@ -0,0 +1,220 @@
+// Group commit: buffer sends locally and submit them together
+
+package umsbb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchMessage is one message of a batch passed to SendBatch
+type BatchMessage struct {
+	Data   []byte
+	TypeID uint32
+}
+
+// SendBatch sends msgs in order and stops at the first failure
+//
+// Returns:
+//   - the number of messages accepted before the failure
+//   - the error from the first refused message, or nil if all were sent
+func SendBatch(bus BusInterface, msgs []BatchMessage) (int, error) {
+	for i, msg := range msgs {
+		if err := bus.Send(msg.Data, msg.TypeID); err != nil {
+			return i, fmt.Errorf("batch message %d of %d: %w", i+1, len(msgs), err)
+		}
+	}
+	return len(msgs), nil
+}
+
+// FlushError reports a group flush the bus refused part way through
+//
+// Flushing is not atomic: the first Sent messages of the group were
+// submitted and are already on the bus, while the Pending messages from the
+// refused one onwards were not. The GroupCommitBus keeps those queued, in
+// order, for the next flush.
+//
+//	var flushErr *umsbb.FlushError
+//	if errors.As(err, &flushErr) {
+//	    log.Printf("sent %d, %d waiting", flushErr.Sent, flushErr.Pending)
+//	}
+type FlushError struct {
+	// Sent is the number of messages of the group that were submitted
+	Sent int
+	// Pending is the number of messages left queued, starting with the refused one
+	Pending int
+
+	cause error
+}
+
+// Error implements the error interface
+func (e *FlushError) Error() string {
+	return fmt.Sprintf("group flush sent %d messages, %d pending: %v", e.Sent, e.Pending, e.cause)
+}
+
+// Unwrap returns the error from the refused message
+func (e *FlushError) Unwrap() error {
+	return e.cause
+}
+
+var _ Bus = (*GroupCommitBus)(nil)
+
+// GroupCommitBus accumulates sends and submits them as one group, in the
+// style of a Kafka producer's linger.ms
+//
+// A group is flushed when it reaches maxGroupSize messages, when its first
+// message has waited maxGroupDelay, or when Flush is called. Messages the
+// bus refuses stay queued, in order, for the next flush, so a full bus
+// delays the rest of a group rather than dropping it; the messages before
+// the refused one are already sent, and the *FlushError says how many.
+type GroupCommitBus struct {
+	bus           Bus
+	maxGroupSize  int
+	maxGroupDelay time.Duration
+
+	mu      sync.Mutex
+	pending []BatchMessage
+	timer   *time.Timer
+	lastErr error
+}
+
+// NewGroupCommitBus wraps bus so Append buffers messages until the group is full or stale
+//
+// A maxGroupSize below 1 disables the size trigger and a maxGroupDelay of
+// zero disables the delay trigger.
+//
+// Example:
+//
+//	group := umsbb.NewGroupCommitBus(bus, 100, 5*time.Millisecond)
+//	defer group.Close()
+//	for _, event := range events {
+//	    if err := group.Append(ctx, event, 1); err != nil {
+//	        log.Printf("append failed: %v", err)
+//	    }
+//	}
+//	err := group.Flush(ctx)
+func NewGroupCommitBus(bus Bus, maxGroupSize int, maxGroupDelay time.Duration) *GroupCommitBus {
+	return &GroupCommitBus{
+		bus:           bus,
+		maxGroupSize:  maxGroupSize,
+		maxGroupDelay: maxGroupDelay,
+	}
+}
+
+// Append queues a message for the current group
+//
+// If the message fills the group, Append flushes it and returns the flush
+// error, a *FlushError when the bus refused part of the group.
+func (g *GroupCommitBus) Append(ctx context.Context, data []byte, typeID uint32) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// The bus only borrows data during Send, but the group outlives this call
+	g.pending = append(g.pending, BatchMessage{Data: append([]byte(nil), data...), TypeID: typeID})
+
+	if g.maxGroupSize > 0 && len(g.pending) >= g.maxGroupSize {
+		return g.flushLocked()
+	}
+	if g.timer == nil && g.maxGroupDelay > 0 {
+		g.timer = time.AfterFunc(g.maxGroupDelay, g.lingerExpired)
+	}
+	return nil
+}
+
+// Flush sends every queued message
+//
+// If the bus refuses one, the messages before it stay sent and Flush
+// returns a *FlushError counting the sent and still-pending messages.
+func (g *GroupCommitBus) Flush(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.flushLocked()
+}
+
+// flushLocked sends the pending group and keeps anything the bus refused
+func (g *GroupCommitBus) flushLocked() error {
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+
+	sent, err := SendBatch(g.bus, g.pending)
+	g.pending = g.pending[sent:]
+	if len(g.pending) == 0 {
+		g.pending = nil
+	}
+	if err != nil {
+		return &FlushError{Sent: sent, Pending: len(g.pending), cause: err}
+	}
+	return nil
+}
+
+// lingerExpired flushes a group whose first message waited maxGroupDelay
+func (g *GroupCommitBus) lingerExpired() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.timer = nil
+	if err := g.flushLocked(); err != nil {
+		g.lastErr = err
+		// Retry the refused remainder after another delay
+		g.timer = time.AfterFunc(g.maxGroupDelay, g.lingerExpired)
+	}
+}
+
+// Err returns the error from the most recent failed automatic flush
+func (g *GroupCommitBus) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.lastErr
+}
+
+// Pending returns the number of messages waiting for the next flush
+func (g *GroupCommitBus) Pending() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return len(g.pending)
+}
+
+// Send appends a message to the current group
+func (g *GroupCommitBus) Send(data []byte, typeID uint32) error {
+	return g.Append(context.Background(), data, typeID)
+}
+
+// Receive forwards to the wrapped bus
+func (g *GroupCommitBus) Receive() ([]byte, error) {
+	return g.bus.Receive()
+}
+
+// ReceiveData forwards to the wrapped bus
+func (g *GroupCommitBus) ReceiveData() (*UniversalData, error) {
+	return g.bus.ReceiveData()
+}
+
+// Close flushes the pending group and closes the wrapped bus
+//
+// The wrapped bus is closed even if the final flush fails; the *FlushError
+// is returned and its Pending messages are lost.
+func (g *GroupCommitBus) Close() error {
+	g.mu.Lock()
+	flushErr := g.flushLocked()
+	g.mu.Unlock()
+
+	if err := g.bus.Close(); err != nil {
+		return err
+	}
+	return flushErr
+}
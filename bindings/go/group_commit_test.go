@@ -0,0 +1,47 @@
+package umsbb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+)
+
+func TestGroupCommitFlushReportsPartialSend(t *testing.T) {
+	bus := umsbb.NewLoopbackChannelBus(2)
+	group := umsbb.NewGroupCommitBus(bus, 0, 0)
+	ctx := context.Background()
+
+	for _, s := range []string{"a", "b", "c"} {
+		if err := group.Append(ctx, []byte(s), 1); err != nil {
+			t.Fatalf("Append(%q) failed: %v", s, err)
+		}
+	}
+
+	err := group.Flush(ctx)
+	var flushErr *umsbb.FlushError
+	if !errors.As(err, &flushErr) {
+		t.Fatalf("Flush error = %v, want *FlushError", err)
+	}
+	if flushErr.Sent != 2 || flushErr.Pending != 1 || group.Pending() != 1 {
+		t.Fatalf("Sent = %d Pending = %d group.Pending() = %d, want 2, 1, 1",
+			flushErr.Sent, flushErr.Pending, group.Pending())
+	}
+	if !errors.Is(err, umsbb.ErrSubmitFailed) {
+		t.Fatalf("Flush error %v does not wrap ErrSubmitFailed", err)
+	}
+
+	// The sent messages are on the bus; the refused one follows on the next flush
+	for _, want := range []string{"a", "b"} {
+		if got, _ := bus.Receive(); string(got) != want {
+			t.Fatalf("Receive = %q, want %q", got, want)
+		}
+	}
+	if err := group.Flush(ctx); err != nil {
+		t.Fatalf("second Flush failed: %v", err)
+	}
+	if got, _ := bus.Receive(); string(got) != "c" {
+		t.Fatalf("Receive = %q, want c", got)
+	}
+}
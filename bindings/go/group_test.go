@@ -0,0 +1,67 @@
+package umsbb_test
+
+import (
+	"testing"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+func TestGroupHubCopiesPerGroupAndBoundsQueues(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t)
+	hub := umsbb.NewGroupHub(bus, 2)
+	fast := hub.Join("fast")
+	slow := hub.Join("slow")
+	defer fast.Close()
+	defer slow.Close()
+
+	for _, data := range []string{"a", "b", "c", "d"} {
+		if err := bus.Send([]byte(data), 1); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	// The fast group can only run two messages ahead of the slow one; the
+	// rest stay on the bus
+	for _, want := range []string{"a", "b"} {
+		if got, err := fast.Receive(); err != nil || string(got) != want {
+			t.Fatalf("fast Receive = %q, %v; want %q", got, err, want)
+		}
+	}
+	if got, err := fast.Receive(); err != nil || got != nil {
+		t.Fatalf("fast Receive = %q, %v; want nothing while slow is full", got, err)
+	}
+	if n, err := bus.Size(); err != nil || n != 2 {
+		t.Fatalf("bus Size = %d, %v; want 2 left undrained", n, err)
+	}
+
+	for _, want := range []string{"a", "b", "c", "d"} {
+		if got, err := slow.Receive(); err != nil || string(got) != want {
+			t.Fatalf("slow Receive = %q, %v; want %q", got, err, want)
+		}
+	}
+	for _, want := range []string{"c", "d"} {
+		if got, err := fast.Receive(); err != nil || string(got) != want {
+			t.Fatalf("fast Receive = %q, %v; want %q", got, err, want)
+		}
+	}
+}
+
+// uncomparableBus is a Bus value that cannot be a map key
+type uncomparableBus struct {
+	*umsbb.DirectUniversalBus
+	tags []string
+}
+
+func TestGroupHubAcceptsUncomparableBus(t *testing.T) {
+	hub := umsbb.NewGroupHub(uncomparableBus{DirectUniversalBus: umsbbtest.NewTestBus(t)}, 0)
+	member := hub.Join("g")
+	defer member.Close()
+
+	if err := member.Send([]byte("x"), 1); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if got, err := member.Receive(); err != nil || string(got) != "x" {
+		t.Fatalf("Receive = %q, %v; want x", got, err)
+	}
+}
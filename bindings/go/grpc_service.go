@@ -0,0 +1,98 @@
+// Transport-independent implementation of the BusService gRPC interface
+
+package umsbb
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// SubscribeStream is the server side of a BusService.Subscribe call
+type SubscribeStream interface {
+	Context() context.Context
+	Send(msg *UniversalData) error
+}
+
+// PublishStream is the server side of a BusService.Publish call
+type PublishStream interface {
+	Context() context.Context
+	// Recv returns io.EOF when the client has finished sending
+	Recv() (*UniversalData, error)
+}
+
+// BusService implements the Subscribe and Publish RPCs of proto/bus.proto
+//
+// It works on UniversalData so the binding does not depend on gRPC. Code
+// generated from proto/bus.proto plugs in through thin adapters that convert
+// BusMessage values:
+//
+//	type server struct {
+//	    umsbbpb.UnimplementedBusServiceServer
+//	    svc *umsbb.BusService
+//	}
+//
+//	func (s server) Subscribe(req *umsbbpb.SubscribeRequest, stream umsbbpb.BusService_SubscribeServer) error {
+//	    return s.svc.Subscribe(req.MaxMessages, subscribeAdapter{stream})
+//	}
+//
+//	type subscribeAdapter struct{ umsbbpb.BusService_SubscribeServer }
+//
+//	func (a subscribeAdapter) Send(m *umsbb.UniversalData) error {
+//	    return a.BusService_SubscribeServer.Send(&umsbbpb.BusMessage{
+//	        TypeId: m.TypeID, SourceLang: int32(m.SourceLang), Data: m.Data,
+//	    })
+//	}
+type BusService struct {
+	bus *DirectUniversalBus
+}
+
+// NewBusService serves bus over the BusService RPCs
+func NewBusService(bus *DirectUniversalBus) *BusService {
+	return &BusService{bus: bus}
+}
+
+// Subscribe drains the bus into stream until the client goes away, maxMessages
+// have been sent, or the bus fails
+//
+// A maxMessages of 0 streams until the client cancels. A message whose send
+// fails is returned to the bus so another subscriber can receive it.
+func (s *BusService) Subscribe(maxMessages uint64, stream SubscribeStream) error {
+	ctx := stream.Context()
+	for sent := uint64(0); maxMessages == 0 || sent < maxMessages; sent++ {
+		msg, err := receiveDataContext(ctx, s.bus)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil // Client cancelled
+			}
+			return err
+		}
+		if err := stream.Send(msg); err != nil {
+			return errors.Join(err, s.bus.Send(msg.Data, msg.TypeID))
+		}
+	}
+	return nil
+}
+
+// Publish sends every message from stream into the bus, waiting while it is full
+//
+// Returns:
+//   - accepted: Number of messages the bus accepted
+//   - error: The first receive or send failure, or nil once the client finishes
+func (s *BusService) Publish(stream PublishStream) (uint64, error) {
+	ctx := stream.Context()
+	var accepted uint64
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return accepted, nil
+		}
+		if err != nil {
+			return accepted, err
+		}
+		if err := sendContext(ctx, s.bus, msg.Data, msg.TypeID); err != nil {
+			return accepted, err
+		}
+		accepted++
+	}
+}
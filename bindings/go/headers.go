@@ -0,0 +1,205 @@
+// Messages with bounded metadata headers
+
+package umsbb
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Default header limits applied when no option overrides them
+const (
+	DefaultMaxHeaderSize  = 16 * 1024
+	DefaultMaxHeaderCount = 64
+)
+
+// ErrHeaderTooLarge is returned when message headers exceed the configured limits
+var ErrHeaderTooLarge = errors.New("message headers exceed configured limit")
+
+// errTruncatedHeaders is returned when a framed payload ends inside its header block
+var errTruncatedHeaders = errors.New("truncated message headers")
+
+// Message is a payload with string metadata headers
+//
+// Headers are framed ahead of the payload, so messages sent with SendMessage
+// must be read back with ReceiveMessage.
+type Message struct {
+	Headers    map[string]string
+	Data       []byte
+	TypeID     uint32
+	SourceLang LanguageType
+}
+
+// headerBlockSize returns the encoded size of headers
+func headerBlockSize(headers map[string]string) int {
+	size := 2
+	for k, v := range headers {
+		size += 2 + len(k) + 4 + len(v)
+	}
+	return size
+}
+
+// checkHeaders enforces the header count and size limits in options
+func (o busOptions) checkHeaders(count, size int) error {
+	if o.maxHeaderCount > 0 && count > o.maxHeaderCount {
+		return fmt.Errorf("%w: %d headers, limit %d", ErrHeaderTooLarge, count, o.maxHeaderCount)
+	}
+	if o.maxHeaderSize > 0 && size > o.maxHeaderSize {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrHeaderTooLarge, size, o.maxHeaderSize)
+	}
+	return nil
+}
+
+// encodeMessage frames headers ahead of data
+//
+// Layout: uint16 count, then per header uint16 key length, key, uint32 value
+// length, value, followed by the payload. All integers are big-endian.
+func encodeMessage(headers map[string]string, data []byte) ([]byte, error) {
+	if len(headers) > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: %d headers", ErrHeaderTooLarge, len(headers))
+	}
+
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		if len(k) > math.MaxUint16 {
+			return nil, fmt.Errorf("%w: header key of %d bytes", ErrHeaderTooLarge, len(k))
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]byte, 0, headerBlockSize(headers)+len(data))
+	out = binary.BigEndian.AppendUint16(out, uint16(len(keys)))
+	for _, k := range keys {
+		v := headers[k]
+		out = binary.BigEndian.AppendUint16(out, uint16(len(k)))
+		out = append(out, k...)
+		out = binary.BigEndian.AppendUint32(out, uint32(len(v)))
+		out = append(out, v...)
+	}
+	return append(out, data...), nil
+}
+
+// decodeMessage splits a framed payload into headers and data, enforcing limits
+// before allocating anything for the headers
+func decodeMessage(framed []byte, options busOptions) (map[string]string, []byte, error) {
+	if len(framed) < 2 {
+		return nil, nil, errTruncatedHeaders
+	}
+	count := int(binary.BigEndian.Uint16(framed))
+	if err := options.checkHeaders(count, 0); err != nil {
+		return nil, nil, err
+	}
+
+	pos := 2
+	headers := make(map[string]string, count)
+	for i := 0; i < count; i++ {
+		if len(framed)-pos < 2 {
+			return nil, nil, errTruncatedHeaders
+		}
+		keyLen := int(binary.BigEndian.Uint16(framed[pos:]))
+		pos += 2
+		if len(framed)-pos < keyLen+4 {
+			return nil, nil, errTruncatedHeaders
+		}
+		key := string(framed[pos : pos+keyLen])
+		pos += keyLen
+
+		valueLen := binary.BigEndian.Uint32(framed[pos:])
+		pos += 4
+		if uint64(len(framed)-pos) < uint64(valueLen) {
+			return nil, nil, errTruncatedHeaders
+		}
+		if err := options.checkHeaders(0, pos+int(valueLen)); err != nil {
+			return nil, nil, err
+		}
+		headers[key] = string(framed[pos : pos+int(valueLen)])
+		pos += int(valueLen)
+	}
+
+	return headers, framed[pos:], nil
+}
+
+// SendMessage sends msg.Data with its headers, tagged with msg.TypeID
+//
+// Trace context is injected and, on a bus built WithMetrics, the
+// HeaderSentAt header is added unless the caller set it. Returns
+// ErrHeaderTooLarge if the headers, including those added, exceed the limits
+// set with WithMaxHeaderSize or WithMaxHeaderCount, so a message that a
+// receiver with the same limits would reject is never sent.
+//
+// Example:
+//
+//	err := bus.SendMessage(&umsbb.Message{
+//	    Headers: map[string]string{"trace-id": traceID},
+//	    Data:    payload,
+//	    TypeID:  1,
+//	})
+func (b *DirectUniversalBus) SendMessage(msg *Message) error {
+	if err := b.validatePayload(msg.Data, msg.TypeID); err != nil {
+		return err
+	}
+
+	// Trace context is injected into a copy so the caller's map is untouched
+	ctx, span := b.opts().tracer.StartSpan(ContextWithTypeID(context.Background(), msg.TypeID), "umsbb.SendMessage")
+	headers := make(map[string]string, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	b.opts().tracer.Inject(ctx, headers)
+	if _, ok := headers[HeaderSentAt]; !ok && b.opts().metrics != nil {
+		headers[HeaderSentAt] = strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+
+	if err := b.opts().checkHeaders(len(headers), headerBlockSize(headers)); err != nil {
+		b.counters.totalErrors.Add(1)
+		endSpan(span, err)
+		return err
+	}
+
+	framed, err := encodeMessage(headers, msg.Data)
+	if err != nil {
+		b.counters.totalErrors.Add(1)
+		endSpan(span, err)
+		return err
+	}
+
+	err = b.send(framed, msg.TypeID, -1)
+	endSpan(span, err)
+	return err
+}
+
+// ReceiveMessage receives the next message sent with SendMessage
+//
+// Returns:
+//   - message: Received message, or nil if nothing available
+//   - error: ErrHeaderTooLarge if the producer exceeded this bus's header limits
+func (b *DirectUniversalBus) ReceiveMessage() (*Message, error) {
+	data, err := b.ReceiveData()
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	headers, payload, err := decodeMessage(data.Data, *b.opts())
+	if err != nil {
+		b.counters.totalErrors.Add(1)
+		return nil, err
+	}
+
+	msg := &Message{
+		Headers:    headers,
+		Data:       payload,
+		TypeID:     data.TypeID,
+		SourceLang: data.SourceLang,
+	}
+	if metrics := b.opts().metrics; metrics != nil {
+		metrics.observeMessage(msg, time.Now())
+	}
+	return msg, nil
+}
@@ -0,0 +1,48 @@
+package umsbb_test
+
+import (
+	"errors"
+	"testing"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+func TestSendMessageCountsAddedHeaders(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t, umsbb.WithMaxHeaderCount(1), umsbb.WithMetrics(umsbb.NewMetricsCollector()))
+
+	// One caller header plus the stamped send time exceeds the limit, so the
+	// message must fail on send rather than on receive
+	err := bus.SendMessage(&umsbb.Message{Headers: map[string]string{"k": "v"}, Data: []byte("x"), TypeID: 1})
+	if !errors.Is(err, umsbb.ErrHeaderTooLarge) {
+		t.Fatalf("SendMessage = %v, want ErrHeaderTooLarge", err)
+	}
+	if n, err := bus.Size(); err != nil || n != 0 {
+		t.Fatalf("Size = %d, %v; want nothing queued", n, err)
+	}
+}
+
+func TestSendMessageStampsSentAtOnlyWithMetrics(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []umsbb.BusOption
+		want bool
+	}{
+		{name: "without metrics"},
+		{name: "with metrics", opts: []umsbb.BusOption{umsbb.WithMetrics(umsbb.NewMetricsCollector())}, want: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			bus := umsbbtest.NewTestBus(t, tc.opts...)
+			if err := bus.SendMessage(&umsbb.Message{Data: []byte("x"), TypeID: 1}); err != nil {
+				t.Fatalf("SendMessage failed: %v", err)
+			}
+			msg, err := bus.ReceiveMessage()
+			if err != nil || msg == nil {
+				t.Fatalf("ReceiveMessage = %v, %v; want the message", msg, err)
+			}
+			if _, ok := msg.Headers[umsbb.HeaderSentAt]; ok != tc.want {
+				t.Fatalf("HeaderSentAt present = %v, want %v", ok, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Idle behaviour of consumer workers on an empty bus
+
+package umsbb
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// IdlePolicy controls how a consumer worker waits while the bus is empty
+type IdlePolicy int
+
+const (
+	// BusyPoll polls the bus every 100µs for the lowest latency
+	BusyPoll IdlePolicy = iota
+	// Park blocks the worker until a message is sent, using no CPU while idle
+	//
+	// Only sends through this DirectUniversalBus wake parked workers;
+	// messages submitted by other language bindings sharing the handle wait
+	// until the next Go send.
+	Park
+)
+
+// WorkerConfig configures the workers started by StartConsumers
+type WorkerConfig struct {
+	// Count is the number of workers (0 = auto-determine)
+	Count uint32
+	// IdlePolicy controls how workers wait on an empty bus (default: BusyPoll)
+	IdlePolicy IdlePolicy
+}
+
+// sendSignal wakes parked consumers when a message is sent
+type sendSignal struct {
+	seq     atomic.Uint64
+	waiters atomic.Int32
+	mu      sync.Mutex
+	once    sync.Once
+	cond    *sync.Cond
+}
+
+// condition returns the condition variable, creating it on first use
+func (s *sendSignal) condition() *sync.Cond {
+	s.once.Do(func() { s.cond = sync.NewCond(&s.mu) })
+	return s.cond
+}
+
+// notify records a send and wakes any parked consumers
+//
+// It only takes the lock when a consumer is parked, so sends on a busy bus
+// pay a single atomic increment.
+func (s *sendSignal) notify() {
+	s.seq.Add(1)
+	if s.waiters.Load() > 0 {
+		s.wake()
+	}
+}
+
+// wake wakes every parked consumer so it can recheck its stop condition
+func (s *sendSignal) wake() {
+	cond := s.condition()
+	s.mu.Lock()
+	cond.Broadcast()
+	s.mu.Unlock()
+}
+
+// wait blocks until a send after seq is recorded or stopped returns true
+//
+// Callers read seq before finding the bus empty, so a message sent in
+// between is never missed.
+func (s *sendSignal) wait(seq uint64, stopped func() bool) {
+	cond := s.condition()
+	s.waiters.Add(1)
+	defer s.waiters.Add(-1)
+
+	s.mu.Lock()
+	for s.seq.Load() == seq && !stopped() {
+		cond.Wait()
+	}
+	s.mu.Unlock()
+}
@@ -0,0 +1,127 @@
+// JSON convenience methods backed by pooled encoders and decoders
+
+package umsbb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// jsonEncoder is a reusable encoder writing into its own buffer
+type jsonEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+// jsonDecoder is a reusable decoder reading from a swappable source
+type jsonDecoder struct {
+	src    bytes.Reader
+	dec    *json.Decoder
+	offset int64
+}
+
+// ErrTrailingJSON is returned when a payload has data after its JSON value
+var ErrTrailingJSON = errors.New("unexpected data after JSON value")
+
+var jsonEncoderPool = sync.Pool{
+	New: func() any {
+		e := &jsonEncoder{}
+		e.enc = json.NewEncoder(&e.buf)
+		return e
+	},
+}
+
+var jsonDecoderPool = sync.Pool{
+	New: func() any {
+		d := &jsonDecoder{}
+		d.dec = json.NewDecoder(&d.src)
+		return d
+	},
+}
+
+// SendJSON encodes v as JSON and sends it tagged with typeID
+//
+// Example:
+//
+//	err := bus.SendJSON(ctx, SensorReading{Celsius: 21.5}, 1)
+func (b *DirectUniversalBus) SendJSON(ctx context.Context, v any, typeID uint32) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	e := jsonEncoderPool.Get().(*jsonEncoder)
+	defer jsonEncoderPool.Put(e)
+
+	e.buf.Reset()
+	if err := e.enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode %T: %w", v, err)
+	}
+
+	// Encode appends a newline; Send copies the payload into C memory so the
+	// pooled buffer can be reused as soon as it returns
+	return b.Send(bytes.TrimSuffix(e.buf.Bytes(), []byte{'\n'}), typeID)
+}
+
+// ReceiveJSON waits for the next message and decodes its JSON payload into target
+//
+// ReceiveJSON polls the bus until a message arrives or ctx is done.
+//
+// Example:
+//
+//	var reading SensorReading
+//	if err := bus.ReceiveJSON(ctx, &reading); err != nil {
+//	    log.Printf("ReceiveJSON failed: %v", err)
+//	}
+func (b *DirectUniversalBus) ReceiveJSON(ctx context.Context, target any) error {
+	_, err := b.ReceiveJSONTyped(ctx, target)
+	return err
+}
+
+// ReceiveJSONTyped is ReceiveJSON that also returns the message's type ID
+//
+// The type ID is returned even when decoding fails, so callers can tell
+// which type carried a bad payload. Decode failures wrap the encoding/json
+// error, such as *json.SyntaxError, or ErrTrailingJSON.
+//
+// Example:
+//
+//	var reading SensorReading
+//	typeID, err := bus.ReceiveJSONTyped(ctx, &reading)
+//	var syntaxErr *json.SyntaxError
+//	if errors.As(err, &syntaxErr) {
+//	    log.Printf("type %d sent malformed JSON: %v", typeID, err)
+//	}
+func (b *DirectUniversalBus) ReceiveJSONTyped(ctx context.Context, target any) (uint32, error) {
+	msg, err := receiveDataContext(ctx, b)
+	if err != nil {
+		return 0, err
+	}
+	return msg.TypeID, decodeJSON(msg.Data, target)
+}
+
+// decodeJSON decodes exactly one JSON value from data into target
+func decodeJSON(data []byte, target any) error {
+	d := jsonDecoderPool.Get().(*jsonDecoder)
+	d.src.Reset(data)
+
+	if err := d.dec.Decode(target); err != nil {
+		// A failed decoder keeps its error state, so it is not returned to the pool
+		return fmt.Errorf("failed to decode into %T: %w", target, err)
+	}
+
+	consumed := d.dec.InputOffset() - d.offset
+	if rest := bytes.TrimSpace(data[consumed:]); len(rest) > 0 {
+		return fmt.Errorf("failed to decode into %T: %w", target, ErrTrailingJSON)
+	}
+
+	// Only a decoder that consumed the whole payload has an empty read buffer
+	if consumed == int64(len(data)) {
+		d.offset += consumed
+		jsonDecoderPool.Put(d)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Content-based routing on JSON field values
+
+package umsbb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrNoJSONRoute is returned by Router when no route matches a message
+var ErrNoJSONRoute = errors.New("no route matches message")
+
+// JSONRoute sends messages whose field at JSONPath matches Pattern to Segment
+//
+// JSONPath is a dot-separated path of object keys and array indexes, such as
+// "order.items.0.sku". The field's value is matched as text: strings without
+// quotes, numbers and booleans as written, and objects or arrays as raw JSON.
+type JSONRoute struct {
+	JSONPath string
+	Pattern  *regexp.Regexp
+	TypeID   uint32
+	Segment  uint32
+}
+
+// Router picks a route for each message from the first matching JSONRoute
+type Router struct {
+	routes []JSONRoute
+	paths  [][]string
+}
+
+// JSONFieldRouter creates a router that evaluates routes in order
+//
+// Example:
+//
+//	router := umsbb.JSONFieldRouter([]umsbb.JSONRoute{
+//	    {JSONPath: "event.severity", Pattern: regexp.MustCompile(`^(critical|error)$`), TypeID: 1, Segment: 0},
+//	    {JSONPath: "event.source", Pattern: regexp.MustCompile(`.*`), TypeID: 2, Segment: 1},
+//	})
+//	err := router.Send(bus, payload)
+func JSONFieldRouter(routes []JSONRoute) *Router {
+	r := &Router{
+		routes: append([]JSONRoute(nil), routes...),
+		paths:  make([][]string, len(routes)),
+	}
+	for i, route := range routes {
+		r.paths[i] = strings.Split(route.JSONPath, ".")
+	}
+	return r
+}
+
+// Route returns the first route whose field matches, or ErrNoJSONRoute
+//
+// Routes with a nil Pattern or a path missing from the message are skipped.
+func (r *Router) Route(data []byte) (JSONRoute, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return JSONRoute{}, fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	for i, route := range r.routes {
+		if route.Pattern == nil {
+			continue
+		}
+		value, ok := jsonPathValue(doc, r.paths[i])
+		if ok && route.Pattern.MatchString(value) {
+			return route, nil
+		}
+	}
+	return JSONRoute{}, ErrNoJSONRoute
+}
+
+// Send routes data and sends it to the matching route's segment with its type ID
+func (r *Router) Send(bus *DirectUniversalBus, data []byte) error {
+	route, err := r.Route(data)
+	if err != nil {
+		return err
+	}
+
+	segments, err := bus.activeSegmentCount()
+	if err != nil {
+		return err
+	}
+	if route.Segment >= segments {
+		return fmt.Errorf("route %q targets segment %d but the bus has %d", route.JSONPath, route.Segment, segments)
+	}
+	return bus.send(data, route.TypeID, int(route.Segment))
+}
+
+// jsonPathValue walks path through doc and renders the value found as text
+func jsonPathValue(doc any, path []string) (string, bool) {
+	node := doc
+	for _, key := range path {
+		switch v := node.(type) {
+		case map[string]any:
+			child, ok := v[key]
+			if !ok {
+				return "", false
+			}
+			node = child
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return "", false
+			}
+			node = v[index]
+		default:
+			return "", false
+		}
+	}
+
+	switch v := node.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case nil:
+		return "null", true
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(raw), true
+	}
+}
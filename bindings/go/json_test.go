@@ -0,0 +1,106 @@
+package umsbb_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+type jsonLocation struct {
+	Site  string   `json:"site"`
+	Racks []string `json:"racks"`
+}
+
+type jsonReading struct {
+	Sensor   string             `json:"sensor"`
+	Celsius  float64            `json:"celsius"`
+	Location jsonLocation       `json:"location"`
+	Tags     map[string]string  `json:"tags"`
+	History  []map[string]int64 `json:"history"`
+}
+
+func TestJSONRoundTripNestedStructs(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	want := []jsonReading{
+		{
+			Sensor:   "t1",
+			Celsius:  21.5,
+			Location: jsonLocation{Site: "lab", Racks: []string{"a", "b"}},
+			Tags:     map[string]string{"unit": "C"},
+			History:  []map[string]int64{{"min": -3}, {"max": 40}},
+		},
+		{Sensor: "t2", Location: jsonLocation{Site: "roof"}},
+	}
+	for i, reading := range want {
+		if err := bus.SendJSON(ctx, reading, uint32(10+i)); err != nil {
+			t.Fatalf("SendJSON failed: %v", err)
+		}
+	}
+
+	var first jsonReading
+	typeID, err := bus.ReceiveJSONTyped(ctx, &first)
+	if err != nil || typeID != 10 {
+		t.Fatalf("ReceiveJSONTyped = %d, %v; want type 10", typeID, err)
+	}
+	if !reflect.DeepEqual(first, want[0]) {
+		t.Fatalf("ReceiveJSONTyped decoded %+v, want %+v", first, want[0])
+	}
+
+	var second jsonReading
+	if err := bus.ReceiveJSON(ctx, &second); err != nil {
+		t.Fatalf("ReceiveJSON failed: %v", err)
+	}
+	if !reflect.DeepEqual(second, want[1]) {
+		t.Fatalf("ReceiveJSON decoded %+v, want %+v", second, want[1])
+	}
+}
+
+func TestReceiveJSONTypedDistinguishesDecodeErrors(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name    string
+		payload string
+		check   func(error) bool
+	}{
+		{"syntax", `{"sensor": nope}`, func(err error) bool {
+			var syntaxErr *json.SyntaxError
+			return errors.As(err, &syntaxErr)
+		}},
+		{"trailing data", `{"sensor":"t1"} {}`, func(err error) bool {
+			return errors.Is(err, umsbb.ErrTrailingJSON)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := bus.Send([]byte(tt.payload), 7); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			var reading jsonReading
+			typeID, err := bus.ReceiveJSONTyped(ctx, &reading)
+			if typeID != 7 || !tt.check(err) {
+				t.Fatalf("ReceiveJSONTyped = %d, %v; want type 7 and a %s error", typeID, err, tt.name)
+			}
+		})
+	}
+
+	// A bus error is not a decode error
+	bus.Close()
+	var reading jsonReading
+	_, err := bus.ReceiveJSONTyped(ctx, &reading)
+	var syntaxErr *json.SyntaxError
+	if err == nil || errors.As(err, &syntaxErr) || errors.Is(err, umsbb.ErrTrailingJSON) {
+		t.Fatalf("ReceiveJSONTyped on a closed bus = %v, want a bus error", err)
+	}
+}
@@ -0,0 +1,36 @@
+package umsbb_test
+
+import (
+	"testing"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+)
+
+func TestLanguageTypeRoundTrip(t *testing.T) {
+	for lang := umsbb.LangC; lang <= umsbb.LangSwift; lang++ {
+		parsed, err := umsbb.ParseLanguageType(lang.String())
+		if err != nil {
+			t.Fatalf("ParseLanguageType(%q) failed: %v", lang.String(), err)
+		}
+		if parsed != lang {
+			t.Errorf("ParseLanguageType(%q) = %v, want %v", lang.String(), parsed, lang)
+		}
+	}
+}
+
+func TestParseLanguageTypeAliases(t *testing.T) {
+	for s, want := range map[string]umsbb.LanguageType{
+		"c++":  umsbb.LangCPP,
+		"JS":   umsbb.LangJavaScript,
+		"c#":   umsbb.LangCSharp,
+		" Go ": umsbb.LangGo,
+		"RUST": umsbb.LangRust,
+	} {
+		if got, err := umsbb.ParseLanguageType(s); err != nil || got != want {
+			t.Errorf("ParseLanguageType(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	if _, err := umsbb.ParseLanguageType("cobol"); err == nil {
+		t.Error("ParseLanguageType(\"cobol\") succeeded, want error")
+	}
+}
@@ -0,0 +1,194 @@
+// Capacity leases reserved ahead of sending
+
+package umsbb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ErrNoCapacity is returned when a lease cannot be reserved
+var ErrNoCapacity = errors.New("insufficient bus capacity")
+
+// ErrLeaseExhausted is returned when a send needs more than the lease has left
+var ErrLeaseExhausted = errors.New("lease capacity exhausted")
+
+// ErrLeaseReleased is returned when sending on a released lease
+var ErrLeaseReleased = errors.New("lease already released")
+
+// leaseTable tracks the bytes reserved on each segment
+type leaseTable struct {
+	mu       sync.Mutex
+	reserved []uint64
+	released chan struct{} // closed and replaced whenever capacity is freed
+}
+
+// Lease is capacity reserved on one or more segments
+type Lease struct {
+	bus      *DirectUniversalBus
+	mu       sync.Mutex
+	segments []uint32
+	reserved []uint64 // bytes reserved per leased segment
+	used     []uint64 // bytes sent per leased segment
+	next     int
+	released bool
+}
+
+// tryReserve reserves bytes on the segments least reserved so far, if all fit
+//
+// Callers must hold t.mu.
+func (t *leaseTable) tryReserve(capacity, bytes uint64, segments uint32) []uint32 {
+	order := make([]uint32, len(t.reserved))
+	for i := range order {
+		order[i] = uint32(i)
+	}
+	slices.SortStableFunc(order, func(a, b uint32) int {
+		switch {
+		case t.reserved[a] < t.reserved[b]:
+			return -1
+		case t.reserved[a] > t.reserved[b]:
+			return 1
+		}
+		return 0
+	})
+
+	picked := order[:segments]
+	for _, seg := range picked {
+		if capacity-t.reserved[seg] < bytes {
+			return nil
+		}
+	}
+	for _, seg := range picked {
+		t.reserved[seg] += bytes
+	}
+	return picked
+}
+
+// AcquireLease atomically reserves bytes of capacity on each of segments segments
+//
+// Reservations are tracked by this bus, so producers that lease before
+// sending cannot oversubscribe a segment between checking and enqueueing.
+// If capacity is short and ctx has a deadline, AcquireLease waits for other
+// leases to be released until the deadline; without a deadline it returns
+// ErrNoCapacity immediately.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+//	defer cancel()
+//	lease, err := bus.AcquireLease(ctx, 64*1024, 1)
+//	if err != nil {
+//	    return err
+//	}
+//	defer lease.Release()
+//	err = lease.Send(payload, 1)
+func (b *DirectUniversalBus) AcquireLease(ctx context.Context, bytes uint64, segments uint32) (*Lease, error) {
+	if bytes == 0 || segments == 0 {
+		return nil, errors.New("lease needs a non-zero size and segment count")
+	}
+
+	count, err := b.activeSegmentCount()
+	if err != nil {
+		return nil, err
+	}
+	if segments > count {
+		return nil, fmt.Errorf("%w: %d segments requested, bus has %d", ErrNoCapacity, segments, count)
+	}
+	if bytes > b.bufferSize {
+		return nil, fmt.Errorf("%w: %d bytes requested, segments hold %d", ErrNoCapacity, bytes, b.bufferSize)
+	}
+
+	_, hasDeadline := ctx.Deadline()
+	t := &b.leases
+	for {
+		t.mu.Lock()
+		if t.reserved == nil {
+			t.reserved = make([]uint64, count)
+			t.released = make(chan struct{})
+		}
+		picked := t.tryReserve(b.bufferSize, bytes, segments)
+		released := t.released
+		t.mu.Unlock()
+
+		if picked != nil {
+			reserved := make([]uint64, len(picked))
+			for i := range reserved {
+				reserved[i] = bytes
+			}
+			return &Lease{
+				bus:      b,
+				segments: picked,
+				reserved: reserved,
+				used:     make([]uint64, len(picked)),
+			}, nil
+		}
+
+		if !hasDeadline {
+			return nil, ErrNoCapacity
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %v", ErrNoCapacity, ctx.Err())
+		case <-released:
+		}
+	}
+}
+
+// Segments returns the segments this lease reserved capacity on
+func (l *Lease) Segments() []uint32 {
+	return append([]uint32(nil), l.segments...)
+}
+
+// Send sends data on the next leased segment with room for it
+//
+// Returns ErrLeaseExhausted if no leased segment has enough reserved bytes left.
+func (l *Lease) Send(data []byte, typeID uint32) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.released {
+		return ErrLeaseReleased
+	}
+
+	size := uint64(len(data))
+	for i := range l.segments {
+		idx := (l.next + i) % len(l.segments)
+		if l.reserved[idx]-l.used[idx] < size {
+			continue
+		}
+
+		if err := l.bus.send(data, typeID, int(l.segments[idx])); err != nil {
+			return err
+		}
+		l.used[idx] += size
+		l.next = (idx + 1) % len(l.segments)
+		return nil
+	}
+	return fmt.Errorf("%w: %d bytes", ErrLeaseExhausted, size)
+}
+
+// Release returns the lease's reserved capacity to the bus
+//
+// Release is idempotent.
+func (l *Lease) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.released {
+		return
+	}
+	l.released = true
+
+	t := &l.bus.leases
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, seg := range l.segments {
+		t.reserved[seg] -= l.reserved[i]
+	}
+	close(t.released)
+	t.released = make(chan struct{})
+}
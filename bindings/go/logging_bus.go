@@ -0,0 +1,126 @@
+// Structured send/receive logging middleware
+
+package umsbb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LogRecord is one JSON line written by LoggingBus
+type LogRecord struct {
+	TS         string       `json:"ts"`
+	Direction  string       `json:"direction"`
+	TypeID     uint32       `json:"typeID"`
+	SourceLang LanguageType `json:"sourceLang"`
+	SizeBytes  int          `json:"sizeBytes"`
+	SHA256     string       `json:"sha256"`
+}
+
+// LoggingOption configures a LoggingBus
+type LoggingOption func(*LoggingBus)
+
+// WithSamplingRate logs only a fraction of messages, e.g. 0.01 logs 1 in 100
+//
+// Rates outside (0, 1] are clamped to 1, logging every message.
+func WithSamplingRate(rate float64) LoggingOption {
+	return func(lb *LoggingBus) {
+		lb.samplingRate = rate
+	}
+}
+
+var _ Bus = (*LoggingBus)(nil)
+
+// LoggingBus writes a JSON line to a writer for every message it sends or receives
+type LoggingBus struct {
+	bus          Bus
+	samplingRate float64
+	seen         atomic.Uint64
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewLoggingBus wraps bus and logs every send and receive to w
+//
+// Example:
+//
+//	f, _ := os.Create("bus-audit.jsonl")
+//	logged := umsbb.NewLoggingBus(bus, f, umsbb.WithSamplingRate(0.1))
+//	_ = logged.Send([]byte("hello"), 1)
+func NewLoggingBus(bus Bus, w io.Writer, opts ...LoggingOption) *LoggingBus {
+	lb := &LoggingBus{
+		bus:          bus,
+		samplingRate: 1,
+		enc:          json.NewEncoder(w),
+	}
+	for _, opt := range opts {
+		opt(lb)
+	}
+	if lb.samplingRate <= 0 || lb.samplingRate > 1 {
+		lb.samplingRate = 1
+	}
+	return lb
+}
+
+// sampled reports whether the next message should be logged, spreading
+// logged messages evenly at the configured rate
+func (lb *LoggingBus) sampled() bool {
+	n := lb.seen.Add(1)
+	return uint64(float64(n)*lb.samplingRate) != uint64(float64(n-1)*lb.samplingRate)
+}
+
+// record writes one log line if the message is sampled
+func (lb *LoggingBus) record(direction string, data []byte, typeID uint32, lang LanguageType) {
+	if !lb.sampled() {
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	rec := LogRecord{
+		TS:         time.Now().UTC().Format(time.RFC3339Nano),
+		Direction:  direction,
+		TypeID:     typeID,
+		SourceLang: lang,
+		SizeBytes:  len(data),
+		SHA256:     hex.EncodeToString(sum[:]),
+	}
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	_ = lb.enc.Encode(rec)
+}
+
+// Send forwards the payload and logs it once it was accepted
+func (lb *LoggingBus) Send(data []byte, typeID uint32) error {
+	if err := lb.bus.Send(data, typeID); err != nil {
+		return err
+	}
+	lb.record("send", data, typeID, LangGo)
+	return nil
+}
+
+// Receive returns the next payload, logging it if one was available
+func (lb *LoggingBus) Receive() ([]byte, error) {
+	return payloadOf(lb.ReceiveData())
+}
+
+// ReceiveData returns the next message, logging it if one was available
+func (lb *LoggingBus) ReceiveData() (*UniversalData, error) {
+	msg, err := lb.bus.ReceiveData()
+	if err != nil || msg == nil {
+		return msg, err
+	}
+	lb.record("recv", msg.Data, msg.TypeID, msg.SourceLang)
+	return msg, nil
+}
+
+// Close closes the wrapped bus
+func (lb *LoggingBus) Close() error {
+	return lb.bus.Close()
+}
@@ -0,0 +1,70 @@
+package umsbb_test
+
+import (
+	"os"
+	"testing"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+// TestMain fails the run if the tests together leave goroutines behind;
+// tests that start workers also check themselves with RequireNoGoroutineLeak
+func TestMain(m *testing.M) {
+	os.Exit(umsbbtest.RunTestMain(m))
+}
+
+func TestSendReceiveRoundTrip(t *testing.T) {
+	umsbbtest.RunTableTest(t, []umsbbtest.TestCase{
+		{Name: "text", Input: []byte("hello"), TypeID: 1, ExpectedOutput: []byte("hello")},
+		{Name: "binary", Input: []byte{0, 1, 2, 0xff}, TypeID: 42, ExpectedOutput: []byte{0, 1, 2, 0xff}},
+		{Name: "empty", Input: nil, TypeID: 1, WantErr: true},
+	})
+}
+
+func TestSizeCountsQueuedMessages(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t)
+
+	for i := 0; i < 3; i++ {
+		if err := bus.Send([]byte("queued"), 7); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	if n, err := bus.Size(); err != nil || n != 3 {
+		t.Fatalf("Size = %d, %v; want 3", n, err)
+	}
+
+	if _, err := bus.Receive(); err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	if n, err := bus.Size(); err != nil || n != 2 {
+		t.Fatalf("Size after receive = %d, %v; want 2", n, err)
+	}
+}
+
+func TestSendAfterCloseFails(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t)
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if err := bus.Send([]byte("late"), 1); err == nil {
+		t.Fatal("Send after Close succeeded, want error")
+	}
+}
+
+func TestAutoScalingBusCloseStopsWorkers(t *testing.T) {
+	umsbbtest.RequireLibrary(t)
+
+	umsbbtest.RequireNoGoroutineLeak(t, func() {
+		ab, err := umsbb.NewAutoScalingBus(64*1024, 1, false)
+		if err != nil {
+			t.Fatalf("NewAutoScalingBus failed: %v", err)
+		}
+		ab.StartAutoProducers(func(uint32) []byte { return []byte("tick") }, 2)
+		ab.StartConsumers(func([]byte, uint32) {}, umsbb.WorkerConfig{Count: 2, IdlePolicy: umsbb.Park})
+		if err := ab.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	})
+}
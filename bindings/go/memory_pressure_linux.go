@@ -0,0 +1,131 @@
+//go:build linux
+
+// Shrinking bus segments while the host is low on memory
+
+package umsbb
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MemoryPressureInterval is how often MemoryPressureMonitor reads /proc/meminfo
+var MemoryPressureInterval = 5 * time.Second
+
+const (
+	// compactedSegmentDivisor is how much segments shrink under pressure
+	compactedSegmentDivisor = 4
+
+	// minCompactedSegment keeps compacted segments large enough for big messages
+	minCompactedSegment = 64 * 1024
+
+	// memoryPressureHysteresis is how far above the threshold available
+	// memory must rise before segments are restored, so the bus does not
+	// flap around the threshold
+	memoryPressureHysteresis = 1.2
+)
+
+// MemoryPressureMonitor shrinks the bus's segments while available host
+// memory is below threshold, a fraction of total memory, and restores them
+// once it recovers
+//
+// It checks every MemoryPressureInterval until ctx is done and returns
+// ctx.Err(). Segments still holding messages are resized on a later check.
+//
+// Example:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//	go umsbb.MemoryPressureMonitor(bus, 0.1, ctx) // compact below 10% free
+func MemoryPressureMonitor(bus *DirectUniversalBus, threshold float64, ctx context.Context) error {
+	if !(threshold > 0 && threshold < 1) {
+		return fmt.Errorf("memory pressure threshold must be between 0 and 1, got %v", threshold)
+	}
+
+	full := bus.bufferSize
+	compacted := max(full/compactedSegmentDivisor, min(full, minCompactedSegment))
+
+	ticker := time.NewTicker(MemoryPressureInterval)
+	defer ticker.Stop()
+
+	shrunk := false
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		available, err := availableMemoryFraction()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case available < threshold:
+			resized, err := bus.Compact(compacted)
+			if err != nil {
+				return err
+			}
+			if resized > 0 {
+				log.Printf("[Go Memory] %.1f%% memory available; compacted %d segments to %d bytes",
+					available*100, resized, compacted)
+			}
+			shrunk = true
+		case shrunk && available >= threshold*memoryPressureHysteresis:
+			resized, err := bus.Compact(full)
+			if err != nil {
+				return err
+			}
+			if resized > 0 {
+				log.Printf("[Go Memory] %.1f%% memory available; restored %d segments to %d bytes",
+					available*100, resized, full)
+			} else {
+				shrunk = false
+			}
+		}
+	}
+}
+
+// availableMemoryFraction returns MemAvailable / MemTotal from /proc/meminfo
+func availableMemoryFraction() (float64, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var total, available uint64
+	haveAvailable := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total, err = strconv.ParseUint(fields[1], 10, 64)
+		case "MemAvailable:":
+			available, err = strconv.ParseUint(fields[1], 10, 64)
+			haveAvailable = true
+		}
+		if err != nil {
+			return 0, fmt.Errorf("parse /proc/meminfo: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	if total == 0 || !haveAvailable {
+		return 0, errors.New("/proc/meminfo has no MemTotal or MemAvailable")
+	}
+	return float64(available) / float64(total), nil
+}
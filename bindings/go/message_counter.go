@@ -0,0 +1,81 @@
+// Per-type send counting middleware
+
+package umsbb
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var _ BusInterface = (*MessageCounter)(nil)
+
+// MessageCounter counts successful sends per typeID without decoding payloads
+//
+// Counting happens on Send, so messages are counted even if no consumer ever
+// receives them. Receive and Close pass straight through.
+type MessageCounter struct {
+	bus    BusInterface
+	counts sync.Map // uint32 -> *atomic.Uint64
+	total  atomic.Uint64
+}
+
+// NewMessageCounter wraps bus and counts every message sent through it
+//
+// Example:
+//
+//	counted := umsbb.NewMessageCounter(bus)
+//	_ = counted.Send([]byte("hello"), 7)
+//	fmt.Println(counted.By(7), counted.Total())
+func NewMessageCounter(bus BusInterface) *MessageCounter {
+	return &MessageCounter{bus: bus}
+}
+
+// Send forwards to the wrapped bus and counts the message if it was accepted
+func (mc *MessageCounter) Send(data []byte, typeID uint32) error {
+	if err := mc.bus.Send(data, typeID); err != nil {
+		return err
+	}
+
+	counter, ok := mc.counts.Load(typeID)
+	if !ok {
+		counter, _ = mc.counts.LoadOrStore(typeID, new(atomic.Uint64))
+	}
+	counter.(*atomic.Uint64).Add(1)
+	mc.total.Add(1)
+	return nil
+}
+
+// Receive forwards to the wrapped bus
+func (mc *MessageCounter) Receive() ([]byte, error) {
+	return mc.bus.Receive()
+}
+
+// Close closes the wrapped bus
+func (mc *MessageCounter) Close() error {
+	return mc.bus.Close()
+}
+
+// By returns the number of messages sent with typeID
+func (mc *MessageCounter) By(typeID uint32) uint64 {
+	counter, ok := mc.counts.Load(typeID)
+	if !ok {
+		return 0
+	}
+	return counter.(*atomic.Uint64).Load()
+}
+
+// Total returns the number of messages sent across all types
+func (mc *MessageCounter) Total() uint64 {
+	return mc.total.Load()
+}
+
+// Reset clears every count
+//
+// Sends racing with Reset may be counted either before or after it.
+func (mc *MessageCounter) Reset() {
+	mc.counts.Range(func(key, _ any) bool {
+		mc.counts.Delete(key)
+		return true
+	})
+	mc.total.Store(0)
+}
@@ -0,0 +1,153 @@
+// End-to-end latency summaries exported in the Prometheus text format
+
+package umsbb
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// HeaderSentAt carries the send time, in Unix nanoseconds, of messages sent
+// with SendMessage
+const HeaderSentAt = "umsbb-sent-at"
+
+// DefaultLatencyObjectives are the quantiles reported when none are configured
+var DefaultLatencyObjectives = []float64{0.5, 0.9, 0.99}
+
+// latencyWindow is the number of recent samples each summary keeps for quantiles
+const latencyWindow = 1024
+
+// latencyKey labels one summary series
+type latencyKey struct {
+	typeID     uint32
+	sourceLang LanguageType
+}
+
+// latencySeries is a sliding-window summary of one label set
+type latencySeries struct {
+	samples []float64 // ring of the last latencyWindow observations, in seconds
+	next    int
+	count   uint64
+	sum     float64
+}
+
+// MetricsCollector records end-to-end message latency per type ID and source language
+//
+// Latency is measured from the HeaderSentAt header stamped by SendMessage to
+// the moment ReceiveMessage returns, so it includes queueing time. Clocks of
+// the sending and receiving hosts must agree. Quantiles are computed over the
+// last 1024 observations of each series; the sum and count cover all of them.
+type MetricsCollector struct {
+	objectives []float64
+
+	mu     sync.Mutex
+	series map[latencyKey]*latencySeries
+}
+
+// NewMetricsCollector creates a collector reporting the given quantiles
+//
+// With no objectives it reports DefaultLatencyObjectives. Attach it to a bus
+// with WithMetrics and serve it as an http.Handler.
+//
+// Example:
+//
+//	metrics := umsbb.NewMetricsCollector(0.5, 0.95, 0.999)
+//	bus, _ := umsbb.NewDirectUniversalBus(1024*1024, 0, false, false, umsbb.WithMetrics(metrics))
+//	http.Handle("/metrics", metrics)
+func NewMetricsCollector(objectives ...float64) *MetricsCollector {
+	if len(objectives) == 0 {
+		objectives = DefaultLatencyObjectives
+	}
+	objectives = slices.Clone(objectives)
+	slices.Sort(objectives)
+
+	return &MetricsCollector{
+		objectives: objectives,
+		series:     make(map[latencyKey]*latencySeries),
+	}
+}
+
+// observeMessage records the latency of msg if it carries a valid HeaderSentAt
+func (m *MetricsCollector) observeMessage(msg *Message, now time.Time) {
+	sentAt, err := strconv.ParseInt(msg.Headers[HeaderSentAt], 10, 64)
+	if err != nil {
+		return
+	}
+	m.Observe(msg.TypeID, msg.SourceLang, now.Sub(time.Unix(0, sentAt)))
+}
+
+// Observe records one latency sample; negative latencies from clock skew count as zero
+func (m *MetricsCollector) Observe(typeID uint32, sourceLang LanguageType, latency time.Duration) {
+	seconds := max(latency, 0).Seconds()
+	key := latencyKey{typeID: typeID, sourceLang: sourceLang}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.series[key]
+	if s == nil {
+		s = &latencySeries{}
+		m.series[key] = s
+	}
+	if len(s.samples) < latencyWindow {
+		s.samples = append(s.samples, seconds)
+	} else {
+		s.samples[s.next] = seconds
+		s.next = (s.next + 1) % latencyWindow
+	}
+	s.count++
+	s.sum += seconds
+}
+
+// ServeHTTP writes every series in the Prometheus text exposition format
+func (m *MetricsCollector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_ = m.WriteText(w)
+}
+
+// WriteText writes every series in the Prometheus text exposition format
+func (m *MetricsCollector) WriteText(w io.Writer) error {
+	m.mu.Lock()
+	keys := make([]latencyKey, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	snapshot := make(map[latencyKey]latencySeries, len(keys))
+	for _, key := range keys {
+		s := *m.series[key]
+		s.samples = slices.Clone(s.samples)
+		snapshot[key] = s
+	}
+	m.mu.Unlock()
+
+	slices.SortFunc(keys, func(a, b latencyKey) int {
+		return cmp.Or(cmp.Compare(a.typeID, b.typeID), cmp.Compare(a.sourceLang, b.sourceLang))
+	})
+
+	const name = "umsbb_end_to_end_latency_seconds"
+	if _, err := fmt.Fprintf(w, "# HELP %s Time from SendMessage to ReceiveMessage.\n# TYPE %s summary\n", name, name); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		s := snapshot[key]
+		labels := fmt.Sprintf(`type_id="%d",source_lang="%s"`, key.typeID, key.sourceLang)
+
+		slices.Sort(s.samples)
+		for _, q := range m.objectives {
+			value := s.samples[min(int(q*float64(len(s.samples))), len(s.samples)-1)]
+			if _, err := fmt.Fprintf(w, "%s{%s,quantile=\"%g\"} %g\n", name, labels, q, value); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_sum{%s} %g\n%s_count{%s} %d\n", name, labels, s.sum, name, labels, s.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// Concurrent send to several buses
+
+package umsbb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MultiSendError collects the per-bus results of MultiSend
+//
+// Errors is aligned with the buses passed to MultiSend; a nil entry means
+// that bus accepted the message. Unwrap exposes every failure, so errors.Is
+// and errors.As match an error from any bus.
+type MultiSendError struct {
+	Errors []error
+}
+
+// Failed returns the number of buses that rejected the message
+func (e MultiSendError) Failed() int {
+	failed := 0
+	for _, err := range e.Errors {
+		if err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Err returns e as an error, or nil if every send succeeded
+func (e MultiSendError) Err() error {
+	if e.Failed() == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error summarises the failed sends
+func (e MultiSendError) Error() string {
+	var parts []string
+	for i, err := range e.Errors {
+		if err != nil {
+			parts = append(parts, fmt.Sprintf("bus %d: %v", i, err))
+		}
+	}
+	return fmt.Sprintf("%d of %d sends failed: %s", len(parts), len(e.Errors), strings.Join(parts, "; "))
+}
+
+// Unwrap returns the non-nil per-bus errors for errors.Is and errors.As
+func (e MultiSendError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// MultiSend sends data to every bus concurrently and waits for all of them
+//
+// Buses not yet sent to when ctx is done record ctx.Err() instead.
+//
+// Example:
+//
+//	result := umsbb.MultiSend(ctx, []*umsbb.DirectUniversalBus{primary, replica}, data, 1)
+//	if err := result.Err(); err != nil {
+//	    if errors.Is(err, umsbb.ErrSubmitFailed) {
+//	        log.Printf("a replica is full: %v", err)
+//	    }
+//	}
+func MultiSend(ctx context.Context, buses []*DirectUniversalBus, data []byte, typeID uint32) MultiSendError {
+	result := MultiSendError{Errors: make([]error, len(buses))}
+
+	var wg sync.WaitGroup
+	for i, bus := range buses {
+		wg.Add(1)
+		go func(i int, bus *DirectUniversalBus) {
+			defer wg.Done()
+			if err := ctx.Err(); err != nil {
+				result.Errors[i] = err
+				return
+			}
+			result.Errors[i] = bus.Send(data, typeID)
+		}(i, bus)
+	}
+	wg.Wait()
+
+	return result
+}
@@ -0,0 +1,337 @@
+// Functional options for DirectUniversalBus
+
+package umsbb
+
+import (
+	"maps"
+	"time"
+)
+
+// BusOption configures optional DirectUniversalBus behaviour
+type BusOption func(*busOptions)
+
+// busOptions collects the settings applied by BusOption values
+type busOptions struct {
+	fanout           bool
+	fanoutQueueSize  int
+	maxHeaderSize    int
+	maxHeaderCount   int
+	retryBudgets     []RetryBudget
+	walPath          string
+	journalDir       string
+	tracer           Tracer
+	retainMessages   int
+	retainBytes      uint64
+	retainPerType    map[uint32]RetentionPolicy
+	dedupWindow      int
+	reconnect        reconnectPolicy
+	languagePriority []LanguageType
+	contentWindow    uint
+	contentHashes    uint
+	initFuncs        []func(*DirectUniversalBus) error
+	bufferSize       *uint64
+	segmentCount     *uint32
+	gpuPreferred     *bool
+	maxGoroutines    int
+	metrics          *MetricsCollector
+	schemas          *SchemaRegistry
+	orderedTypeIDs   []uint32
+	backpressure     *backpressureAlert
+	sizes            *MessageSizeHistogram
+}
+
+// newBusOptions applies opts over the defaults
+func newBusOptions(opts []BusOption) busOptions {
+	options := busOptions{
+		maxHeaderSize:  DefaultMaxHeaderSize,
+		maxHeaderCount: DefaultMaxHeaderCount,
+		tracer:         NoopTracer{},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+	return options
+}
+
+// opts returns the options currently in effect
+func (b *DirectUniversalBus) opts() *busOptions {
+	return b.options.Load()
+}
+
+// WithBufferSize overrides the bufferSize passed to the constructor
+//
+// It lets configuration built elsewhere, such as by BusOptionsFromEnv,
+// control the bus geometry.
+func WithBufferSize(size uint64) BusOption {
+	return func(o *busOptions) {
+		o.bufferSize = &size
+	}
+}
+
+// WithSegmentCount overrides the segmentCount passed to the constructor
+func WithSegmentCount(n uint32) BusOption {
+	return func(o *busOptions) {
+		o.segmentCount = &n
+	}
+}
+
+// WithGPUPreferred overrides the gpuPreferred flag passed to the constructor
+func WithGPUPreferred(preferred bool) BusOption {
+	return func(o *busOptions) {
+		o.gpuPreferred = &preferred
+	}
+}
+
+// WithFanout makes every consumer receive every message instead of sharing them
+//
+// In fanout mode messages are read through consumers returned by AddConsumer;
+// ReceiveData and ReceiveBatch on the bus itself return ErrFanoutEnabled.
+//
+// Example:
+//
+//	bus, err := umsbb.NewDirectUniversalBus(1024*1024, 0, false, false, umsbb.WithFanout(true))
+func WithFanout(enabled bool) BusOption {
+	return func(o *busOptions) {
+		o.fanout = enabled
+	}
+}
+
+// WithFanoutQueueSize limits how many messages each fanout consumer can have
+// queued
+//
+// Once any consumer's queue is full, receives stop draining the bus until
+// that consumer catches up, so the slowest consumer holds the others back
+// instead of messages piling up in memory. A size of 0 or less uses
+// DefaultFanoutQueueSize.
+func WithFanoutQueueSize(n int) BusOption {
+	return func(o *busOptions) {
+		o.fanoutQueueSize = n
+	}
+}
+
+// WithMaxHeaderSize limits the serialized size of message headers in bytes
+//
+// SendMessage and ReceiveMessage return ErrHeaderTooLarge when the encoded
+// header block is larger than size. A size of 0 or less removes the limit.
+func WithMaxHeaderSize(size int) BusOption {
+	return func(o *busOptions) {
+		o.maxHeaderSize = size
+	}
+}
+
+// WithMaxHeaderCount limits the number of headers carried by a message
+//
+// SendMessage and ReceiveMessage return ErrHeaderTooLarge when a message has
+// more than n headers. A count of 0 or less removes the limit.
+func WithMaxHeaderCount(n int) BusOption {
+	return func(o *busOptions) {
+		o.maxHeaderCount = n
+	}
+}
+
+// WithRetryBudgets caps how often SendWithRetry may retry each type ID
+//
+// Type IDs without a budget retry until their context is done.
+//
+// Example:
+//
+//	bus, err := umsbb.NewDirectUniversalBus(1024*1024, 0, false, false,
+//	    umsbb.WithRetryBudgets([]umsbb.RetryBudget{
+//	        {TypeID: 7, MaxRetries: 100, Window: time.Minute},
+//	    }))
+func WithRetryBudgets(budgets []RetryBudget) BusOption {
+	return func(o *busOptions) {
+		o.retryBudgets = append([]RetryBudget(nil), budgets...)
+	}
+}
+
+// WithWAL records every sent and drained message in a write-ahead log at path
+//
+// After a crash, RecoverWAL resubmits the messages that were sent but never
+// drained. The file is created if missing and appended to otherwise. Each
+// entry is synced to disk before its message is submitted, and the log is
+// compacted down to its outstanding entries as it grows.
+func WithWAL(path string) BusOption {
+	return func(o *busOptions) {
+		o.walPath = path
+	}
+}
+
+// WithTracer creates a span through t for every Send and Receive
+//
+// SendMessage also injects the span context into the message headers.
+// A nil tracer keeps the default NoopTracer.
+func WithTracer(t Tracer) BusOption {
+	return func(o *busOptions) {
+		if t != nil {
+			o.tracer = t
+		}
+	}
+}
+
+// WithRetention keeps copies of the last maxMessages sent messages in memory
+//
+// Retained messages are available from Retained. A count of 0 or less keeps
+// no count limit, so retention is bounded only by WithRetentionMemoryLimit.
+func WithRetention(maxMessages int) BusOption {
+	return func(o *busOptions) {
+		o.retainMessages = maxMessages
+	}
+}
+
+// WithRetentionMemoryLimit bounds the payload bytes held by the retention buffer
+//
+// When a new message would exceed the limit, the oldest retained messages are
+// dropped to make room; a message larger than the whole limit is not
+// retained. Setting a limit enables retention even without WithRetention.
+func WithRetentionMemoryLimit(bytes uint64) BusOption {
+	return func(o *busOptions) {
+		o.retainBytes = bytes
+	}
+}
+
+// WithRetentionPerType retains the given type IDs under their own policy
+// instead of the WithRetention count
+//
+// Each listed type ID keeps up to MaxMessages messages no older than MaxAge,
+// independently of other types, while WithRetentionMemoryLimit still bounds
+// the whole buffer. With no WithRetention or memory limit, only the listed
+// type IDs are retained.
+//
+// Example:
+//
+//	bus, err := umsbb.NewDirectUniversalBus(1024*1024, 4, false, false,
+//	    umsbb.WithRetention(100),
+//	    umsbb.WithRetentionPerType(map[uint32]umsbb.RetentionPolicy{
+//	        controlTypeID: {MaxMessages: 1000, MaxAge: time.Hour},
+//	        sensorTypeID:  {MaxMessages: 10, MaxAge: time.Second},
+//	    }))
+func WithRetentionPerType(policies map[uint32]RetentionPolicy) BusOption {
+	return func(o *busOptions) {
+		o.retainPerType = maps.Clone(policies)
+	}
+}
+
+// WithDeduplication skips resubmitted message IDs among the last windowSize sent
+//
+// See SendWithID. A window of 0 or less disables deduplication.
+func WithDeduplication(windowSize int) BusOption {
+	return func(o *busOptions) {
+		o.dedupWindow = windowSize
+	}
+}
+
+// WithContentDeduplication drops messages whose content was sent recently
+//
+// Before each submit a Bloom filter is checked for the SHA-256 of the type ID
+// and payload; a probable duplicate is counted in BusStats.DuplicateCount and
+// Send returns nil without submitting it. The filter is cleared after
+// windowSize unique messages and sized for a 1% false positive rate, so
+// about 1 in 100 unique messages may be dropped as a full window nears its
+// reset. A hashFunctions of 0 picks the optimal count. Concurrent sends of
+// the same content may both be submitted.
+//
+// Unlike WithDeduplication this needs no message IDs, which suits
+// at-least-once producers that resend identical payloads.
+//
+// Example:
+//
+//	bus, err := umsbb.NewDirectUniversalBus(1024*1024, 0, false, false,
+//	    umsbb.WithContentDeduplication(10000, 0))
+func WithContentDeduplication(windowSize, hashFunctions uint) BusOption {
+	return func(o *busOptions) {
+		o.contentWindow = windowSize
+		o.contentHashes = hashFunctions
+	}
+}
+
+// WithAutoReconnect recreates the C handle when the C library rejects it
+// and retries the send
+//
+// Only submits failing with EBADF, the C library's report of an invalid
+// handle, reconnect; a full segment is returned as ErrSubmitFailed as
+// usual. Up to maxAttempts recreations are tried, waiting backoff times the
+// attempt number before each, and senders failing on the same handle share
+// one recreation. Recreating the handle discards anything still queued in
+// the old one. Register listeners with OnReconnect.
+func WithAutoReconnect(maxAttempts int, backoff time.Duration) BusOption {
+	return func(o *busOptions) {
+		o.reconnect = reconnectPolicy{maxAttempts: maxAttempts, backoff: backoff}
+	}
+}
+
+// WithLanguagePriority drains messages from the listed source languages first
+//
+// The language at position i is routed to segment i, and Receive scans
+// segments in ascending order, so messages from earlier languages are
+// returned before later ones. Languages not listed keep type ID routing and
+// may share a segment with a prioritized language. At most 16 languages can
+// be listed, and priority only holds for the first segmentCount of them.
+//
+// Example:
+//
+//	// Rust control traffic before Python data
+//	bus, err := umsbb.NewDirectUniversalBus(1024*1024, 4, false, false,
+//	    umsbb.WithLanguagePriority([]umsbb.LanguageType{umsbb.LangRust, umsbb.LangPython}))
+func WithLanguagePriority(order []LanguageType) BusOption {
+	return func(o *busOptions) {
+		o.languagePriority = append([]LanguageType(nil), order...)
+	}
+}
+
+// WithInitFunc runs fn once the C handle exists, before the bus is returned
+//
+// Use it for setup that must happen before any message is sent, such as
+// registering type IDs or warming up segments. Several init functions run in
+// the order given. If one returns an error the bus is closed and the
+// constructor returns that error.
+//
+// Example:
+//
+//	bus, err := umsbb.NewDirectUniversalBus(1024*1024, 0, false, false,
+//	    umsbb.WithInitFunc(func(b *umsbb.DirectUniversalBus) error {
+//	        return b.Send([]byte("warmup"), 0)
+//	    }))
+func WithInitFunc(fn func(*DirectUniversalBus) error) BusOption {
+	return func(o *busOptions) {
+		if fn != nil {
+			o.initFuncs = append(o.initFuncs, fn)
+		}
+	}
+}
+
+// WithMaxGoroutines caps the producer and consumer goroutines of an AutoScalingBus at n
+//
+// StartAutoProducers and StartAutoConsumers start only as many workers as
+// the cap leaves room for and log a warning when they start fewer than
+// asked. A stopped worker frees its slot. n of 0 or less removes the cap.
+// The option has no effect on a DirectUniversalBus.
+func WithMaxGoroutines(n int) BusOption {
+	return func(o *busOptions) {
+		o.maxGoroutines = n
+	}
+}
+
+// WithMetrics records the end-to-end latency of every ReceiveMessage in m
+//
+// SendMessage on a bus with this option stamps each message with the
+// HeaderSentAt header; only messages carrying it are measured.
+func WithMetrics(m *MetricsCollector) BusOption {
+	return func(o *busOptions) {
+		o.metrics = m
+	}
+}
+
+// WithSchemaRegistry validates payloads against r before they are sent
+//
+// Validation only runs while r.ValidateOnSend(true) is in effect. A payload
+// that fails is rejected with ErrSchemaMismatch before it reaches the C
+// library. The payload checked is the caller's, before SendMessage headers
+// or a ChecksumBus checksum are added.
+func WithSchemaRegistry(r *SchemaRegistry) BusOption {
+	return func(o *busOptions) {
+		o.schemas = r
+	}
+}
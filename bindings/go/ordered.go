@@ -0,0 +1,214 @@
+// Per-type-ID ordered delivery
+
+package umsbb
+
+import (
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+)
+
+// orderedSeqSize is the big-endian sequence number prefixed to ordered payloads
+const orderedSeqSize = 8
+
+// orderedStream sequences the messages of one type ID
+type orderedStream struct {
+	sendMu   sync.Mutex // held for the whole of each send
+	nextSend atomic.Uint64
+
+	nextRecv uint64                 // guarded by orderedDelivery.mu
+	held     map[uint64]heldMessage // guarded by orderedDelivery.mu
+	stale    int                    // held messages below nextRecv; guarded by orderedDelivery.mu
+}
+
+// heldMessage is a message drained ahead of its sequence, with the segment
+// it was drained from, or -1 if the drain was not segment-specific
+type heldMessage struct {
+	msg     *UniversalData
+	segment int
+}
+
+// orderedDelivery holds back messages of ordered type IDs until every
+// earlier message of the same type ID has been received
+type orderedDelivery struct {
+	streams   map[uint32]*orderedStream // fixed at construction
+	mu        sync.Mutex
+	delivered uint64 // messages returned by drain; guarded by mu
+}
+
+// newOrderedDelivery returns nil when no type IDs are ordered
+func newOrderedDelivery(typeIDs []uint32) *orderedDelivery {
+	if len(typeIDs) == 0 {
+		return nil
+	}
+
+	o := &orderedDelivery{streams: make(map[uint32]*orderedStream, len(typeIDs))}
+	for _, typeID := range typeIDs {
+		o.streams[typeID] = &orderedStream{held: make(map[uint64]heldMessage)}
+	}
+	return o
+}
+
+// stream returns the sequencer for typeID, or nil if typeID is unordered
+func (o *orderedDelivery) stream(typeID uint32) *orderedStream {
+	if o == nil {
+		return nil
+	}
+	return o.streams[typeID]
+}
+
+// tag prefixes data with the next send sequence number
+//
+// Callers must hold s.sendMu and advance nextSend once the message is accepted.
+func (s *orderedStream) tag(data []byte) []byte {
+	tagged := make([]byte, orderedSeqSize+len(data))
+	binary.BigEndian.PutUint64(tagged, s.nextSend.Load())
+	copy(tagged[orderedSeqSize:], data)
+	return tagged
+}
+
+// drain returns the next deliverable message of segment, or of any segment
+// when segment is negative, taking messages from next and holding back any
+// that arrive ahead of their sequence
+//
+// Messages are returned with their sequence number; strip removes it. Each
+// is stamped with order, its position among every message drain has
+// returned, so callers draining segments concurrently can restore the
+// delivery order.
+func (o *orderedDelivery) drain(segment int, next func() (*UniversalData, error)) (msg *UniversalData, order uint64, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	defer func() {
+		if msg != nil {
+			o.delivered++
+			order = o.delivered
+		}
+	}()
+
+	if held := o.popHeld(segment); held != nil {
+		return held, 0, nil
+	}
+
+	for {
+		msg, err = next()
+		if err != nil || msg == nil {
+			return msg, 0, err
+		}
+
+		s := o.streams[msg.TypeID]
+		if s == nil || len(msg.Data) < orderedSeqSize {
+			return msg, 0, nil // Unordered, or not sent through this binding
+		}
+
+		seq := binary.BigEndian.Uint64(msg.Data)
+		if seq < s.nextRecv {
+			return msg, 0, nil // Sent before a resync
+		}
+		if seq == s.nextRecv {
+			s.nextRecv++
+			return msg, 0, nil
+		}
+		if _, ok := s.held[seq]; ok {
+			return msg, 0, nil // Sequence reused across a resync; never drop the held copy
+		}
+		s.held[seq] = heldMessage{msg: msg, segment: segment}
+	}
+}
+
+// popHeld returns a held message of segment, or of any segment when segment
+// is negative, whose predecessors have all been received
+//
+// Messages held across a resync are released oldest first. Callers must
+// hold o.mu.
+func (o *orderedDelivery) popHeld(segment int) *UniversalData {
+	ours := func(h heldMessage) bool { return segment < 0 || h.segment == segment }
+
+	for _, s := range o.streams {
+		if s.stale > 0 {
+			oldest, found := uint64(0), false
+			for seq, h := range s.held {
+				if seq < s.nextRecv && ours(h) && (!found || seq < oldest) {
+					oldest, found = seq, true
+				}
+			}
+			if found {
+				h := s.held[oldest]
+				delete(s.held, oldest)
+				s.stale--
+				return h.msg
+			}
+		}
+		if h, ok := s.held[s.nextRecv]; ok && ours(h) {
+			delete(s.held, s.nextRecv)
+			s.nextRecv++
+			return h.msg
+		}
+	}
+	return nil
+}
+
+// strip removes the sequence number from a message returned by drain
+func (o *orderedDelivery) strip(msg *UniversalData) {
+	if o == nil || msg == nil {
+		return
+	}
+	if o.streams[msg.TypeID] != nil && len(msg.Data) >= orderedSeqSize {
+		msg.Data = msg.Data[orderedSeqSize:]
+	}
+}
+
+// heldCount returns the number of messages held back
+func (o *orderedDelivery) heldCount() int {
+	if o == nil {
+		return 0
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	n := 0
+	for _, s := range o.streams {
+		n += len(s.held)
+	}
+	return n
+}
+
+// resync expects the next sequence number sent, so messages lost with a
+// recreated handle do not stall delivery; messages already held are
+// delivered rather than dropped
+func (o *orderedDelivery) resync() {
+	if o == nil {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, s := range o.streams {
+		s.nextRecv = s.nextSend.Load()
+		s.stale = len(s.held) // every held sequence was sent before nextSend
+	}
+}
+
+// WithOrderedDelivery delivers the messages of each listed type ID in the
+// order they were sent
+//
+// Sends of one listed type ID are serialized and prefixed with an 8-byte
+// sequence number, which receives through this binding strip again. A
+// message that is drained ahead of an earlier one is held back until the
+// earlier one has been received, so a message lost from the bus stalls its
+// type ID until the handle is recreated. Held messages count towards Size,
+// so CloseGraceful waits for them, and with WithWAL they stay outstanding in
+// the log until they are delivered. A segment-specific drain, such as those
+// of ParallelReceiveBatch, only delivers held messages it drained from that
+// segment itself. Drains of a bus with ordered type IDs are serialized.
+//
+// Example:
+//
+//	bus, err := umsbb.NewDirectUniversalBus(1024*1024, 4, false, false,
+//	    umsbb.WithOrderedDelivery(orderCreated, orderUpdated))
+func WithOrderedDelivery(typeIDs ...uint32) BusOption {
+	return func(o *busOptions) {
+		o.orderedTypeIDs = append(o.orderedTypeIDs, typeIDs...)
+	}
+}
@@ -0,0 +1,166 @@
+//go:build nocgo
+
+package umsbb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// newOrderedTestBus returns a two-segment bus ordering type ID 5
+func newOrderedTestBus(t *testing.T, opts ...BusOption) *DirectUniversalBus {
+	t.Helper()
+
+	opts = append(opts, WithOrderedDelivery(5))
+	bus, err := NewDirectUniversalBus(64*1024, 2, false, false, opts...)
+	if err != nil {
+		t.Fatalf("NewDirectUniversalBus failed: %v", err)
+	}
+	t.Cleanup(func() { _ = bus.Close() })
+	return bus
+}
+
+// sendReversed sends "first" to segment 1 and then "second" to segment 0,
+// so draining segment 0 first finds them out of order
+func sendReversed(t *testing.T, bus *DirectUniversalBus) {
+	t.Helper()
+
+	if err := bus.send([]byte("first"), 5, 1); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if err := bus.send([]byte("second"), 5, 0); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+}
+
+// expectDrain drains segment and checks the payload, "" meaning nothing
+func expectDrain(t *testing.T, bus *DirectUniversalBus, segment int, want string) {
+	t.Helper()
+
+	msg, err := bus.drainFrom(segment)
+	if err != nil {
+		t.Fatalf("drainFrom(%d) failed: %v", segment, err)
+	}
+	got := ""
+	if msg != nil {
+		got = string(msg.Data)
+	}
+	if got != want {
+		t.Fatalf("drainFrom(%d) = %q, want %q", segment, got, want)
+	}
+}
+
+func TestOrderedSegmentDrainKeepsToItsSegment(t *testing.T) {
+	bus := newOrderedTestBus(t)
+	sendReversed(t, bus)
+
+	expectDrain(t, bus, 0, "") // "second" is held back
+	if n, err := bus.Size(); err != nil || n != 2 {
+		t.Fatalf("Size = %d, %v; want 2 counting the held message", n, err)
+	}
+
+	expectDrain(t, bus, 1, "first")
+	expectDrain(t, bus, 1, "") // "second" came from segment 0
+	expectDrain(t, bus, 0, "second")
+	if n, err := bus.Size(); err != nil || n != 0 {
+		t.Fatalf("Size = %d, %v; want 0", n, err)
+	}
+}
+
+func TestOrderedResyncDeliversHeldMessages(t *testing.T) {
+	bus := newOrderedTestBus(t)
+	sendReversed(t, bus)
+
+	expectDrain(t, bus, 0, "")
+	bus.ordered.resync()
+	expectDrain(t, bus, -1, "second")
+	expectDrain(t, bus, -1, "first")
+}
+
+func TestOrderedHeldMessagesSurviveCloseWithWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bus.wal")
+
+	bus := newOrderedTestBus(t, WithWAL(path))
+	sendReversed(t, bus)
+	expectDrain(t, bus, 0, "")
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	bus = newOrderedTestBus(t, WithWAL(path))
+	if n, err := bus.RecoverWAL(context.Background()); err != nil || n != 2 {
+		t.Fatalf("RecoverWAL = %d, %v; want both messages replayed", n, err)
+	}
+	expectDrain(t, bus, -1, "first")
+	expectDrain(t, bus, -1, "second")
+}
+
+func TestRecoverWALResequencesOrderedMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bus.wal")
+
+	bus := newOrderedTestBus(t, WithWAL(path))
+	for _, data := range []string{"a", "b", "c"} {
+		if err := bus.Send([]byte(data), 5); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// The new process's own sequence numbers overlap the logged ones
+	bus = newOrderedTestBus(t, WithWAL(path))
+	if err := bus.Send([]byte("new"), 5); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if n, err := bus.RecoverWAL(context.Background()); err != nil || n != 3 {
+		t.Fatalf("RecoverWAL = %d, %v; want 3", n, err)
+	}
+	for _, want := range []string{"new", "a", "b", "c"} {
+		expectDrain(t, bus, -1, want)
+	}
+	if n, err := bus.Size(); err != nil || n != 0 {
+		t.Fatalf("Size = %d, %v; want nothing held back", n, err)
+	}
+
+	// Every entry is balanced, so a second recovery replays nothing
+	if n, err := bus.RecoverWAL(context.Background()); err != nil || n != 0 {
+		t.Fatalf("second RecoverWAL = %d, %v; want 0", n, err)
+	}
+}
+
+func TestParallelReceiveBatchKeepsOrderAcrossSegments(t *testing.T) {
+	for range 50 {
+		bus := newOrderedTestBus(t)
+
+		// Even sequence numbers go to segment 1 and odd ones to segment 0,
+		// so each segment's goroutine keeps waiting on the other
+		const n = 20
+		for i := range n {
+			if err := bus.send([]byte{byte(i)}, 5, (i+1)%2); err != nil {
+				t.Fatalf("send failed: %v", err)
+			}
+		}
+
+		var got []byte
+		for len(got) < n {
+			batch, err := bus.ParallelReceiveBatch(context.Background(), n)
+			if err != nil {
+				t.Fatalf("ParallelReceiveBatch failed: %v", err)
+			}
+			if len(batch) == 0 {
+				t.Fatalf("ParallelReceiveBatch stalled after %v", got)
+			}
+			for _, msg := range batch {
+				got = append(got, msg.Data[0])
+			}
+		}
+		for i, seq := range got {
+			if int(seq) != i {
+				t.Fatalf("ParallelReceiveBatch delivered %v, want 0..%d in order", got, n-1)
+			}
+		}
+		_ = bus.Close()
+	}
+}
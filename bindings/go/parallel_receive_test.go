@@ -0,0 +1,54 @@
+package umsbb_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+)
+
+func BenchmarkParallelReceiveBatch(b *testing.B) {
+	const messages = 1024
+	payload := []byte("benchmark test message")
+
+	for _, segments := range []uint32{1, 4, 8} {
+		for _, parallel := range []bool{false, true} {
+			mode := "sequential"
+			if parallel {
+				mode = "parallel"
+			}
+			b.Run(fmt.Sprintf("segments=%d/%s", segments, mode), func(b *testing.B) {
+				bus, err := umsbb.NewDirectUniversalBus(1024*1024, segments, false, false)
+				if err != nil {
+					b.Skipf("bus unavailable: %v", err)
+				}
+				defer bus.Close()
+				ctx := context.Background()
+
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					for j := range messages {
+						if err := bus.Send(payload, uint32(j%256)); err != nil {
+							b.Fatalf("Send failed: %v", err)
+						}
+					}
+					b.StartTimer()
+
+					for received := 0; received < messages; {
+						var batch []umsbb.UniversalData
+						if parallel {
+							batch, err = bus.ParallelReceiveBatch(ctx, 64)
+						} else {
+							batch, err = bus.ReceiveBatch(64 * int(segments))
+						}
+						if err != nil || len(batch) == 0 {
+							b.Fatalf("drained %d of %d: %v", received, messages, err)
+						}
+						received += len(batch)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Multi-step message transformation pipelines
+
+package umsbb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// PipelineStage is one transformation step of a Pipeline
+type PipelineStage struct {
+	fn     func(in UniversalData) (UniversalData, error)
+	errors atomic.Int64
+}
+
+// Process creates a stage that transforms each message with fn
+//
+// Example:
+//
+//	decode := umsbb.Process(func(in umsbb.UniversalData) (umsbb.UniversalData, error) {
+//	    in.Data = bytes.ToUpper(in.Data)
+//	    return in, nil
+//	})
+func Process(fn func(in UniversalData) (UniversalData, error)) *PipelineStage {
+	return &PipelineStage{fn: fn}
+}
+
+// Errors returns how many messages this stage failed to process
+func (s *PipelineStage) Errors() int64 {
+	return s.errors.Load()
+}
+
+// Pipeline runs messages through a fixed sequence of stages
+type Pipeline struct {
+	stages     []*PipelineStage
+	deadLetter Bus
+}
+
+// Chain builds a pipeline that applies stages in order
+//
+// Example:
+//
+//	pipeline := umsbb.Chain(decode, validate).WithDeadLetter(dlq)
+//	go pipeline.Run(ctx, ingest, store)
+func Chain(stages ...*PipelineStage) *Pipeline {
+	return &Pipeline{stages: stages}
+}
+
+// WithDeadLetter sends messages that fail a stage to bus, unmodified
+func (p *Pipeline) WithDeadLetter(bus Bus) *Pipeline {
+	p.deadLetter = bus
+	return p
+}
+
+// apply runs msg through every stage, stopping at the first failure
+func (p *Pipeline) apply(msg UniversalData) (UniversalData, error) {
+	for i, stage := range p.stages {
+		out, err := stage.fn(msg)
+		if err != nil {
+			stage.errors.Add(1)
+			return msg, fmt.Errorf("stage %d: %w", i, err)
+		}
+		msg = out
+	}
+	return msg, nil
+}
+
+// Run moves messages from src through every stage to dst until ctx is done
+//
+// A message that fails a stage is counted on that stage and, if a dead-letter
+// bus is set, forwarded there in its original form; otherwise it is dropped.
+// Receive and send failures on src, dst or the dead-letter bus stop the
+// pipeline and are returned.
+func (p *Pipeline) Run(ctx context.Context, src, dst Bus) error {
+	if src == nil || dst == nil {
+		return errors.New("pipeline source and destination are required")
+	}
+	for i, stage := range p.stages {
+		if stage == nil || stage.fn == nil {
+			return fmt.Errorf("pipeline stage %d has no process function", i)
+		}
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		msg, err := src.ReceiveData()
+		if err != nil {
+			return fmt.Errorf("pipeline receive failed: %w", err)
+		}
+		if msg == nil {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(receivePollInterval):
+			}
+			continue
+		}
+
+		out, err := p.apply(*msg)
+		if err != nil {
+			if p.deadLetter != nil {
+				if err := p.deadLetter.Send(msg.Data, msg.TypeID); err != nil {
+					return fmt.Errorf("pipeline dead-letter send failed: %w", err)
+				}
+			}
+			continue
+		}
+
+		if err := dst.Send(out.Data, out.TypeID); err != nil {
+			return fmt.Errorf("pipeline send failed: %w", err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Receive polling with exponential backoff while the bus is empty
+
+package umsbb
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// Default backoff bounds for PollReceiver
+const (
+	DefaultPollInitialDelay = time.Microsecond
+	DefaultPollMaxDelay     = time.Millisecond
+)
+
+// BackoffConfig controls how PollReceiver waits between empty receives
+type BackoffConfig struct {
+	// InitialDelay is the first wait after an empty receive (default: 1µs)
+	InitialDelay time.Duration
+	// MaxDelay caps the doubling delay (default: 1ms)
+	MaxDelay time.Duration
+	// Jitter sleeps a random duration between half and all of the delay,
+	// so many idle receivers do not poll in lockstep
+	Jitter bool
+}
+
+// PollReceiver receives from a bus, backing off exponentially while it is empty
+//
+// The delay doubles after every empty receive up to MaxDelay and resets to
+// InitialDelay as soon as a message arrives, so an idle loop costs little
+// CPU while a busy one keeps its latency.
+type PollReceiver struct {
+	bus   BusInterface
+	cfg   BackoffConfig
+	delay atomic.Int64
+}
+
+// NewPollReceiver wraps bus with the backoff in cfg
+//
+// Example:
+//
+//	poller := umsbb.NewPollReceiver(bus, umsbb.BackoffConfig{MaxDelay: 500 * time.Microsecond, Jitter: true})
+//	for {
+//	    data, err := poller.Receive(ctx)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    handle(data)
+//	}
+func NewPollReceiver(bus BusInterface, cfg BackoffConfig) *PollReceiver {
+	if cfg.InitialDelay <= 0 {
+		cfg.InitialDelay = DefaultPollInitialDelay
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = DefaultPollMaxDelay
+	}
+	cfg.MaxDelay = max(cfg.MaxDelay, cfg.InitialDelay)
+
+	p := &PollReceiver{bus: bus, cfg: cfg}
+	p.delay.Store(int64(cfg.InitialDelay))
+	return p
+}
+
+// Receive waits for the next payload or until ctx is done
+func (p *PollReceiver) Receive(ctx context.Context) ([]byte, error) {
+	for {
+		data, err := p.bus.Receive()
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			p.delay.Store(int64(p.cfg.InitialDelay))
+			return data, nil
+		}
+
+		delay := time.Duration(p.delay.Load())
+		sleep := delay
+		if p.cfg.Jitter {
+			sleep = delay/2 + rand.N(delay/2+1)
+		}
+
+		timer := time.NewTimer(sleep)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		p.delay.Store(int64(min(delay*2, p.cfg.MaxDelay)))
+	}
+}
+
+// CurrentBackoffNs returns the delay the next empty receive will wait, in nanoseconds
+func (p *PollReceiver) CurrentBackoffNs() int64 {
+	return p.delay.Load()
+}
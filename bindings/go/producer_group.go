@@ -0,0 +1,99 @@
+// Fixed pool of producers fed from a channel
+
+package umsbb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ProducerGroup sends every message read from a channel with a fixed number
+// of worker goroutines
+//
+// Unlike StartAutoProducers, the group does not generate data: any code
+// that writes to the channel is a producer. A full bus holds a worker until
+// the send is accepted, which in turn backs up the channel.
+type ProducerGroup struct {
+	bus    *DirectUniversalBus
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	sent   atomic.Uint64
+	failed atomic.Uint64
+}
+
+// NewProducerGroup starts workers goroutines that send the messages from in
+// until it is closed or the group is stopped
+//
+// Example:
+//
+//	in := make(chan umsbb.UniversalData, 256)
+//	pg := umsbb.NewProducerGroup(bus, in, 4)
+//	for _, reading := range readings {
+//	    in <- umsbb.UniversalData{Data: reading, TypeID: sensorTypeID}
+//	}
+//	close(in)
+//	pg.Wait()
+func NewProducerGroup(bus *DirectUniversalBus, in <-chan UniversalData, workers int) *ProducerGroup {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pg := &ProducerGroup{bus: bus, cancel: cancel}
+	for i := 0; i < workers; i++ {
+		pg.wg.Add(1)
+		go pg.run(ctx, in)
+	}
+	return pg
+}
+
+// run sends messages from in until it is closed or ctx is done
+func (pg *ProducerGroup) run(ctx context.Context, in <-chan UniversalData) {
+	defer pg.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-in:
+			if !ok {
+				return
+			}
+			if err := sendContext(ctx, pg.bus, msg.Data, msg.TypeID); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				pg.failed.Add(1)
+				fmt.Printf("[Go Producer] send of type %d failed: %v\n", msg.TypeID, err)
+				continue
+			}
+			pg.sent.Add(1)
+		}
+	}
+}
+
+// Wait blocks until every worker has exited, after in is closed or Stop
+func (pg *ProducerGroup) Wait() {
+	pg.wg.Wait()
+}
+
+// Stop makes the workers exit without draining in and waits for them
+//
+// A message a worker was still retrying against a full bus is dropped.
+func (pg *ProducerGroup) Stop() {
+	pg.cancel()
+	pg.wg.Wait()
+}
+
+// Sent returns the number of messages sent so far
+func (pg *ProducerGroup) Sent() uint64 {
+	return pg.sent.Load()
+}
+
+// Failed returns the number of messages the bus rejected with an error
+// other than a full segment
+func (pg *ProducerGroup) Failed() uint64 {
+	return pg.failed.Load()
+}
@@ -0,0 +1,163 @@
+// Bus construction from protobuf-encoded configuration
+
+package umsbb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// BusConfig mirrors the BusConfig message of proto/bus_config.proto
+type BusConfig struct {
+	BufferSize     uint64
+	SegmentCount   uint32
+	GPUPreferred   bool
+	AutoScale      bool
+	Fanout         bool
+	WALPath        string
+	MaxHeaderSize  uint32
+	MaxHeaderCount uint32
+	OrderedTypeIDs []uint32
+}
+
+// busConfigWireTypes maps BusConfig field numbers to their wire types
+var busConfigWireTypes = map[uint64]ProtoWireType{
+	1: ProtoVarint, 2: ProtoVarint, 3: ProtoVarint, 4: ProtoVarint, 5: ProtoVarint,
+	6: ProtoBytes, 7: ProtoVarint, 8: ProtoVarint, 9: ProtoVarint,
+}
+
+// ParseBusConfigProto decodes a protobuf-encoded BusConfig
+//
+// Unknown fields are skipped, so configurations written by newer versions
+// of the schema still load.
+//
+// Example:
+//
+//	data, _ := os.ReadFile("/etc/umsbb/bus.pb")
+//	cfg, err := umsbb.ParseBusConfigProto(data)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	bus, err := umsbb.NewFromProto(cfg)
+func ParseBusConfigProto(data []byte) (*BusConfig, error) {
+	cfg := &BusConfig{}
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errors.New("malformed bus config: bad field key")
+		}
+		data = data[n:]
+		number, wt := key>>3, ProtoWireType(key&7)
+
+		var value uint64
+		var bytes []byte
+		switch wt {
+		case ProtoVarint:
+			if value, n = binary.Uvarint(data); n <= 0 {
+				return nil, fmt.Errorf("malformed bus config: field %d", number)
+			}
+		case ProtoFixed64:
+			n = 8
+		case ProtoFixed32:
+			n = 4
+		case ProtoBytes:
+			size, m := binary.Uvarint(data)
+			if m <= 0 || size > uint64(len(data)-m) {
+				return nil, fmt.Errorf("malformed bus config: field %d", number)
+			}
+			bytes, n = data[m:m+int(size)], m+int(size)
+		default:
+			return nil, fmt.Errorf("malformed bus config: field %d has wire type %d", number, wt)
+		}
+		if n > len(data) {
+			return nil, fmt.Errorf("malformed bus config: field %d truncated", number)
+		}
+		data = data[n:]
+
+		if want, ok := busConfigWireTypes[number]; ok && wt != want && !(number == 9 && wt == ProtoBytes) {
+			return nil, fmt.Errorf("bus config field %d has wire type %d, want %d", number, wt, want)
+		}
+		switch number {
+		case 1:
+			cfg.BufferSize = value
+		case 2:
+			cfg.SegmentCount = uint32(value)
+		case 3:
+			cfg.GPUPreferred = value != 0
+		case 4:
+			cfg.AutoScale = value != 0
+		case 5:
+			cfg.Fanout = value != 0
+		case 6:
+			cfg.WALPath = string(bytes)
+		case 7:
+			cfg.MaxHeaderSize = uint32(value)
+		case 8:
+			cfg.MaxHeaderCount = uint32(value)
+		case 9:
+			ids, err := decodeRepeatedUint32(wt, value, bytes)
+			if err != nil {
+				return nil, fmt.Errorf("bus config ordered_type_ids: %w", err)
+			}
+			cfg.OrderedTypeIDs = append(cfg.OrderedTypeIDs, ids...)
+		}
+	}
+	return cfg, nil
+}
+
+// decodeRepeatedUint32 returns the values of one repeated uint32 field
+// occurrence, which is a single varint or, when packed, a run of them
+func decodeRepeatedUint32(wt ProtoWireType, value uint64, packed []byte) ([]uint32, error) {
+	switch wt {
+	case ProtoVarint:
+		return []uint32{uint32(value)}, nil
+	case ProtoBytes:
+		var ids []uint32
+		for len(packed) > 0 {
+			v, n := binary.Uvarint(packed)
+			if n <= 0 {
+				return nil, errors.New("malformed packed varint")
+			}
+			ids = append(ids, uint32(v))
+			packed = packed[n:]
+		}
+		return ids, nil
+	default:
+		return nil, fmt.Errorf("unexpected wire type %d", wt)
+	}
+}
+
+// NewFromProto validates cfg and creates a bus from it
+//
+// Further options are applied after those derived from cfg.
+func NewFromProto(cfg *BusConfig, opts ...BusOption) (*DirectUniversalBus, error) {
+	if cfg == nil {
+		return nil, errors.New("bus config cannot be nil")
+	}
+
+	var errs []error
+	for _, err := range ValidateBusConfig(cfg.BufferSize, cfg.SegmentCount) {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid bus config: %w", err)
+	}
+
+	options := []BusOption{WithFanout(cfg.Fanout)}
+	if cfg.WALPath != "" {
+		options = append(options, WithWAL(cfg.WALPath))
+	}
+	if cfg.MaxHeaderSize > 0 {
+		options = append(options, WithMaxHeaderSize(int(cfg.MaxHeaderSize)))
+	}
+	if cfg.MaxHeaderCount > 0 {
+		options = append(options, WithMaxHeaderCount(int(cfg.MaxHeaderCount)))
+	}
+	if len(cfg.OrderedTypeIDs) > 0 {
+		options = append(options, WithOrderedDelivery(cfg.OrderedTypeIDs...))
+	}
+
+	return NewDirectUniversalBus(cfg.BufferSize, cfg.SegmentCount, cfg.GPUPreferred, cfg.AutoScale,
+		append(options, opts...)...)
+}
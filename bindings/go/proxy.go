@@ -0,0 +1,225 @@
+// TCP forwarding of bus messages between machines
+
+package umsbb
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// DefaultMaxProxyFrameSize bounds the payload of one forwarded message
+const DefaultMaxProxyFrameSize = 64 << 20
+
+// proxyFrameHeaderSize is the uint32 payload length plus the uint32 type ID
+const proxyFrameHeaderSize = 8
+
+// BusProxy forwards messages between buses over TCP
+//
+// Each frame is a big-endian uint32 payload length, a big-endian uint32 type
+// ID and the payload. Forwarding is one-way: Connect drains the local bus
+// and sends to the remote side, and ListenAndForward delivers what it
+// receives to its local bus. Run a pair in each direction for a two-way
+// bridge; using separate buses for each direction avoids forwarding
+// messages back where they came from.
+type BusProxy struct {
+	// MaxFrameSize rejects larger incoming payloads (default: 64MiB)
+	MaxFrameSize uint32
+}
+
+// maxFrameSize returns the configured limit or the default
+func (p *BusProxy) maxFrameSize() uint32 {
+	if p.MaxFrameSize == 0 {
+		return DefaultMaxProxyFrameSize
+	}
+	return p.MaxFrameSize
+}
+
+// ListenAndForward accepts connections on addr and sends every message they
+// carry into bus
+//
+// A full bus applies backpressure: the connection is not read until the bus
+// accepts the current message. ListenAndForward blocks until ctx is done,
+// then closes the listener and every connection and returns ctx.Err().
+//
+// Example:
+//
+//	var proxy umsbb.BusProxy
+//	go proxy.ListenAndForward(":7400", bus, ctx)
+func (p *BusProxy) ListenAndForward(addr string, bus *DirectUniversalBus, ctx context.Context) error {
+	var lc net.ListenConfig
+	ln, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return p.serve(ln, bus, ctx)
+}
+
+// serve forwards the messages of every connection accepted on ln into bus
+// until ctx is done, then closes ln
+func (p *BusProxy) serve(ln net.Listener, bus *DirectUniversalBus, ctx context.Context) error {
+	return serveConns(ln, ctx, func(conn net.Conn) {
+		if err := p.forwardFrom(ctx, conn, bus); err != nil && ctx.Err() == nil {
+			fmt.Printf("[Go Proxy] connection from %s closed: %v\n", conn.RemoteAddr(), err)
+		}
+	})
+}
+
+// serveConns calls handle in its own goroutine for every connection accepted
+// on ln, closing the connection when handle returns
+//
+// When ctx is done ln and every open connection are closed; serveConns
+// returns once every handle has returned.
+func serveConns(ln net.Listener, ctx context.Context, handle func(net.Conn)) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	conns := make(map[net.Conn]struct{})
+
+	stop := context.AfterFunc(ctx, func() {
+		ln.Close()
+		mu.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		mu.Unlock()
+	})
+	defer stop()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			wg.Wait()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		// A connection accepted as ctx ended may have missed the sweep that
+		// closes the others
+		mu.Lock()
+		if ctx.Err() != nil {
+			mu.Unlock()
+			conn.Close()
+			continue
+		}
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+				conn.Close()
+			}()
+
+			handle(conn)
+		}()
+	}
+}
+
+// forwardFrom reads frames from r and sends them into bus until r ends
+func (p *BusProxy) forwardFrom(ctx context.Context, r io.Reader, bus *DirectUniversalBus) error {
+	for {
+		msg, err := p.readFrame(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := sendContext(ctx, bus, msg.Data, msg.TypeID); err != nil {
+			return err
+		}
+	}
+}
+
+// readFrame reads one frame from r, returning io.EOF if r ends before it
+func (p *BusProxy) readFrame(r io.Reader) (*UniversalData, error) {
+	var header [proxyFrameHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[0:4])
+	typeID := binary.BigEndian.Uint32(header[4:8])
+	if size == 0 || size > p.maxFrameSize() {
+		return nil, fmt.Errorf("invalid frame size %d", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return &UniversalData{Data: data, TypeID: typeID}, nil
+}
+
+// Connect dials addr and forwards every message received from bus to it
+//
+// Connect blocks until ctx is done, returning ctx.Err(), or until the
+// connection fails. A message whose write fails is sent back into bus so a
+// later Connect can forward it, although it may then arrive after messages
+// sent since.
+//
+// Example:
+//
+//	var proxy umsbb.BusProxy
+//	if err := proxy.Connect("bus-gateway:7400", bus, ctx); err != nil {
+//	    log.Printf("proxy stopped: %v", err)
+//	}
+func (p *BusProxy) Connect(addr string, bus *DirectUniversalBus, ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return forwardTo(ctx, conn, bus, func(conn net.Conn, msg *UniversalData) error {
+		return writeProxyFrame(conn, msg)
+	})
+}
+
+// forwardTo writes every message received from bus to conn with write until
+// ctx is done or a write fails, then closes conn
+//
+// A message whose write fails is sent back into bus.
+func forwardTo(ctx context.Context, conn net.Conn, bus *DirectUniversalBus, write func(net.Conn, *UniversalData) error) error {
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	for {
+		msg, err := receiveDataContext(ctx, bus)
+		if err != nil {
+			return err
+		}
+
+		if err := write(conn, msg); err != nil {
+			if sendErr := bus.Send(msg.Data, msg.TypeID); sendErr != nil {
+				return fmt.Errorf("%w (and the message was lost: %v)", err, sendErr)
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+	}
+}
+
+// writeProxyFrame writes msg as one length-prefixed frame
+func writeProxyFrame(w io.Writer, msg *UniversalData) error {
+	frame := make([]byte, proxyFrameHeaderSize+len(msg.Data))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(msg.Data)))
+	binary.BigEndian.PutUint32(frame[4:8], msg.TypeID)
+	copy(frame[proxyFrameHeaderSize:], msg.Data)
+
+	_, err := w.Write(frame)
+	return err
+}
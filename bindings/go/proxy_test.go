@@ -0,0 +1,75 @@
+package umsbb_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+// dialRetry dials addr until the listener is up
+func dialRetry(t *testing.T, network, addr string) net.Conn {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		conn, err := net.Dial(network, addr)
+		if err == nil {
+			return conn
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Dial %s failed: %v", addr, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestListenAndForwardClosesConnectionsOnCancel(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t)
+	addr := freeAddr(t)
+
+	umsbbtest.RequireNoGoroutineLeak(t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			var proxy umsbb.BusProxy
+			done <- proxy.ListenAndForward(addr, bus, ctx)
+		}()
+
+		conn := dialRetry(t, "tcp", addr)
+		defer conn.Close()
+
+		// Keep dialing while the proxy shuts down, so connections arrive
+		// as the listener closes
+		stopDialing := make(chan struct{})
+		dialed := make(chan struct{})
+		go func() {
+			defer close(dialed)
+			for {
+				select {
+				case <-stopDialing:
+					return
+				default:
+				}
+				if c, err := net.Dial("tcp", addr); err == nil {
+					c.Close()
+				}
+			}
+		}()
+		cancel()
+
+		select {
+		case err := <-done:
+			if err != context.Canceled {
+				t.Fatalf("ListenAndForward = %v, want context.Canceled", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("ListenAndForward still running with connections open")
+		}
+		close(stopDialing)
+		<-dialed
+	})
+}
@@ -0,0 +1,270 @@
+// Leader-only sends for buses replicated across nodes
+
+package umsbb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrNoLeader is returned by RaftBus.Send when no leader is elected within
+// the configured election wait
+var ErrNoLeader = errors.New("no raft leader available")
+
+// DefaultRaftElectionWait is how long Send waits for a leader by default
+const DefaultRaftElectionWait = 5 * time.Second
+
+// raftLeaderPollInterval is how often Send checks for a new leader
+const raftLeaderPollInterval = 50 * time.Millisecond
+
+// Acknowledgements written back for each forwarded frame
+const (
+	raftAckAccepted  byte = 0
+	raftAckNotLeader byte = 1
+	raftAckFailed    byte = 2
+)
+
+// RaftNode is the view of a Raft node that RaftBus needs
+//
+// The package has no dependencies, so it does not import a Raft
+// implementation; adapting hashicorp/raft takes a few lines:
+//
+//	type hashicorpNode struct {
+//	    r     *raft.Raft
+//	    addrs map[raft.ServerID]string // server ID -> RaftConfig.ForwardAddr
+//	}
+//
+//	func (n hashicorpNode) IsLeader() bool { return n.r.State() == raft.Leader }
+//
+//	func (n hashicorpNode) LeaderForwardAddr() string {
+//	    _, id := n.r.LeaderWithID()
+//	    return n.addrs[id]
+//	}
+type RaftNode interface {
+	// IsLeader reports whether this node is the current leader
+	IsLeader() bool
+	// LeaderForwardAddr returns the leader's RaftConfig.ForwardAddr, or ""
+	// while no leader is known
+	LeaderForwardAddr() string
+}
+
+// RaftConfig configures a RaftBus
+type RaftConfig struct {
+	// Node reports leadership; required
+	Node RaftNode
+	// Bus is this node's local bus; required
+	Bus *DirectUniversalBus
+	// ForwardAddr is the host and port this node listens on for forwarded
+	// sends, and that followers dial while it leads; required. The listener
+	// is not authenticated, so bind it to an interface only the cluster can
+	// reach.
+	ForwardAddr string
+	// ElectionWait bounds how long Send waits for a reachable leader
+	// (default: DefaultRaftElectionWait)
+	ElectionWait time.Duration
+}
+
+var _ Bus = (*RaftBus)(nil)
+
+// RaftBus accepts sends only on the Raft leader; followers forward them to
+// the leader over TCP
+//
+// Leader election is left to the RaftNode: when the leader fails, Send
+// waits for the new one and retries, so callers using the Bus interface see
+// only a slower send. Receives read the local bus, so consumers should run
+// on the leader. Forwarding uses the BusProxy frame format, and the leader
+// acknowledges each frame once its bus has accepted it. A node that is no
+// longer leader refuses forwarded frames, and the follower retries them on
+// the new leader. A frame whose acknowledgement is lost is retried too, so a
+// message can be delivered twice but is not lost.
+type RaftBus struct {
+	cfg    RaftConfig
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex // serializes forwarding
+	conn     net.Conn
+	connAddr string
+}
+
+// NewRaftBus listens on cfg.ForwardAddr for forwarded sends and returns a
+// bus that routes sends to the leader
+//
+// Example:
+//
+//	rb, err := umsbb.NewRaftBus(umsbb.RaftConfig{
+//	    Node:        hashicorpNode{r: r, addrs: forwardAddrs},
+//	    Bus:         bus,
+//	    ForwardAddr: "10.0.0.5:7401", // a cluster-only interface
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer rb.Close()
+//	err = rb.Send(payload, typeID) // lands on the leader's bus
+func NewRaftBus(cfg RaftConfig) (*RaftBus, error) {
+	if cfg.Node == nil || cfg.Bus == nil || cfg.ForwardAddr == "" {
+		return nil, errors.New("raft bus needs a node, a local bus and a forward address")
+	}
+	if host, _, err := net.SplitHostPort(cfg.ForwardAddr); err != nil || host == "" {
+		return nil, fmt.Errorf("raft forward address %q must name the host to bind", cfg.ForwardAddr)
+	}
+	if cfg.ElectionWait <= 0 {
+		cfg.ElectionWait = DefaultRaftElectionWait
+	}
+
+	ln, err := net.Listen("tcp", cfg.ForwardAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rb := &RaftBus{cfg: cfg, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(rb.done)
+		serveConns(ln, ctx, func(conn net.Conn) {
+			if err := rb.acceptForwarded(ctx, conn); err != nil && ctx.Err() == nil {
+				fmt.Printf("[Go Raft] connection from %s closed: %v\n", conn.RemoteAddr(), err)
+			}
+		})
+	}()
+	return rb, nil
+}
+
+// acceptForwarded sends the frames a follower forwards into the local bus,
+// acknowledging each, until the follower disconnects
+func (rb *RaftBus) acceptForwarded(ctx context.Context, conn net.Conn) error {
+	var proxy BusProxy
+	for {
+		msg, err := proxy.readFrame(conn)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		ack := raftAckAccepted
+		if !rb.cfg.Node.IsLeader() {
+			ack = raftAckNotLeader
+		} else if err := sendContext(ctx, rb.cfg.Bus, msg.Data, msg.TypeID); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			ack = raftAckFailed
+		}
+		if _, err := conn.Write([]byte{ack}); err != nil {
+			return err
+		}
+	}
+}
+
+// Send sends data on the local bus when this node leads, and otherwise
+// forwards it to the leader, waiting out elections up to ElectionWait
+func (rb *RaftBus) Send(data []byte, typeID uint32) error {
+	deadline := time.Now().Add(rb.cfg.ElectionWait)
+	var lastErr error
+	for {
+		if rb.cfg.Node.IsLeader() {
+			return rb.cfg.Bus.Send(data, typeID)
+		}
+		if addr := rb.cfg.Node.LeaderForwardAddr(); addr != "" {
+			err := rb.forward(addr, &UniversalData{Data: data, TypeID: typeID})
+			if err == nil {
+				return nil
+			}
+			lastErr = err
+		}
+
+		if time.Now().After(deadline) {
+			if lastErr != nil {
+				return fmt.Errorf("%w: %v", ErrNoLeader, lastErr)
+			}
+			return ErrNoLeader
+		}
+		time.Sleep(raftLeaderPollInterval)
+	}
+}
+
+// forward writes msg to the leader at addr, redialing when the leader
+// changed, and waits for the leader to accept it
+func (rb *RaftBus) forward(addr string, msg *UniversalData) error {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	if rb.conn != nil && rb.connAddr != addr {
+		rb.conn.Close()
+		rb.conn = nil
+	}
+	if rb.conn == nil {
+		conn, err := net.DialTimeout("tcp", addr, rb.cfg.ElectionWait)
+		if err != nil {
+			return err
+		}
+		rb.conn, rb.connAddr = conn, addr
+	}
+
+	ack, err := rb.exchange(msg)
+	if err != nil {
+		rb.conn.Close()
+		rb.conn = nil
+		return err
+	}
+	switch ack {
+	case raftAckAccepted:
+		return nil
+	case raftAckNotLeader:
+		return fmt.Errorf("%s is no longer the leader", addr)
+	default:
+		return fmt.Errorf("leader %s could not accept the message", addr)
+	}
+}
+
+// exchange writes msg on rb.conn and reads its acknowledgement, giving up
+// after ElectionWait
+//
+// Callers must hold rb.mu.
+func (rb *RaftBus) exchange(msg *UniversalData) (byte, error) {
+	if err := rb.conn.SetDeadline(time.Now().Add(rb.cfg.ElectionWait)); err != nil {
+		return 0, err
+	}
+	if err := writeProxyFrame(rb.conn, msg); err != nil {
+		return 0, err
+	}
+	var ack [1]byte
+	if _, err := io.ReadFull(rb.conn, ack[:]); err != nil {
+		return 0, err
+	}
+	return ack[0], nil
+}
+
+// Receive returns the next payload from the local bus
+func (rb *RaftBus) Receive() ([]byte, error) {
+	return rb.cfg.Bus.Receive()
+}
+
+// ReceiveData returns the next message from the local bus
+func (rb *RaftBus) ReceiveData() (*UniversalData, error) {
+	return rb.cfg.Bus.ReceiveData()
+}
+
+// Close stops accepting forwarded sends, closes the connection to the
+// leader and closes the local bus
+func (rb *RaftBus) Close() error {
+	rb.cancel()
+	<-rb.done
+
+	rb.mu.Lock()
+	if rb.conn != nil {
+		rb.conn.Close()
+		rb.conn = nil
+	}
+	rb.mu.Unlock()
+
+	return rb.cfg.Bus.Close()
+}
@@ -0,0 +1,137 @@
+package umsbb_test
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+// fakeCluster is a leader election the test controls
+type fakeCluster struct {
+	mu     sync.Mutex
+	leader string // forward address of the leader, as every node sees it
+}
+
+func (c *fakeCluster) setLeader(addr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.leader = addr
+}
+
+// fakeNode is one member of a fakeCluster; stale nodes keep reporting an
+// old leader until told otherwise
+type fakeNode struct {
+	cluster *fakeCluster
+	addr    string
+
+	mu        sync.Mutex
+	staleAddr string
+}
+
+func (n *fakeNode) IsLeader() bool {
+	n.cluster.mu.Lock()
+	defer n.cluster.mu.Unlock()
+	return n.cluster.leader == n.addr
+}
+
+func (n *fakeNode) LeaderForwardAddr() string {
+	n.mu.Lock()
+	stale := n.staleAddr
+	n.mu.Unlock()
+	if stale != "" {
+		return stale
+	}
+
+	n.cluster.mu.Lock()
+	defer n.cluster.mu.Unlock()
+	return n.cluster.leader
+}
+
+// freeAddr returns a loopback address nothing is listening on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// newRaftNode starts a RaftBus for a new node of cluster
+func newRaftNode(t *testing.T, cluster *fakeCluster) (*umsbb.RaftBus, *fakeNode, *umsbb.DirectUniversalBus) {
+	t.Helper()
+
+	node := &fakeNode{cluster: cluster, addr: freeAddr(t)}
+	bus := umsbbtest.NewTestBus(t)
+	rb, err := umsbb.NewRaftBus(umsbb.RaftConfig{Node: node, Bus: bus, ForwardAddr: node.addr, ElectionWait: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("NewRaftBus failed: %v", err)
+	}
+	t.Cleanup(func() { _ = rb.Close() })
+	return rb, node, bus
+}
+
+// requireQueued checks that bus holds exactly want messages
+func requireQueued(t *testing.T, bus *umsbb.DirectUniversalBus, want int) {
+	t.Helper()
+
+	if n, err := bus.Size(); err != nil || n != want {
+		t.Fatalf("Size = %d, %v; want %d", n, err, want)
+	}
+}
+
+func TestRaftBusRequiresBindHost(t *testing.T) {
+	cluster := &fakeCluster{}
+	_, err := umsbb.NewRaftBus(umsbb.RaftConfig{
+		Node:        &fakeNode{cluster: cluster},
+		Bus:         umsbbtest.NewTestBus(t),
+		ForwardAddr: ":0",
+	})
+	if err == nil {
+		t.Fatal("NewRaftBus with no bind host succeeded, want error")
+	}
+}
+
+func TestRaftBusFollowerForwardsToLeader(t *testing.T) {
+	cluster := &fakeCluster{}
+	_, leader, leaderBus := newRaftNode(t, cluster)
+	follower, _, followerBus := newRaftNode(t, cluster)
+	cluster.setLeader(leader.addr)
+
+	if err := follower.Send([]byte("hello"), 1); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	// Send returns only after the leader acknowledged, so no waiting is needed
+	requireQueued(t, leaderBus, 1)
+	requireQueued(t, followerBus, 0)
+}
+
+func TestRaftBusRetriesWhenLeaderSteppedDown(t *testing.T) {
+	cluster := &fakeCluster{}
+	_, old, oldBus := newRaftNode(t, cluster)
+	_, next, nextBus := newRaftNode(t, cluster)
+	follower, followerNode, _ := newRaftNode(t, cluster)
+
+	// The follower still believes in the old leader after the election
+	cluster.setLeader(next.addr)
+	followerNode.mu.Lock()
+	followerNode.staleAddr = old.addr
+	followerNode.mu.Unlock()
+	time.AfterFunc(200*time.Millisecond, func() {
+		followerNode.mu.Lock()
+		followerNode.staleAddr = ""
+		followerNode.mu.Unlock()
+	})
+
+	if err := follower.Send([]byte("hello"), 1); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	requireQueued(t, oldBus, 0)
+	requireQueued(t, nextBus, 1)
+}
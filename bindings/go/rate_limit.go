@@ -0,0 +1,243 @@
+// Rate limiters shared by a process or, through Redis, by many processes
+
+package umsbb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter decides whether an event may happen now
+type Limiter interface {
+	// Allow reports whether one event may happen now, consuming it if so
+	Allow() bool
+	// Wait blocks until one event is allowed or ctx is done
+	Wait(ctx context.Context) error
+}
+
+// waitAllow polls allow every interval until it succeeds or ctx is done
+func waitAllow(ctx context.Context, allow func() bool, interval time.Duration) error {
+	for {
+		if allow() {
+			return nil
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// rateInterval is the time one event takes at limit per second, or a second
+// when no events are allowed
+func rateInterval(limit float64) time.Duration {
+	if limit <= 0 {
+		return time.Second
+	}
+	return time.Duration(float64(time.Second) / limit)
+}
+
+var _ Limiter = (*LocalLimiter)(nil)
+
+// LocalLimiter is an in-process token bucket
+type LocalLimiter struct {
+	limit float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLocalLimiter allows limit events per second with bursts of up to burst
+//
+// A burst below 1 is raised to 1.
+func NewLocalLimiter(limit float64, burst int) *LocalLimiter {
+	b := math.Max(float64(burst), 1)
+	return &LocalLimiter{limit: limit, burst: b, tokens: b, last: time.Now()}
+}
+
+// Allow takes a token if one is available
+func (l *LocalLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.limit)
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Wait blocks until a token is available or ctx is done
+func (l *LocalLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	interval := rateInterval(l.limit)
+	l.mu.Unlock()
+	return waitAllow(ctx, l.Allow, interval)
+}
+
+// SetLimit changes the rate to limit events per second
+//
+// Tokens earned at the old rate are kept; a limit of 0 or less also
+// discards them, so no further events are allowed.
+func (l *LocalLimiter) SetLimit(limit float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.limit)
+	l.last = now
+	l.limit = limit
+	if limit <= 0 {
+		l.tokens = 0
+	}
+}
+
+// RedisCounter is the subset of a Redis client used by DistributedLimiter
+//
+// A go-redis client adapts with two one-line methods:
+//
+//	type redisCounter struct{ *redis.Client }
+//
+//	func (c redisCounter) Incr(ctx context.Context, key string) (int64, error) {
+//	    return c.Client.Incr(ctx, key).Result()
+//	}
+//
+//	func (c redisCounter) Expire(ctx context.Context, key string, ttl time.Duration) error {
+//	    return c.Client.Expire(ctx, key, ttl).Err()
+//	}
+type RedisCounter interface {
+	Incr(ctx context.Context, key string) (int64, error)
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+}
+
+// redisRetryInterval is how long DistributedLimiter uses its local fallback
+// after a Redis error before trying Redis again
+const redisRetryInterval = time.Second
+
+// redisTimeout bounds each Redis round trip so an unreachable server falls
+// back quickly instead of stalling the caller
+const redisTimeout = 50 * time.Millisecond
+
+var _ Limiter = (*DistributedLimiter)(nil)
+
+// DistributedLimiter shares a rate limit between processes through Redis
+//
+// Time is divided into windows of burst/limit seconds, each allowing burst
+// events, so the long-run rate is limit per second. Each event INCRs a
+// per-window key, and the first event of a window sets its expiry. When
+// Redis is unavailable the limiter falls back to a local token bucket with
+// the same limit, which is per process, until Redis answers again.
+type DistributedLimiter struct {
+	client RedisCounter
+	key    string
+	limit  float64
+	burst  int64
+	window time.Duration
+	local  *LocalLimiter
+
+	mu         sync.Mutex
+	retryAfter time.Time
+	lastErr    error
+}
+
+// RedisRateLimiter creates a limiter shared by every process using key
+//
+// Example:
+//
+//	limiter, err := umsbb.RedisRateLimiter(redisCounter{client}, "umsbb:ingest", 500, 50)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := limiter.Wait(ctx); err == nil {
+//	    _ = bus.Send(payload, 1)
+//	}
+func RedisRateLimiter(client RedisCounter, key string, limit float64, burst int) (*DistributedLimiter, error) {
+	if client == nil {
+		return nil, errors.New("redis client cannot be nil")
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("rate limit must be positive, got %v", limit)
+	}
+	burst = max(burst, 1)
+
+	return &DistributedLimiter{
+		client: client,
+		key:    key,
+		limit:  limit,
+		burst:  int64(burst),
+		window: max(time.Duration(float64(burst)/limit*float64(time.Second)), time.Millisecond),
+		local:  NewLocalLimiter(limit, burst),
+	}, nil
+}
+
+// Allow counts the event in the current window and reports whether it fits
+func (l *DistributedLimiter) Allow() bool {
+	if l.usingFallback() {
+		return l.local.Allow()
+	}
+
+	allowed, err := l.allowRedis()
+	if err != nil {
+		l.mu.Lock()
+		l.retryAfter = time.Now().Add(redisRetryInterval)
+		l.lastErr = err
+		l.mu.Unlock()
+		return l.local.Allow()
+	}
+	return allowed
+}
+
+// Wait blocks until an event is allowed or ctx is done
+func (l *DistributedLimiter) Wait(ctx context.Context) error {
+	return waitAllow(ctx, l.Allow, rateInterval(l.limit))
+}
+
+// Err returns the most recent Redis error, or nil if Redis has never failed
+func (l *DistributedLimiter) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.lastErr
+}
+
+// usingFallback reports whether a recent Redis error still applies
+func (l *DistributedLimiter) usingFallback() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return time.Now().Before(l.retryAfter)
+}
+
+// allowRedis increments the current window's counter
+func (l *DistributedLimiter) allowRedis() (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	window := time.Now().UnixNano() / int64(l.window)
+	key := fmt.Sprintf("%s:%d", l.key, window)
+
+	count, err := l.client.Incr(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("redis INCR %s: %w", key, err)
+	}
+	if count == 1 {
+		// Keep the key a little past its window so late INCRs still see it
+		if err := l.client.Expire(ctx, key, 2*l.window); err != nil {
+			return false, fmt.Errorf("redis EXPIRE %s: %w", key, err)
+		}
+	}
+	return count <= l.burst, nil
+}
@@ -0,0 +1,104 @@
+// Exponentially weighted send and receive rates
+
+package umsbb
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// ewmaState is an immutable rate estimate as of a point in time
+type ewmaState struct {
+	rate float64 // events per second
+	at   int64   // unix nanoseconds
+}
+
+// ewmaRate estimates an event rate with exponential decay
+//
+// Each event adds lambda to a rate that decays by half every half-life, so
+// a steady stream of r events per second converges on r. Updates swap an
+// immutable state with compare-and-swap, so concurrent events need no lock.
+type ewmaRate struct {
+	lambda float64 // ln 2 / half-life, per second
+	state  atomic.Pointer[ewmaState]
+}
+
+// decayed returns s's rate decayed to now
+func (e *ewmaRate) decayed(s *ewmaState, now int64) float64 {
+	if s == nil {
+		return 0
+	}
+	dt := float64(now-s.at) / float64(time.Second)
+	return s.rate * math.Exp(-e.lambda*max(dt, 0))
+}
+
+// mark records one event
+func (e *ewmaRate) mark() {
+	now := time.Now().UnixNano()
+	for {
+		old := e.state.Load()
+		next := &ewmaState{rate: e.decayed(old, now) + e.lambda, at: now}
+		if e.state.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// current returns the rate decayed to now
+func (e *ewmaRate) current() float64 {
+	return e.decayed(e.state.Load(), time.Now().UnixNano())
+}
+
+// RateMeter tracks the EWMA send and receive rates of a bus
+type RateMeter struct {
+	send    ewmaRate
+	receive ewmaRate
+}
+
+// newRateMeter creates a meter whose rates halve every halfLife without events
+func newRateMeter(halfLife time.Duration) *RateMeter {
+	lambda := math.Ln2 / halfLife.Seconds()
+	return &RateMeter{
+		send:    ewmaRate{lambda: lambda},
+		receive: ewmaRate{lambda: lambda},
+	}
+}
+
+// EnableRateMeter starts tracking send and receive rates with the given half-life
+//
+// A shorter half-life follows bursts more closely; a longer one smooths
+// them out. Calling it again resets the rates. A halfLife of 0 or less
+// disables the meter.
+//
+// Example:
+//
+//	bus.EnableRateMeter(5 * time.Second)
+//	fmt.Printf("send %.0f/s, receive %.0f/s\n", bus.SendRate(), bus.ReceiveRate())
+func (b *DirectUniversalBus) EnableRateMeter(halfLife time.Duration) {
+	if halfLife <= 0 {
+		b.rates.Store(nil)
+		return
+	}
+	b.rates.Store(newRateMeter(halfLife))
+}
+
+// SendRate returns the EWMA rate of successful sends in messages per second
+//
+// It is 0 until EnableRateMeter is called.
+func (b *DirectUniversalBus) SendRate() float64 {
+	if m := b.rates.Load(); m != nil {
+		return m.send.current()
+	}
+	return 0
+}
+
+// ReceiveRate returns the EWMA rate of received messages in messages per second
+//
+// It is 0 until EnableRateMeter is called.
+func (b *DirectUniversalBus) ReceiveRate() float64 {
+	if m := b.rates.Load(); m != nil {
+		return m.receive.current()
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+// Blocking receive of a fixed number of messages
+
+package umsbb
+
+import (
+	"context"
+	"errors"
+)
+
+// ReadFully blocks until n messages have been received or ctx is done
+//
+// On success exactly n messages are returned in receive order. If ctx ends
+// or a receive fails first, the messages received so far are returned with
+// the error, so tests can report what did arrive.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+//	defer cancel()
+//	msgs, err := bus.ReadFully(ctx, 5)
+//	if err != nil {
+//	    t.Fatalf("got %d of 5 messages: %v", len(msgs), err)
+//	}
+func (b *DirectUniversalBus) ReadFully(ctx context.Context, n int) ([]UniversalData, error) {
+	if n < 0 {
+		return nil, errors.New("message count cannot be negative")
+	}
+
+	msgs := make([]UniversalData, 0, n)
+	for len(msgs) < n {
+		msg, err := receiveDataContext(ctx, b)
+		if err != nil {
+			return msgs, err
+		}
+		msgs = append(msgs, *msg)
+	}
+	return msgs, nil
+}
@@ -0,0 +1,118 @@
+// Automatic recreation of invalidated C handles
+
+package umsbb
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ReconnectEvent describes a successful handle recreation
+type ReconnectEvent struct {
+	// Attempts is the number of recreations tried, including the successful one
+	Attempts int
+	// Cause is the error that triggered the reconnect
+	Cause error
+	// At is when the new handle became active
+	At time.Time
+}
+
+// reconnectPolicy is set with WithAutoReconnect
+type reconnectPolicy struct {
+	maxAttempts int
+	backoff     time.Duration
+}
+
+// enabled reports whether automatic reconnection is configured
+func (p reconnectPolicy) enabled() bool {
+	return p.maxAttempts > 0
+}
+
+// reconnectListeners holds the callbacks registered with OnReconnect
+type reconnectListeners struct {
+	mu        sync.Mutex
+	listeners []func(ReconnectEvent)
+}
+
+// OnReconnect registers fn to be called after the bus recreates its C handle
+//
+// Listeners run synchronously on the goroutine whose send triggered the
+// reconnect, so they should return quickly.
+//
+// Example:
+//
+//	bus.OnReconnect(func(ev umsbb.ReconnectEvent) {
+//	    log.Printf("bus reconnected after %d attempts: %v", ev.Attempts, ev.Cause)
+//	})
+func (b *DirectUniversalBus) OnReconnect(fn func(ReconnectEvent)) {
+	b.reconnects.mu.Lock()
+	defer b.reconnects.mu.Unlock()
+
+	b.reconnects.listeners = append(b.reconnects.listeners, fn)
+}
+
+// handleInvalid reports whether err says the C library no longer accepts
+// the bus handle, as opposed to a full segment or a bad message
+func handleInvalid(err error) bool {
+	return errors.Is(err, syscall.EBADF)
+}
+
+// reconnectAndRetry recreates the handle and retries the failed operation
+// after each successful recreation, until it succeeds, fails for a reason
+// other than an invalid handle, or attempts run out
+//
+// gen is the handle generation the failed operation used. Concurrent
+// senders that fail on the same handle recreate it once: the first to take
+// the lock advances the generation and the others just retry on its handle.
+func (b *DirectUniversalBus) reconnectAndRetry(cause error, gen uint64, retry func() error) error {
+	policy := b.opts().reconnect
+
+	for attempt := 1; attempt <= policy.maxAttempts; attempt++ {
+		if b.handleGeneration() == gen {
+			time.Sleep(policy.backoff * time.Duration(attempt))
+		}
+
+		b.mu.Lock()
+		if b.handle == nil {
+			// Closed by the caller, not invalidated by the library
+			b.mu.Unlock()
+			return cause
+		}
+		var err error
+		recreated := false
+		if b.generation == gen {
+			err = b.recreateHandle()
+			recreated = err == nil
+		}
+		gen = b.generation
+		b.mu.Unlock()
+		if err != nil {
+			continue
+		}
+
+		if recreated {
+			b.ordered.resync()
+			b.notifyReconnect(ReconnectEvent{Attempts: attempt, Cause: cause, At: time.Now()})
+		}
+
+		err = retry()
+		if !handleInvalid(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("reconnect failed after %d attempts: %w", policy.maxAttempts, cause)
+}
+
+// notifyReconnect calls every registered listener
+func (b *DirectUniversalBus) notifyReconnect(ev ReconnectEvent) {
+	b.reconnects.mu.Lock()
+	listeners := append([]func(ReconnectEvent){}, b.reconnects.listeners...)
+	b.reconnects.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(ev)
+	}
+}
@@ -0,0 +1,79 @@
+//go:build nocgo
+
+package umsbb
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFullSegmentDoesNotReconnect(t *testing.T) {
+	bus, err := NewDirectUniversalBus(64, 1, false, false, WithAutoReconnect(3, time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewDirectUniversalBus failed: %v", err)
+	}
+	defer bus.Close()
+
+	var reconnects atomic.Int32
+	bus.OnReconnect(func(ReconnectEvent) { reconnects.Add(1) })
+
+	queued := 0
+	for ; queued < 100; queued++ {
+		if err = bus.Send([]byte("0123456789abcdef"), 1); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrSubmitFailed) {
+		t.Fatalf("Send on a full segment = %v, want ErrSubmitFailed", err)
+	}
+	if n := reconnects.Load(); n != 0 {
+		t.Fatalf("full segment caused %d reconnects, want 0", n)
+	}
+	if n, _ := bus.Size(); n != queued {
+		t.Fatalf("Size = %d after backpressure, want the %d queued messages kept", n, queued)
+	}
+}
+
+func TestConcurrentSendersShareOneReconnect(t *testing.T) {
+	bus, err := NewDirectUniversalBus(64*1024, 1, false, false, WithAutoReconnect(3, time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewDirectUniversalBus failed: %v", err)
+	}
+	defer bus.Close()
+
+	var reconnects atomic.Int32
+	bus.OnReconnect(func(ReconnectEvent) { reconnects.Add(1) })
+
+	// Invalidate the handle behind the bus's back, as a library reset would
+	destroyHandle(bus.handle)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- bus.Send([]byte("after reset"), 1)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Send failed: %v", err)
+		}
+	}
+	if n := reconnects.Load(); n != 1 {
+		t.Fatalf("%d reconnects, want 1", n)
+	}
+	if gen := bus.handleGeneration(); gen != 1 {
+		t.Fatalf("handle generation = %d, want 1", gen)
+	}
+	if n, _ := bus.Size(); n != 20 {
+		t.Fatalf("Size = %d, want all 20 sends on the new handle", n)
+	}
+}
@@ -0,0 +1,88 @@
+// Channel-based receiving
+
+package umsbb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RecvChan receives from the bus in a background goroutine and delivers
+// messages on the returned data channel
+//
+// bufSize is the capacity of the data channel. Receive errors are sent on
+// the error channel, which holds one error; errors raised while one is
+// still unread are dropped so they never hold up delivery. After an error
+// the goroutine waits one poll interval before receiving again. Both
+// channels are closed once ctx is done; a message drained but not yet
+// delivered by then is sent back to the bus.
+//
+// Example:
+//
+//	msgs, errs := bus.RecvChan(ctx, 64)
+//	go func() {
+//	    for err := range errs {
+//	        log.Printf("receive failed: %v", err)
+//	    }
+//	}()
+//	for msg := range msgs {
+//	    handle(msg)
+//	}
+func (b *DirectUniversalBus) RecvChan(ctx context.Context, bufSize int) (<-chan UniversalData, <-chan error) {
+	msgs := make(chan UniversalData, max(bufSize, 0))
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(msgs)
+		defer close(errs)
+
+		for {
+			msg, err := receiveDataContext(ctx, b)
+			if msg != nil {
+				if !deliverOrReturn(ctx, b, msgs, *msg) {
+					return
+				}
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				select {
+				case errs <- err:
+				default:
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(receivePollInterval):
+				}
+			}
+		}
+	}()
+	return msgs, errs
+}
+
+// deliverOrReturn sends msg on msgs, or puts it back on the bus and returns
+// false if ctx is done first
+//
+// A message drained just as ctx ends is delivered if msgs has room, so it
+// is never dropped between the bus and the channel.
+func deliverOrReturn(ctx context.Context, b *DirectUniversalBus, msgs chan<- UniversalData, msg UniversalData) bool {
+	select {
+	case msgs <- msg:
+		return true
+	default:
+	}
+	select {
+	case msgs <- msg:
+		return true
+	case <-ctx.Done():
+	}
+
+	if err := b.Send(msg.Data, msg.TypeID); err != nil {
+		fmt.Printf("[Go RecvChan] message lost on cancel: %v\n", err)
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package umsbb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+func TestRecvChanReturnsUndeliveredMessageOnCancel(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t)
+
+	umsbbtest.RequireNoGoroutineLeak(t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		msgs, _ := bus.RecvChan(ctx, 0)
+
+		if err := bus.Send([]byte("pending"), 1); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		// Nobody reads msgs, so the goroutine holds the drained message
+		deadline := time.Now().Add(time.Second)
+		for {
+			n, err := bus.Size()
+			if err != nil {
+				t.Fatalf("Size failed: %v", err)
+			}
+			if n == 0 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("RecvChan never drained the message")
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		cancel()
+		for range msgs {
+			t.Fatal("message delivered after cancel with no reader")
+		}
+
+		got, err := bus.Receive()
+		if err != nil || string(got) != "pending" {
+			t.Fatalf("Receive = %q, %v; want the undelivered message back on the bus", got, err)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+// Process-wide registry of named buses
+
+package umsbb
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBusNameTaken is returned when registering a name that is already in use
+var ErrBusNameTaken = errors.New("bus name already registered")
+
+// BusRegistry shares buses between packages by name
+type BusRegistry struct {
+	buses sync.Map // name -> *DirectUniversalBus
+}
+
+// DefaultBusRegistry is the process-wide registry used by the package-level helpers
+var DefaultBusRegistry = &BusRegistry{}
+
+// Register makes bus available under name
+//
+// Example:
+//
+//	if err := umsbb.DefaultBusRegistry.Register("telemetry", bus); err != nil {
+//	    log.Fatal(err)
+//	}
+func (r *BusRegistry) Register(name string, bus *DirectUniversalBus) error {
+	if name == "" {
+		return errors.New("bus name cannot be empty")
+	}
+	if bus == nil {
+		return errors.New("bus cannot be nil")
+	}
+
+	if _, loaded := r.buses.LoadOrStore(name, bus); loaded {
+		return fmt.Errorf("%w: %s", ErrBusNameTaken, name)
+	}
+	return nil
+}
+
+// Lookup returns the bus registered under name
+func (r *BusRegistry) Lookup(name string) (*DirectUniversalBus, bool) {
+	v, ok := r.buses.Load(name)
+	if !ok {
+		return nil, false
+	}
+	return v.(*DirectUniversalBus), true
+}
+
+// MustLookup returns the bus registered under name and panics if there is none
+//
+// Use it where a missing bus is a programming error, such as wiring done at startup.
+func (r *BusRegistry) MustLookup(name string) *DirectUniversalBus {
+	bus, ok := r.Lookup(name)
+	if !ok {
+		panic(fmt.Sprintf("umsbb: no bus registered as %q", name))
+	}
+	return bus
+}
+
+// Deregister removes name from the registry; the bus itself is not closed
+func (r *BusRegistry) Deregister(name string) {
+	r.buses.Delete(name)
+}
+
+// RegisterBus registers bus under name in DefaultBusRegistry
+func RegisterBus(name string, bus *DirectUniversalBus) error {
+	return DefaultBusRegistry.Register(name, bus)
+}
+
+// LookupBus returns the bus registered under name in DefaultBusRegistry
+func LookupBus(name string) (*DirectUniversalBus, bool) {
+	return DefaultBusRegistry.Lookup(name)
+}
+
+// MustLookupBus returns the bus registered under name in DefaultBusRegistry, panicking if absent
+func MustLookupBus(name string) *DirectUniversalBus {
+	return DefaultBusRegistry.MustLookup(name)
+}
+
+// DeregisterBus removes name from DefaultBusRegistry
+func DeregisterBus(name string) {
+	DefaultBusRegistry.Deregister(name)
+}
@@ -0,0 +1,142 @@
+// Detection of out-of-order and missing sequence numbers on receive
+
+package umsbb
+
+import (
+	"sync"
+)
+
+// OutOfOrderEvent describes a received sequence number that did not follow the previous one
+type OutOfOrderEvent struct {
+	// Expected is the sequence number that should have arrived next
+	Expected uint64
+	// Got is the sequence number that arrived
+	Got uint64
+	// Gap is how many sequence numbers were skipped, or 0 for a backwards jump
+	Gap uint64
+	// Backwards is true when Got is not after the previous sequence number
+	Backwards bool
+	// TypeID is the type ID of the message that arrived
+	TypeID uint32
+}
+
+var _ Bus = (*ReorderingDetector)(nil)
+
+// ReorderingDetector watches the sequence numbers of received messages
+//
+// Sequence numbers are extracted from each message by the caller's function
+// and compared with the previous one. A jump forward by more than one is a
+// gap; a number at or before the previous one is out of order. Messages are
+// always delivered, whatever their order.
+type ReorderingDetector struct {
+	bus   Bus
+	seqOf func(UniversalData) uint64
+
+	mu         sync.Mutex
+	started    bool
+	last       uint64
+	maxGap     uint64
+	outOfOrder uint64
+	listeners  []func(OutOfOrderEvent)
+}
+
+// NewReorderingDetector wraps bus and checks the sequence of every received message
+//
+// Example:
+//
+//	detector := umsbb.NewReorderingDetector(bus, func(m umsbb.UniversalData) uint64 {
+//	    return binary.BigEndian.Uint64(m.Data[:8])
+//	})
+//	detector.OnOutOfOrder(func(ev umsbb.OutOfOrderEvent) {
+//	    log.Printf("expected seq %d, got %d", ev.Expected, ev.Got)
+//	})
+func NewReorderingDetector(bus Bus, expectedSeqFn func(UniversalData) uint64) *ReorderingDetector {
+	return &ReorderingDetector{bus: bus, seqOf: expectedSeqFn}
+}
+
+// OnOutOfOrder registers fn to be called for every gap or backwards jump
+//
+// Listeners run synchronously on the receiving goroutine.
+func (d *ReorderingDetector) OnOutOfOrder(fn func(OutOfOrderEvent)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.listeners = append(d.listeners, fn)
+}
+
+// Send forwards to the wrapped bus
+func (d *ReorderingDetector) Send(data []byte, typeID uint32) error {
+	return d.bus.Send(data, typeID)
+}
+
+// Receive returns the next payload after checking its sequence number
+func (d *ReorderingDetector) Receive() ([]byte, error) {
+	return payloadOf(d.ReceiveData())
+}
+
+// ReceiveData returns the next message after checking its sequence number
+func (d *ReorderingDetector) ReceiveData() (*UniversalData, error) {
+	msg, err := d.bus.ReceiveData()
+	if err != nil || msg == nil {
+		return msg, err
+	}
+	d.observe(*msg)
+	return msg, nil
+}
+
+// Close closes the wrapped bus
+func (d *ReorderingDetector) Close() error {
+	return d.bus.Close()
+}
+
+// MaxGapObserved returns the largest number of sequence numbers skipped at once
+func (d *ReorderingDetector) MaxGapObserved() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.maxGap
+}
+
+// OutOfOrderCount returns how many messages arrived at or before the previous sequence number
+func (d *ReorderingDetector) OutOfOrderCount() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.outOfOrder
+}
+
+// observe compares msg's sequence number with the previous one and notifies listeners
+func (d *ReorderingDetector) observe(msg UniversalData) {
+	seq := d.seqOf(msg)
+
+	d.mu.Lock()
+	if !d.started {
+		d.started = true
+		d.last = seq
+		d.mu.Unlock()
+		return
+	}
+
+	ev := OutOfOrderEvent{Expected: d.last + 1, Got: seq, TypeID: msg.TypeID}
+	switch {
+	case seq <= d.last:
+		// The previous high-water mark is kept, so a late message is not
+		// followed by a spurious gap
+		ev.Backwards = true
+		d.outOfOrder++
+	case seq > d.last+1:
+		ev.Gap = seq - d.last - 1
+		d.maxGap = max(d.maxGap, ev.Gap)
+		d.last = seq
+	default:
+		d.last = seq
+		d.mu.Unlock()
+		return
+	}
+	listeners := append([]func(OutOfOrderEvent){}, d.listeners...)
+	d.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(ev)
+	}
+}
@@ -0,0 +1,103 @@
+// Bloom filter of processed message IDs for replays
+
+package umsbb
+
+import (
+	"math"
+	"sync"
+)
+
+// ReplayFilter remembers processed message IDs in a Bloom filter
+//
+// ShouldProcess never reports a marked ID as unprocessed, but may report an
+// unmarked ID as processed with about the configured false-positive rate,
+// so a replay can skip a small fraction of new messages in exchange for
+// constant memory.
+type ReplayFilter struct {
+	mu     sync.RWMutex
+	bits   []uint64
+	hashes uint64
+}
+
+// NewReplayFilter sizes a filter for bloomSize IDs at falsePositiveRate
+//
+// Out-of-range arguments fall back to 1 ID and a 1% false-positive rate.
+//
+// Example:
+//
+//	filter := umsbb.NewReplayFilter(1_000_000, 0.001)
+//	for _, rec := range records {
+//	    if filter.ShouldProcess(rec.ID) {
+//	        process(rec)
+//	        filter.MarkProcessed(rec.ID)
+//	    }
+//	}
+func NewReplayFilter(bloomSize int, falsePositiveRate float64) *ReplayFilter {
+	n := float64(max(bloomSize, 1))
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = bloomFalsePositiveRate
+	}
+
+	// m = -n ln p / (ln 2)^2 bits, k = m/n ln 2 hash functions
+	bitCount := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	return &ReplayFilter{
+		bits:   make([]uint64, (uint64(bitCount)+63)/64),
+		hashes: uint64(max(1, math.Round(bitCount/n*math.Ln2))),
+	}
+}
+
+// positions derives the filter bits for id by double hashing
+func (f *ReplayFilter) positions(id uint64, fn func(bit uint64)) {
+	h1 := splitmix64(id)
+	h2 := splitmix64(h1) | 1
+	size := uint64(len(f.bits)) * 64
+
+	for i := uint64(0); i < f.hashes; i++ {
+		fn((h1 + i*h2) % size)
+	}
+}
+
+// ShouldProcess reports whether id has not been marked processed
+func (f *ReplayFilter) ShouldProcess(id uint64) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	marked := true
+	f.positions(id, func(bit uint64) {
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			marked = false
+		}
+	})
+	return !marked
+}
+
+// MarkProcessed records id as processed
+func (f *ReplayFilter) MarkProcessed(id uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mark(id)
+}
+
+// MarkProcessedBatch records every id in ids under a single lock
+func (f *ReplayFilter) MarkProcessedBatch(ids []uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, id := range ids {
+		f.mark(id)
+	}
+}
+
+// mark sets the bits for id; callers must hold f.mu for writing
+func (f *ReplayFilter) mark(id uint64) {
+	f.positions(id, func(bit uint64) {
+		f.bits[bit/64] |= 1 << (bit % 64)
+	})
+}
+
+// splitmix64 scrambles x so sequential IDs spread across the filter
+func splitmix64(x uint64) uint64 {
+	x += 0x9E3779B97F4A7C15
+	x = (x ^ (x >> 30)) * 0xBF58476D1CE4E5B9
+	x = (x ^ (x >> 27)) * 0x94D049BB133111EB
+	return x ^ (x >> 31)
+}
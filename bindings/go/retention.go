@@ -0,0 +1,203 @@
+// In-memory retention of recently sent messages
+
+package umsbb
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// RetainedMessage is a copy of a sent message kept by the retention buffer
+type RetainedMessage struct {
+	UniversalData
+	SentAt time.Time
+}
+
+// RetentionPolicy limits how many messages of one type ID are retained and
+// for how long
+//
+// A zero MaxMessages or MaxAge leaves that dimension unlimited, bounded only
+// by WithRetentionMemoryLimit.
+type RetentionPolicy struct {
+	MaxMessages int
+	MaxAge      time.Duration
+}
+
+// retentionBuffer keeps the most recent sent messages within count and byte limits
+//
+// Type IDs with a RetentionPolicy are kept in their own queue under that
+// policy instead of the shared count limit; the byte limit covers all queues.
+type retentionBuffer struct {
+	mu            sync.Mutex
+	maxMessages   int
+	maxBytes      uint64
+	retainDefault bool // whether type IDs without a policy are retained
+	entries       []RetainedMessage
+	policies      map[uint32]RetentionPolicy
+	typed         map[uint32][]RetainedMessage
+	bytes         uint64
+}
+
+// newRetentionBuffer returns a buffer for the given limits, or nil if retention is off
+func newRetentionBuffer(maxMessages int, maxBytes uint64, policies map[uint32]RetentionPolicy) *retentionBuffer {
+	retainDefault := maxMessages > 0 || maxBytes > 0
+	if !retainDefault && len(policies) == 0 {
+		return nil
+	}
+	return &retentionBuffer{
+		maxMessages:   maxMessages,
+		maxBytes:      maxBytes,
+		retainDefault: retainDefault,
+		policies:      policies,
+		typed:         make(map[uint32][]RetainedMessage),
+	}
+}
+
+// add retains a copy of data, evicting the oldest entries to stay within limits
+func (r *retentionBuffer) add(data []byte, typeID uint32) {
+	if r == nil {
+		return
+	}
+
+	policy, typed := r.policies[typeID]
+	if !typed && !r.retainDefault {
+		return
+	}
+
+	size := uint64(len(data))
+	if r.maxBytes > 0 && size > r.maxBytes {
+		return
+	}
+
+	msg := RetainedMessage{
+		UniversalData: UniversalData{
+			Data:       append([]byte(nil), data...),
+			TypeID:     typeID,
+			SourceLang: LangGo,
+		},
+		SentAt: time.Now(),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if typed {
+		r.expire(msg.SentAt)
+		queue := r.typed[typeID]
+		if policy.MaxMessages > 0 && len(queue) >= policy.MaxMessages {
+			queue = r.dropFront(queue, len(queue)-policy.MaxMessages+1)
+		}
+		r.typed[typeID] = queue
+	} else if r.maxMessages > 0 && len(r.entries) >= r.maxMessages {
+		r.entries = r.dropFront(r.entries, len(r.entries)-r.maxMessages+1)
+	}
+
+	for r.maxBytes > 0 && r.bytes+size > r.maxBytes && r.dropOldest() {
+	}
+
+	if typed {
+		r.typed[typeID] = append(r.typed[typeID], msg)
+	} else {
+		r.entries = append(r.entries, msg)
+	}
+	r.bytes += size
+}
+
+// dropFront removes the first n entries of queue and returns the rest
+func (r *retentionBuffer) dropFront(queue []RetainedMessage, n int) []RetainedMessage {
+	for _, msg := range queue[:n] {
+		r.bytes -= uint64(len(msg.Data))
+	}
+	clear(queue[:n])
+	return queue[n:]
+}
+
+// dropOldest removes the oldest entry across all queues, reporting whether
+// there was one
+func (r *retentionBuffer) dropOldest() bool {
+	var oldest *RetainedMessage
+	fromTyped, oldestID := false, uint32(0)
+	if len(r.entries) > 0 {
+		oldest = &r.entries[0]
+	}
+	for typeID, queue := range r.typed {
+		if len(queue) > 0 && (oldest == nil || queue[0].SentAt.Before(oldest.SentAt)) {
+			oldest, fromTyped, oldestID = &queue[0], true, typeID
+		}
+	}
+
+	switch {
+	case oldest == nil:
+		return false
+	case fromTyped:
+		r.typed[oldestID] = r.dropFront(r.typed[oldestID], 1)
+	default:
+		r.entries = r.dropFront(r.entries, 1)
+	}
+	return true
+}
+
+// expire drops typed entries older than their policy's MaxAge
+func (r *retentionBuffer) expire(now time.Time) {
+	for typeID, queue := range r.typed {
+		maxAge := r.policies[typeID].MaxAge
+		if maxAge <= 0 {
+			continue
+		}
+		n := 0
+		for n < len(queue) && now.Sub(queue[n].SentAt) > maxAge {
+			n++
+		}
+		if n > 0 {
+			r.typed[typeID] = r.dropFront(queue, n)
+		}
+	}
+}
+
+// snapshot returns the retained messages, oldest first
+func (r *retentionBuffer) snapshot() []RetainedMessage {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expire(time.Now())
+	msgs := append([]RetainedMessage(nil), r.entries...)
+	for _, queue := range r.typed {
+		msgs = append(msgs, queue...)
+	}
+	if len(r.typed) > 0 {
+		slices.SortStableFunc(msgs, func(a, b RetainedMessage) int {
+			return a.SentAt.Compare(b.SentAt)
+		})
+	}
+	return msgs
+}
+
+// usage returns the payload bytes currently retained
+func (r *retentionBuffer) usage() uint64 {
+	if r == nil {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.bytes
+}
+
+// Retained returns copies of the retained sent messages, oldest first
+//
+// Retention is enabled with WithRetention, WithRetentionMemoryLimit or
+// WithRetentionPerType; otherwise Retained returns nil.
+func (b *DirectUniversalBus) Retained() []RetainedMessage {
+	return b.retention.snapshot()
+}
+
+// MemUsage returns the payload bytes held by the retention buffer
+func (b *DirectUniversalBus) MemUsage() uint64 {
+	return b.retention.usage()
+}
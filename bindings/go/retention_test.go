@@ -0,0 +1,94 @@
+package umsbb_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+const (
+	// ttlBenchMessages is how many messages each BenchmarkRetentionTTL run
+	// sends and drains
+	ttlBenchMessages = 1_000_000
+	ttlBenchTypeID   = 3
+	ttlBenchMaxAge   = 10 * time.Millisecond
+	ttlBenchWait     = 15 * time.Millisecond
+
+	// ttlOverheadLimit is the slowdown BenchmarkRetentionTTL accepts when
+	// UMSBB_TTL_GATE is set
+	ttlOverheadLimit = 0.05
+)
+
+// sendDrainEvict sends ttlBenchMessages messages, lets them outlive
+// ttlBenchMaxAge, drains them and reads Retained, returning how long the
+// sends, drains and Retained call took
+//
+// With a MaxAge policy the retained copies are made by the sends and
+// evicted by later sends and by Retained; draining never checks expiry.
+func sendDrainEvict(b *testing.B, opts ...umsbb.BusOption) time.Duration {
+	bus, err := umsbb.NewDirectUniversalBus(64<<20, 1, false, false, opts...)
+	if err != nil {
+		b.Fatalf("NewDirectUniversalBus failed: %v", err)
+	}
+	defer bus.Close()
+
+	payload := []byte("ttl benchmark message")
+	start := time.Now()
+	for i := 0; i < ttlBenchMessages; i++ {
+		if err := bus.Send(payload, ttlBenchTypeID); err != nil {
+			b.Fatalf("Send %d failed: %v", i, err)
+		}
+	}
+	elapsed := time.Since(start)
+	time.Sleep(ttlBenchWait)
+
+	start = time.Now()
+	for drained := 0; drained < ttlBenchMessages; {
+		msg, err := bus.ReceiveData()
+		if err != nil {
+			b.Fatalf("Receive failed: %v", err)
+		}
+		if msg == nil {
+			b.Fatalf("bus empty after %d of %d messages", drained, ttlBenchMessages)
+		}
+		drained++
+	}
+	if retained := bus.Retained(); len(retained) != 0 {
+		b.Fatalf("%d messages retained past their MaxAge", len(retained))
+	}
+	return elapsed + time.Since(start)
+}
+
+// BenchmarkRetentionTTL measures what retaining messages under a
+// RetentionPolicy MaxAge adds to sending and draining them, against the
+// same traffic with retention off
+//
+// The cost is copying each payload into the retention buffer and evicting
+// the copies once they expire, in later sends and in Retained. The drain
+// path itself does no expiry checking. The overhead is reported as the
+// overhead-% metric; set UMSBB_TTL_GATE to fail the benchmark when it
+// exceeds 5%, as the CI gate does.
+func BenchmarkRetentionTTL(b *testing.B) {
+	umsbbtest.RequireLibrary(b)
+
+	// Only the sends, drains and eviction are timed, not the expiry waits
+	b.StopTimer()
+	var plain, ttl time.Duration
+	for i := 0; i < b.N; i++ {
+		plain += sendDrainEvict(b)
+		ttl += sendDrainEvict(b, umsbb.WithRetentionPerType(map[uint32]umsbb.RetentionPolicy{
+			ttlBenchTypeID: {MaxAge: ttlBenchMaxAge},
+		}))
+	}
+
+	overhead := float64(ttl-plain) / float64(plain)
+	b.ReportMetric(float64(ttl.Nanoseconds())/float64(b.N), "ns/op")
+	b.ReportMetric(overhead*100, "overhead-%")
+	b.ReportMetric(float64(ttl.Nanoseconds())/float64(b.N*ttlBenchMessages), "ns/msg")
+	if os.Getenv("UMSBB_TTL_GATE") != "" && overhead > ttlOverheadLimit {
+		b.Fatalf("TTL retention overhead %.1f%% exceeds %.0f%%", overhead*100, ttlOverheadLimit*100)
+	}
+}
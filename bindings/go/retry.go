@@ -0,0 +1,113 @@
+// Retrying sends with per-type-ID retry budgets
+
+package umsbb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxRetryBackoff caps the delay between SendWithRetry attempts
+const maxRetryBackoff = 10 * time.Millisecond
+
+// ErrRetryBudgetExhausted is returned when a type ID has used all retries in its window
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudget limits the retries a type ID may use within a rolling window
+type RetryBudget struct {
+	TypeID     uint32
+	MaxRetries int
+	Window     time.Duration
+}
+
+// retryWindow counts the retries used since start
+type retryWindow struct {
+	start time.Time
+	used  int
+}
+
+// retryTracker enforces retry budgets per type ID
+type retryTracker struct {
+	mu      sync.Mutex
+	budgets map[uint32]RetryBudget
+	windows map[uint32]*retryWindow
+}
+
+// newRetryTracker returns a tracker for budgets, or nil if there are none
+func newRetryTracker(budgets []RetryBudget) *retryTracker {
+	if len(budgets) == 0 {
+		return nil
+	}
+
+	t := &retryTracker{
+		budgets: make(map[uint32]RetryBudget, len(budgets)),
+		windows: make(map[uint32]*retryWindow, len(budgets)),
+	}
+	for _, budget := range budgets {
+		t.budgets[budget.TypeID] = budget
+	}
+	return t
+}
+
+// acquire consumes one retry for typeID, failing once its budget is spent
+func (t *retryTracker) acquire(typeID uint32) error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	budget, ok := t.budgets[typeID]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	window := t.windows[typeID]
+	if window == nil || (budget.Window > 0 && now.Sub(window.start) >= budget.Window) {
+		window = &retryWindow{start: now}
+		t.windows[typeID] = window
+	}
+
+	if window.used >= budget.MaxRetries {
+		return fmt.Errorf("%w: type ID %d used %d retries in %s", ErrRetryBudgetExhausted, typeID, window.used, budget.Window)
+	}
+	window.used++
+	return nil
+}
+
+// SendWithRetry sends data, retrying with backoff while the bus refuses it
+//
+// Only ErrSubmitFailed is retried. Each retry is charged to the budget set for
+// typeID with WithRetryBudgets; once it is spent ErrRetryBudgetExhausted is
+// returned, so one persistently failing type ID cannot monopolize retries.
+//
+// Example:
+//
+//	if err := bus.SendWithRetry(ctx, payload, 7); errors.Is(err, umsbb.ErrRetryBudgetExhausted) {
+//	    log.Printf("dropping type 7 message: %v", err)
+//	}
+func (b *DirectUniversalBus) SendWithRetry(ctx context.Context, data []byte, typeID uint32) error {
+	backoff := receivePollInterval
+	for {
+		err := b.Send(data, typeID)
+		if !errors.Is(err, ErrSubmitFailed) {
+			return err
+		}
+
+		if err := b.retries.acquire(typeID); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
@@ -0,0 +1,83 @@
+// Load spreading across several bus instances
+
+package umsbb
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+var _ Bus = (*RoundRobinBus)(nil)
+
+// RoundRobinBus spreads sends across several buses and receives from all of them
+//
+// Use it when a single C bus instance is the throughput bottleneck. Messages
+// sent to different buses have no ordering relative to each other.
+type RoundRobinBus struct {
+	buses  []*DirectUniversalBus
+	nextTx atomic.Uint64
+	nextRx atomic.Uint64
+}
+
+// NewRoundRobinBus rotates over buses; it takes ownership of them, so Close closes them all
+//
+// Example:
+//
+//	a, _ := umsbb.NewDirectUniversalBus(1024*1024, 0, false, false)
+//	b, _ := umsbb.NewDirectUniversalBus(1024*1024, 0, false, false)
+//	rr, err := umsbb.NewRoundRobinBus(a, b)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer rr.Close()
+func NewRoundRobinBus(buses ...*DirectUniversalBus) (*RoundRobinBus, error) {
+	if len(buses) == 0 {
+		return nil, errors.New("round robin needs at least one bus")
+	}
+	for _, bus := range buses {
+		if bus == nil {
+			return nil, errors.New("round robin bus cannot be nil")
+		}
+	}
+	return &RoundRobinBus{buses: append([]*DirectUniversalBus(nil), buses...)}, nil
+}
+
+// Send sends to the next bus in rotation
+func (r *RoundRobinBus) Send(data []byte, typeID uint32) error {
+	i := (r.nextTx.Add(1) - 1) % uint64(len(r.buses))
+	return r.buses[i].Send(data, typeID)
+}
+
+// Receive returns the next payload from any bus
+func (r *RoundRobinBus) Receive() ([]byte, error) {
+	return payloadOf(r.ReceiveData())
+}
+
+// ReceiveData polls every bus once, starting after the bus polled first last
+// time, and returns the first message found or nil if all are empty
+func (r *RoundRobinBus) ReceiveData() (*UniversalData, error) {
+	start := r.nextRx.Add(1) - 1
+	n := uint64(len(r.buses))
+
+	var errs []error
+	for k := uint64(0); k < n; k++ {
+		msg, err := r.buses[(start+k)%n].ReceiveData()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if msg != nil {
+			return msg, nil
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// Close closes every bus
+func (r *RoundRobinBus) Close() error {
+	var errs []error
+	for _, bus := range r.buses {
+		errs = append(errs, bus.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,192 @@
+// Routing audit journal middleware
+
+package umsbb
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultJournalFlushInterval is how often journal records are flushed by default
+const DefaultJournalFlushInterval = time.Second
+
+// RoutingRecord is one JSON-lines entry written by the routing journal
+type RoutingRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	TypeID    uint32    `json:"typeID"`
+	Segment   int       `json:"segment"`
+	WorkerID  uint32    `json:"workerID"`
+	LatencyNs int64     `json:"latency_ns"`
+	OK        bool      `json:"ok"`
+}
+
+// JournalOption configures a routing journal
+type JournalOption func(*RoutingJournalBus)
+
+// WithJournalFlushInterval sets how often buffered records are flushed to the writer
+func WithJournalFlushInterval(interval time.Duration) JournalOption {
+	return func(j *RoutingJournalBus) {
+		j.flushInterval = interval
+	}
+}
+
+// segmentRouter is implemented by buses that can report where a type ID is routed
+type segmentRouter interface {
+	segmentOf(typeID uint32) int
+}
+
+// segmentOf returns the segment Send puts a message of typeID in, following
+// segment affinity and then the C library's routing, or -1 if the segment
+// count is unknown
+func (b *DirectUniversalBus) segmentOf(typeID uint32) int {
+	if b.segments == 0 {
+		return -1
+	}
+	return int(b.routedSegment(typeID, b.affinity.lookup(typeID)))
+}
+
+var _ Bus = (*RoutingJournalBus)(nil)
+
+// RoutingJournalBus writes an audit record for every send routed through it
+type RoutingJournalBus struct {
+	bus           Bus
+	flushInterval time.Duration
+
+	mu       sync.Mutex
+	w        *bufio.Writer
+	flushErr error
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// RoutingJournal wraps bus and writes one JSON line per routing decision to w
+//
+// Records are buffered and flushed every flush interval. A failed flush is
+// logged and returned by the next Send.
+//
+// Example:
+//
+//	f, _ := os.Create("routing.jsonl")
+//	journal := umsbb.RoutingJournal(bus, f, umsbb.WithJournalFlushInterval(500*time.Millisecond))
+//	defer journal.Close()
+func RoutingJournal(bus Bus, w io.Writer, opts ...JournalOption) *RoutingJournalBus {
+	j := &RoutingJournalBus{
+		bus:           bus,
+		flushInterval: DefaultJournalFlushInterval,
+		w:             bufio.NewWriter(w),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	if j.flushInterval <= 0 {
+		j.flushInterval = DefaultJournalFlushInterval
+	}
+
+	go j.flushLoop()
+	return j
+}
+
+// flushLoop periodically flushes buffered records until Close
+func (j *RoutingJournalBus) flushLoop() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.stop:
+			return
+		case <-ticker.C:
+			j.mu.Lock()
+			j.flushLocked()
+			j.mu.Unlock()
+		}
+	}
+}
+
+// flushLocked flushes the writer and keeps the first error for the next Send
+func (j *RoutingJournalBus) flushLocked() error {
+	if err := j.w.Flush(); err != nil {
+		log.Printf("[Go Journal] flush failed: %v", err)
+		if j.flushErr == nil {
+			j.flushErr = err
+		}
+		return err
+	}
+	return nil
+}
+
+// Send forwards the payload as worker 0 and records the routing decision
+func (j *RoutingJournalBus) Send(data []byte, typeID uint32) error {
+	return j.SendFrom(0, data, typeID)
+}
+
+// SendFrom forwards the payload and records the routing decision for workerID
+func (j *RoutingJournalBus) SendFrom(workerID uint32, data []byte, typeID uint32) error {
+	j.mu.Lock()
+	flushErr := j.flushErr
+	j.flushErr = nil
+	j.mu.Unlock()
+	if flushErr != nil {
+		return fmt.Errorf("routing journal flush failed: %w", flushErr)
+	}
+
+	start := time.Now()
+	err := j.bus.Send(data, typeID)
+
+	segment := -1
+	if router, ok := j.bus.(segmentRouter); ok {
+		segment = router.segmentOf(typeID)
+	}
+
+	line, encErr := json.Marshal(RoutingRecord{
+		Timestamp: start,
+		TypeID:    typeID,
+		Segment:   segment,
+		WorkerID:  workerID,
+		LatencyNs: time.Since(start).Nanoseconds(),
+		OK:        err == nil,
+	})
+	if encErr == nil {
+		// bufio.Writer errors are sticky and surface on the next flush
+		j.mu.Lock()
+		_, _ = j.w.Write(append(line, '\n'))
+		j.mu.Unlock()
+	}
+	return err
+}
+
+// Receive returns the next payload from the wrapped bus
+func (j *RoutingJournalBus) Receive() ([]byte, error) {
+	return j.bus.Receive()
+}
+
+// ReceiveData returns the next message from the wrapped bus
+func (j *RoutingJournalBus) ReceiveData() (*UniversalData, error) {
+	return j.bus.ReceiveData()
+}
+
+// Close flushes outstanding records and closes the wrapped bus
+func (j *RoutingJournalBus) Close() error {
+	j.closeOnce.Do(func() { close(j.stop) })
+	<-j.done
+
+	j.mu.Lock()
+	flushErr := j.flushLocked()
+	j.mu.Unlock()
+
+	if err := j.bus.Close(); err != nil {
+		return err
+	}
+	return flushErr
+}
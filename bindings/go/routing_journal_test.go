@@ -0,0 +1,46 @@
+package umsbb_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+func TestRoutingJournalRecordsActualSegment(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t, umsbb.WithSegmentCount(4))
+	if err := bus.SetSegmentAffinity(10, 20, 3); err != nil {
+		t.Fatalf("SetSegmentAffinity failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	journal := umsbb.RoutingJournal(bus, &out)
+	for _, typeID := range []uint32{12, 5} {
+		if err := journal.Send([]byte("routed"), typeID); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	if err := journal.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	want := map[uint32]int{12: 3, 5: 5 % 4}
+	records := 0
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		records++
+		var rec umsbb.RoutingRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("bad record %q: %v", scanner.Text(), err)
+		}
+		if rec.Segment != want[rec.TypeID] {
+			t.Errorf("type %d journaled on segment %d, want %d", rec.TypeID, rec.Segment, want[rec.TypeID])
+		}
+	}
+	if records != len(want) {
+		t.Fatalf("journal has %d records, want %d", records, len(want))
+	}
+}
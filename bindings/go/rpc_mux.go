@@ -0,0 +1,121 @@
+// RPC dispatch on type IDs that encode a service and a method
+
+package umsbb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownService is returned when a type ID names an unregistered service
+var ErrUnknownService = errors.New("no service registered for type ID")
+
+// ErrUnknownMethod is returned when a service has no handler for a method
+var ErrUnknownMethod = errors.New("service has no handler for method")
+
+// TypeIDEncoder splits a type ID into a service ID in the high 16 bits and
+// a method ID in the low 16 bits
+type TypeIDEncoder struct {
+	ServiceID uint16
+	MethodID  uint16
+}
+
+// Encode returns the type ID for the service and method
+func (e TypeIDEncoder) Encode() uint32 {
+	return uint32(e.ServiceID)<<16 | uint32(e.MethodID)
+}
+
+// Decode splits typeID into its service and method IDs
+func Decode(typeID uint32) TypeIDEncoder {
+	return TypeIDEncoder{ServiceID: uint16(typeID >> 16), MethodID: uint16(typeID)}
+}
+
+// MethodHandler handles one RPC request payload and returns the reply payload
+type MethodHandler func(ctx context.Context, req []byte) ([]byte, error)
+
+// Mux dispatches messages to RPC handlers by the service and method encoded
+// in their type ID
+type Mux struct {
+	mu       sync.RWMutex
+	services map[uint16]map[uint16]MethodHandler
+}
+
+// NewMux creates a Mux with no services
+//
+// Example:
+//
+//	mux := umsbb.NewMux()
+//	mux.RegisterService(1, map[uint16]func(context.Context, []byte) ([]byte, error){
+//	    1: getUser,
+//	    2: updateUser,
+//	})
+//	go mux.Serve(ctx, requests, replies)
+//
+//	// Client side: call method 2 of service 1
+//	requests.Send(payload, umsbb.TypeIDEncoder{ServiceID: 1, MethodID: 2}.Encode())
+func NewMux() *Mux {
+	return &Mux{services: make(map[uint16]map[uint16]MethodHandler)}
+}
+
+// RegisterService sets the handlers of serviceID by method ID, replacing
+// any handlers registered for it before
+func (m *Mux) RegisterService(serviceID uint16, handlers map[uint16]func(context.Context, []byte) ([]byte, error)) {
+	methods := make(map[uint16]MethodHandler, len(handlers))
+	for methodID, handler := range handlers {
+		if handler != nil {
+			methods[methodID] = handler
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.services[serviceID] = methods
+}
+
+// Dispatch calls the handler for msg's type ID and returns its reply
+func (m *Mux) Dispatch(ctx context.Context, msg UniversalData) ([]byte, error) {
+	route := Decode(msg.TypeID)
+
+	m.mu.RLock()
+	methods, ok := m.services[route.ServiceID]
+	handler := methods[route.MethodID]
+	m.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: service %d", ErrUnknownService, route.ServiceID)
+	}
+	if handler == nil {
+		return nil, fmt.Errorf("%w: service %d method %d", ErrUnknownMethod, route.ServiceID, route.MethodID)
+	}
+	return handler(ctx, msg.Data)
+}
+
+// Serve dispatches every message received from requests until ctx is done,
+// sending each non-empty reply to replies with the request's type ID
+//
+// Requests are handled one at a time in arrival order. Replies carry no
+// correlation ID, so clients with several calls in flight on the same
+// method should include one in their payloads. Failed calls are logged and
+// get no reply.
+func (m *Mux) Serve(ctx context.Context, requests Bus, replies BusInterface) error {
+	for {
+		msg, err := receiveDataContext(ctx, requests)
+		if err != nil {
+			return err
+		}
+
+		reply, err := m.Dispatch(ctx, *msg)
+		if err != nil {
+			fmt.Printf("[Go Mux] type %d: %v\n", msg.TypeID, err)
+			continue
+		}
+		if len(reply) == 0 {
+			continue
+		}
+		if err := sendContext(ctx, replies, reply, msg.TypeID); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Probabilistic trace sampling, globally or per type ID
+
+package umsbb
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+)
+
+// typeIDKey carries the type ID of the message being traced
+type typeIDKey struct{}
+
+// sampledKey marks a context whose span was sampled
+type sampledKey struct{}
+
+// ContextWithTypeID returns ctx carrying typeID for tracers
+//
+// Send and the other send methods add it before starting their span, so a
+// Tracer can make per-type decisions.
+func ContextWithTypeID(ctx context.Context, typeID uint32) context.Context {
+	return context.WithValue(ctx, typeIDKey{}, typeID)
+}
+
+// TypeIDFromContext returns the type ID added by ContextWithTypeID
+func TypeIDFromContext(ctx context.Context) (uint32, bool) {
+	typeID, ok := ctx.Value(typeIDKey{}).(uint32)
+	return typeID, ok
+}
+
+var _ Tracer = (*SamplingTracer)(nil)
+
+// SamplingTracer passes a random fraction of spans to another Tracer
+//
+// Unsampled operations get a span that records nothing and inject no trace
+// context, so downstream consumers do not continue their trace either.
+type SamplingTracer struct {
+	next      Tracer
+	rate      atomic.Uint64 // math.Float64bits of the global rate
+	typeRates sync.Map      // uint32 -> float64
+}
+
+// NewSamplingTracer traces a rate fraction of operations, from 0 to 1, with next
+//
+// Example:
+//
+//	sampler := umsbb.NewSamplingTracer(0.01, otelTracer{tp.Tracer("umsbb")})
+//	sampler.SetTypeIDRate(alertTypeID, 1) // always trace alerts
+//	bus, err := umsbb.NewDirectUniversalBus(1024*1024, 4, false, false,
+//	    umsbb.WithTracer(sampler))
+func NewSamplingTracer(rate float64, next Tracer) *SamplingTracer {
+	if next == nil {
+		next = NoopTracer{}
+	}
+	s := &SamplingTracer{next: next}
+	s.SetRate(rate)
+	return s
+}
+
+// SetRate changes the global sampling rate, clamped to [0, 1]
+func (s *SamplingTracer) SetRate(rate float64) {
+	s.rate.Store(math.Float64bits(clampRate(rate)))
+}
+
+// SetTypeIDRate overrides the sampling rate for messages of typeID
+func (s *SamplingTracer) SetTypeIDRate(typeID uint32, rate float64) {
+	s.typeRates.Store(typeID, clampRate(rate))
+}
+
+// rateFor returns the sampling rate for the operation in ctx
+func (s *SamplingTracer) rateFor(ctx context.Context) float64 {
+	if typeID, ok := TypeIDFromContext(ctx); ok {
+		if rate, ok := s.typeRates.Load(typeID); ok {
+			return rate.(float64)
+		}
+	}
+	return math.Float64frombits(s.rate.Load())
+}
+
+// StartSpan starts a span with the wrapped tracer if the operation is sampled
+func (s *SamplingTracer) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	if rate := s.rateFor(ctx); rate < 1 && rand.Float64() >= rate {
+		return ctx, noopSpan{}
+	}
+	ctx, span := s.next.StartSpan(ctx, name)
+	return context.WithValue(ctx, sampledKey{}, true), span
+}
+
+// Inject propagates the trace context of sampled operations only
+func (s *SamplingTracer) Inject(ctx context.Context, carrier map[string]string) {
+	if sampled, _ := ctx.Value(sampledKey{}).(bool); sampled {
+		s.next.Inject(ctx, carrier)
+	}
+}
+
+// clampRate limits rate to [0, 1], treating NaN as 0
+func clampRate(rate float64) float64 {
+	if !(rate > 0) {
+		return 0
+	}
+	return min(rate, 1)
+}
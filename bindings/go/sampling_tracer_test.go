@@ -0,0 +1,52 @@
+package umsbb_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+)
+
+// countingTracer counts the spans it is asked to start
+type countingTracer struct {
+	umsbb.NoopTracer
+	started int
+}
+
+func (c *countingTracer) StartSpan(ctx context.Context, name string) (context.Context, umsbb.Span) {
+	c.started++
+	return c.NoopTracer.StartSpan(ctx, name)
+}
+
+func TestSamplingApproximatesRate(t *testing.T) {
+	const spans = 10000
+
+	for _, rate := range []float64{0.1, 0.5, 0.9} {
+		next := &countingTracer{}
+		sampler := umsbb.NewSamplingTracer(rate, next)
+		for i := 0; i < spans; i++ {
+			_, span := sampler.StartSpan(context.Background(), "umsbb.Send")
+			span.End()
+		}
+
+		got := float64(next.started) / spans
+		if math.Abs(got-rate) > 0.05 {
+			t.Errorf("rate %v: sampled %v of %d spans, want within ±5%%", rate, got, spans)
+		}
+	}
+}
+
+func TestSamplingTypeIDRateOverridesGlobal(t *testing.T) {
+	next := &countingTracer{}
+	sampler := umsbb.NewSamplingTracer(0, next)
+	sampler.SetTypeIDRate(7, 1)
+
+	for i := 0; i < 100; i++ {
+		_, _ = sampler.StartSpan(umsbb.ContextWithTypeID(context.Background(), 7), "umsbb.Send")
+		_, _ = sampler.StartSpan(umsbb.ContextWithTypeID(context.Background(), 8), "umsbb.Send")
+	}
+	if next.started != 100 {
+		t.Fatalf("started %d spans, want only the 100 of type 7", next.started)
+	}
+}
@@ -0,0 +1,63 @@
+// Polling watcher for auto-scaling recommendations
+
+package umsbb
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WatchScaling polls GetScalingStatus every interval and calls onChange when
+// the optimal producer or consumer count changes
+//
+// WatchScaling blocks until ctx is done, returning ctx.Err(), or until the
+// bus is closed. onChange runs on the watching goroutine, so a slow callback
+// delays the next poll.
+//
+// Example:
+//
+//	go bus.WatchScaling(ctx, time.Second, func(old, new umsbb.ScalingStatus) {
+//	    log.Printf("scaling: producers %d -> %d, consumers %d -> %d",
+//	        old.OptimalProducers, new.OptimalProducers,
+//	        old.OptimalConsumers, new.OptimalConsumers)
+//	})
+func (b *DirectUniversalBus) WatchScaling(ctx context.Context, interval time.Duration, onChange func(old, new ScalingStatus)) error {
+	if interval <= 0 {
+		return errors.New("scaling watch interval must be positive")
+	}
+	if onChange == nil {
+		return errors.New("scaling watch callback cannot be nil")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	previous := b.GetScalingStatus()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if b.closed() {
+			return errors.New("bus is closed")
+		}
+
+		current := b.GetScalingStatus()
+		if current.OptimalProducers != previous.OptimalProducers ||
+			current.OptimalConsumers != previous.OptimalConsumers {
+			onChange(previous, current)
+		}
+		previous = current
+	}
+}
+
+// closed reports whether Close has released the C handle
+func (b *DirectUniversalBus) closed() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.handle == nil
+}
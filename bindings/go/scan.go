@@ -0,0 +1,58 @@
+// Non-destructive iteration over queued messages
+
+package umsbb
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrScanUnsupported is returned by Scan when the C library cannot iterate
+// segments without draining them
+var ErrScanUnsupported = errors.New("C library does not support segment scans")
+
+// Scan calls visitor with a copy of every queued message of typeID, segment
+// by segment, without consuming them
+//
+// Returning false from visitor stops the scan. Messages sent or received
+// while Scan runs may or may not be visited; sends and receives wait while
+// each message is copied out. It is meant for debugging and compaction, not
+// as a way to read messages.
+//
+// Example:
+//
+//	n := 0
+//	err := bus.Scan(ctx, orderTypeID, func(msg umsbb.UniversalData) bool {
+//	    n++
+//	    return n < 100 // look at the first 100 queued orders
+//	})
+func (b *DirectUniversalBus) Scan(ctx context.Context, typeID uint32, visitor func(UniversalData) bool) error {
+	if !scanSupported() {
+		return ErrScanUnsupported
+	}
+
+	ordered := b.ordered != nil && b.ordered.stream(typeID) != nil
+	for segment := uint32(0); segment < b.segments; segment++ {
+		var cursor uint64
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			msg, err := b.scanOne(segment, &cursor, typeID)
+			if err != nil {
+				return err
+			}
+			if msg == nil {
+				break
+			}
+			if ordered && len(msg.Data) >= orderedSeqSize {
+				msg.Data = msg.Data[orderedSeqSize:]
+			}
+			if !visitor(*msg) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+// Protobuf schema validation of payloads by type ID
+
+package umsbb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrSchemaMismatch is returned when a payload does not decode as the
+// protobuf message registered for its type ID
+var ErrSchemaMismatch = errors.New("payload does not match registered schema")
+
+// ProtoWireType is a protobuf wire type
+type ProtoWireType uint8
+
+const (
+	ProtoVarint  ProtoWireType = 0
+	ProtoFixed64 ProtoWireType = 1
+	ProtoBytes   ProtoWireType = 2
+	ProtoFixed32 ProtoWireType = 5
+)
+
+// MessageDescriptor is the part of a protobuf message descriptor the
+// registry validates against
+//
+// A protoreflect.MessageDescriptor adapts with a few lines, mapping each
+// field's Kind to its wire type:
+//
+//	type descriptor struct{ protoreflect.MessageDescriptor }
+//
+//	func (d descriptor) FullName() string { return string(d.MessageDescriptor.FullName()) }
+//
+//	func (d descriptor) FieldWireType(n int32) (umsbb.ProtoWireType, bool) {
+//	    fd := d.Fields().ByNumber(protoreflect.FieldNumber(n))
+//	    if fd == nil {
+//	        return 0, false
+//	    }
+//	    return wireTypeOf(fd.Kind()), true
+//	}
+type MessageDescriptor interface {
+	FullName() string
+	// FieldWireType returns the wire type of field number n, or false if the
+	// message has no such field
+	FieldWireType(n int32) (ProtoWireType, bool)
+}
+
+// ProtoSchema is a MessageDescriptor listing field numbers and wire types
+type ProtoSchema struct {
+	Name   string
+	Fields map[int32]ProtoWireType
+}
+
+// FullName returns the message name
+func (s ProtoSchema) FullName() string {
+	return s.Name
+}
+
+// FieldWireType returns the wire type of field number n
+func (s ProtoSchema) FieldWireType(n int32) (ProtoWireType, bool) {
+	wt, ok := s.Fields[n]
+	return wt, ok
+}
+
+// SchemaRegistry maps type IDs to protobuf message descriptors
+type SchemaRegistry struct {
+	mu          sync.RWMutex
+	descriptors map[uint32]MessageDescriptor
+	onSend      atomic.Bool
+}
+
+// NewSchemaRegistry creates an empty schema registry
+//
+// Example:
+//
+//	schemas := umsbb.NewSchemaRegistry()
+//	schemas.RegisterProto(1, umsbb.ProtoSchema{
+//	    Name:   "sensors.Reading",
+//	    Fields: map[int32]umsbb.ProtoWireType{1: umsbb.ProtoBytes, 2: umsbb.ProtoFixed64},
+//	})
+//	schemas.ValidateOnSend(true)
+//	bus, err := umsbb.NewDirectUniversalBus(1024*1024, 4, false, false,
+//	    umsbb.WithSchemaRegistry(schemas))
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{descriptors: make(map[uint32]MessageDescriptor)}
+}
+
+// RegisterProto binds typeID to md, replacing any earlier descriptor
+func (r *SchemaRegistry) RegisterProto(typeID uint32, md MessageDescriptor) error {
+	if md == nil {
+		return errors.New("message descriptor cannot be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.descriptors[typeID] = md
+	return nil
+}
+
+// ValidateOnSend turns validation of every Send on buses using the registry on or off
+func (r *SchemaRegistry) ValidateOnSend(enabled bool) {
+	r.onSend.Store(enabled)
+}
+
+// Validate checks that data decodes as the message registered for typeID
+//
+// Every field must be known to the descriptor and use its wire type;
+// numeric fields may also be length-delimited, as packed repeated fields
+// are. Nested messages are checked for framing only. Type IDs without a
+// descriptor always pass.
+func (r *SchemaRegistry) Validate(typeID uint32, data []byte) error {
+	r.mu.RLock()
+	md, ok := r.descriptors[typeID]
+	r.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	if err := validateProto(md, data); err != nil {
+		return fmt.Errorf("%w: type ID %d as %s: %v", ErrSchemaMismatch, typeID, md.FullName(), err)
+	}
+	return nil
+}
+
+// validateSend applies Validate when validation on send is enabled
+func (r *SchemaRegistry) validateSend(typeID uint32, data []byte) error {
+	if r == nil || !r.onSend.Load() {
+		return nil
+	}
+	return r.Validate(typeID, data)
+}
+
+// validateProto walks the protobuf wire format of data against md
+func validateProto(md MessageDescriptor, data []byte) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("malformed field key")
+		}
+		data = data[n:]
+
+		number := int64(key >> 3)
+		wt := ProtoWireType(key & 7)
+		if number < 1 || number > 1<<29-1 {
+			return fmt.Errorf("invalid field number %d", number)
+		}
+
+		want, ok := md.FieldWireType(int32(number))
+		if !ok {
+			return fmt.Errorf("unknown field %d", number)
+		}
+		if wt != want && !(wt == ProtoBytes && want != ProtoBytes) {
+			return fmt.Errorf("field %d has wire type %d, want %d", number, wt, want)
+		}
+
+		switch wt {
+		case ProtoVarint:
+			if _, n = binary.Uvarint(data); n <= 0 {
+				return fmt.Errorf("field %d: malformed varint", number)
+			}
+		case ProtoFixed64:
+			n = 8
+		case ProtoFixed32:
+			n = 4
+		case ProtoBytes:
+			size, m := binary.Uvarint(data)
+			if m <= 0 || size > uint64(len(data)-m) {
+				return fmt.Errorf("field %d: malformed length", number)
+			}
+			n = m + int(size)
+		default:
+			return fmt.Errorf("field %d: unsupported wire type %d", number, wt)
+		}
+		if n > len(data) {
+			return fmt.Errorf("field %d: truncated", number)
+		}
+		data = data[n:]
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package umsbb_test
+
+import (
+	"errors"
+	"testing"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+// Field 1 = 1 encodes as a valid payload; a lone 0xff is malformed
+var (
+	validProto   = []byte{0x08, 0x01}
+	invalidProto = []byte{0xff}
+)
+
+// newSchemaBus returns a bus validating type ID 1 as a message with one
+// varint field, with deduplication for SendWithID
+func newSchemaBus(t *testing.T) *umsbb.DirectUniversalBus {
+	t.Helper()
+
+	registry := umsbb.NewSchemaRegistry()
+	schema := umsbb.ProtoSchema{Name: "test.Counter", Fields: map[int32]umsbb.ProtoWireType{1: umsbb.ProtoVarint}}
+	if err := registry.RegisterProto(1, schema); err != nil {
+		t.Fatalf("RegisterProto failed: %v", err)
+	}
+	registry.ValidateOnSend(true)
+	return umsbbtest.NewTestBus(t, umsbb.WithSchemaRegistry(registry), umsbb.WithDeduplication(64))
+}
+
+func TestSchemaValidatesCallerPayload(t *testing.T) {
+	bus := newSchemaBus(t)
+	checked := umsbb.ChecksumMiddleware(bus, umsbb.CRC32)
+
+	sends := map[string]func([]byte) error{
+		"Send": func(data []byte) error { return bus.Send(data, 1) },
+		"SendMessage": func(data []byte) error {
+			return bus.SendMessage(&umsbb.Message{Headers: map[string]string{"k": "v"}, Data: data, TypeID: 1})
+		},
+		"ChecksumBus": func(data []byte) error { return checked.Send(data, 1) },
+		"SendIfAbsent": func(data []byte) error {
+			_, err := bus.SendIfAbsent(data, 1, data)
+			return err
+		},
+		"SendSticky": func(data []byte) error { return bus.SendSticky(data, 1, 7) },
+		"SendWithID": func(data []byte) error { return bus.SendWithID(bus.NextMessageID(), data, 1) },
+	}
+	for name, send := range sends {
+		t.Run(name, func(t *testing.T) {
+			if err := send(validProto); err != nil {
+				t.Fatalf("valid payload rejected: %v", err)
+			}
+			if err := send(invalidProto); !errors.Is(err, umsbb.ErrSchemaMismatch) {
+				t.Fatalf("invalid payload = %v, want ErrSchemaMismatch", err)
+			}
+		})
+	}
+}
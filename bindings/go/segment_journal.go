@@ -0,0 +1,269 @@
+// Append-only per-segment journal of bus writes
+
+package umsbb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// journalHeaderSize is the uint32 record length, int64 write time in Unix
+// nanoseconds, uint32 segment ID and uint32 type ID
+const journalHeaderSize = 4 + 8 + 4 + 4
+
+// journalFilePattern names the journal file of each segment
+const journalFilePattern = "segment-%03d.journal"
+
+// segmentJournal appends every successful segment write to a file per segment
+type segmentJournal struct {
+	dir   string
+	mu    sync.Mutex
+	files map[uint32]*os.File
+}
+
+// openSegmentJournal creates dir if needed, or returns nil if dir is empty
+func openSegmentJournal(dir string) (*segmentJournal, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create segment journal: %w", err)
+	}
+	return &segmentJournal{dir: dir, files: make(map[uint32]*os.File)}, nil
+}
+
+// append records a write of data to segment
+func (j *segmentJournal) append(segment, typeID uint32, data []byte) error {
+	if j == nil {
+		return nil
+	}
+
+	record := make([]byte, journalHeaderSize, journalHeaderSize+len(data))
+	binary.LittleEndian.PutUint32(record[0:4], uint32(journalHeaderSize-4+len(data)))
+	binary.LittleEndian.PutUint64(record[4:12], uint64(time.Now().UnixNano()))
+	binary.LittleEndian.PutUint32(record[12:16], segment)
+	binary.LittleEndian.PutUint32(record[16:20], typeID)
+	record = append(record, data...)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.files == nil {
+		return errors.New("segment journal is closed")
+	}
+	file, ok := j.files[segment]
+	if !ok {
+		path := filepath.Join(j.dir, fmt.Sprintf(journalFilePattern, segment))
+		var err error
+		file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open segment journal: %w", err)
+		}
+		j.files[segment] = file
+	}
+	if _, err := file.Write(record); err != nil {
+		return fmt.Errorf("failed to write segment journal: %w", err)
+	}
+	return nil
+}
+
+// close closes every segment file
+func (j *segmentJournal) close() error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var errs []error
+	for _, file := range j.files {
+		errs = append(errs, file.Close())
+	}
+	j.files = nil
+	return errors.Join(errs...)
+}
+
+// journal records a successful write, logging rather than failing the send
+// since the message is already in the bus
+func (b *DirectUniversalBus) journal(data []byte, typeID uint32, segment int) {
+	if b.segJournal == nil {
+		return
+	}
+	if err := b.segJournal.append(b.routedSegment(typeID, segment), typeID, data); err != nil {
+		fmt.Printf("[Go Journal] %v\n", err)
+	}
+}
+
+// routedSegment returns segment, or the segment the C library routes a Go
+// message of typeID to when segment is negative
+func (b *DirectUniversalBus) routedSegment(typeID uint32, segment int) uint32 {
+	if segment >= 0 || b.segments == 0 {
+		return uint32(max(segment, 0))
+	}
+	if i := slices.Index(b.opts().languagePriority, LangGo); i >= 0 {
+		return uint32(i) % b.segments
+	}
+	return typeID % b.segments
+}
+
+// WithSegmentJournal appends every message written to a segment to a
+// per-segment file in dir
+//
+// Each record is a little-endian uint32 length of the rest of the record,
+// an int64 write time in Unix nanoseconds, a uint32 segment ID, a uint32
+// type ID and the payload. Read the journal back with Replay. The directory
+// is created if missing, and existing journals are appended to.
+func WithSegmentJournal(dir string) BusOption {
+	return func(o *busOptions) {
+		o.journalDir = dir
+	}
+}
+
+// journalReader reads records from one segment file
+type journalReader struct {
+	file *os.File
+	r    *bufio.Reader
+	next *journalRecord
+}
+
+// journalRecord is one decoded journal record
+type journalRecord struct {
+	at  int64
+	msg UniversalData
+}
+
+// read decodes the next record into jr.next, leaving it nil at the end
+//
+// A record truncated by a crash mid-write ends the file.
+func (jr *journalReader) read() error {
+	jr.next = nil
+
+	var header [journalHeaderSize]byte
+	if _, err := io.ReadFull(jr.r, header[:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		return err
+	}
+
+	length := binary.LittleEndian.Uint32(header[0:4])
+	if length < journalHeaderSize-4 {
+		return fmt.Errorf("corrupt segment journal record length %d", length)
+	}
+	data := make([]byte, length-(journalHeaderSize-4))
+	if _, err := io.ReadFull(jr.r, data); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		return err
+	}
+
+	jr.next = &journalRecord{
+		at: int64(binary.LittleEndian.Uint64(header[4:12])),
+		msg: UniversalData{
+			Data:       data,
+			TypeID:     binary.LittleEndian.Uint32(header[16:20]),
+			SourceLang: LangGo,
+		},
+	}
+	return nil
+}
+
+// Replay returns an iterator over the segment journals in dir, yielding the
+// messages written at or after from in write order across all segments
+//
+// The iterator returns io.EOF after the last message and closes the
+// journal files once it returns any error. Stop reading early when the
+// messages pass the end of the range of interest.
+//
+// Example:
+//
+//	next := umsbb.Replay("/var/lib/umsbb/journal", time.Now().Add(-time.Hour))
+//	for {
+//	    msg, err := next()
+//	    if err == io.EOF {
+//	        break
+//	    } else if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    fmt.Printf("type %d: %d bytes\n", msg.TypeID, len(msg.Data))
+//	}
+func Replay(dir string, from time.Time) func() (UniversalData, error) {
+	readers, err := openJournalReaders(dir, from.UnixNano())
+	done := err != nil
+	return func() (UniversalData, error) {
+		if done {
+			if err == nil {
+				err = io.EOF
+			}
+			return UniversalData{}, err
+		}
+
+		// Merge the segments by write time
+		var oldest *journalReader
+		for _, jr := range readers {
+			if jr.next != nil && (oldest == nil || jr.next.at < oldest.next.at) {
+				oldest = jr
+			}
+		}
+		if oldest == nil {
+			done = true
+			closeJournalReaders(readers)
+			return UniversalData{}, io.EOF
+		}
+
+		msg := oldest.next.msg
+		if err = oldest.read(); err != nil {
+			done = true
+			closeJournalReaders(readers)
+		}
+		return msg, nil
+	}
+}
+
+// openJournalReaders opens every segment journal in dir, positioned at the
+// first record written at or after from
+func openJournalReaders(dir string, from int64) ([]*journalReader, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "segment-*.journal"))
+	if err != nil {
+		return nil, err
+	}
+
+	readers := make([]*journalReader, 0, len(paths))
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			closeJournalReaders(readers)
+			return nil, fmt.Errorf("failed to open segment journal: %w", err)
+		}
+
+		jr := &journalReader{file: file, r: bufio.NewReader(file)}
+		readers = append(readers, jr)
+		for {
+			if err := jr.read(); err != nil {
+				closeJournalReaders(readers)
+				return nil, fmt.Errorf("failed to read segment journal %s: %w", path, err)
+			}
+			if jr.next == nil || jr.next.at >= from {
+				break
+			}
+		}
+	}
+	return readers, nil
+}
+
+// closeJournalReaders closes the files of readers
+func closeJournalReaders(readers []*journalReader) {
+	for _, jr := range readers {
+		jr.file.Close()
+	}
+}
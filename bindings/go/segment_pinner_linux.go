@@ -0,0 +1,125 @@
+//go:build linux
+
+// CPU pinning of per-segment workers for NUMA-aware placement
+
+package umsbb
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// maxPinnableCPU bounds the affinity mask passed to sched_setaffinity
+const maxPinnableCPU = 1024
+
+// SegmentPinner runs the workers for each segment on a fixed CPU
+//
+// Keeping a segment's producer on one core, ideally on the NUMA node that
+// holds the segment's memory, avoids cross-node traffic on every send.
+type SegmentPinner struct {
+	bus *DirectUniversalBus
+
+	mu   sync.Mutex
+	cpus map[uint32]int
+	wg   sync.WaitGroup
+}
+
+// NewSegmentPinner creates a pinner for bus with no segments pinned
+func NewSegmentPinner(bus *DirectUniversalBus) *SegmentPinner {
+	return &SegmentPinner{bus: bus, cpus: make(map[uint32]int)}
+}
+
+// PinSegmentToCPU makes workers started for segmentID run on cpuID
+//
+// Workers already running keep their previous CPU.
+func (p *SegmentPinner) PinSegmentToCPU(segmentID uint32, cpuID int) error {
+	segments, err := p.bus.activeSegmentCount()
+	if err != nil {
+		return err
+	}
+	if segmentID >= segments {
+		return fmt.Errorf("segment %d out of range, bus has %d", segmentID, segments)
+	}
+	if cpuID < 0 || cpuID >= maxPinnableCPU {
+		return fmt.Errorf("cpu %d out of range 0..%d", cpuID, maxPinnableCPU-1)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.cpus[segmentID] = cpuID
+	return nil
+}
+
+// Go starts a worker for segmentID on its pinned CPU
+//
+// The worker runs on a locked OS thread whose affinity is set with
+// sched_setaffinity(2), and every message it passes to send goes to
+// segmentID. An unpinned segment's worker runs unpinned. The thread exits
+// when fn returns, so the affinity never leaks to other goroutines.
+//
+// Example:
+//
+//	pinner := umsbb.NewSegmentPinner(bus)
+//	_ = pinner.PinSegmentToCPU(0, 2)
+//	_ = pinner.Go(0, func(send func([]byte, uint32) error) {
+//	    for reading := range readings {
+//	        _ = send(reading, 1)
+//	    }
+//	})
+//	pinner.Wait()
+func (p *SegmentPinner) Go(segmentID uint32, fn func(send func(data []byte, typeID uint32) error)) error {
+	segments, err := p.bus.activeSegmentCount()
+	if err != nil {
+		return err
+	}
+	if segmentID >= segments {
+		return fmt.Errorf("segment %d out of range, bus has %d", segmentID, segments)
+	}
+
+	p.mu.Lock()
+	cpuID, pinned := p.cpus[segmentID]
+	p.mu.Unlock()
+
+	started := make(chan error, 1)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		// Never unlocked: the thread is discarded with its affinity when fn returns
+		runtime.LockOSThread()
+		if pinned {
+			if err := setThreadAffinity(cpuID); err != nil {
+				started <- err
+				return
+			}
+		}
+		started <- nil
+
+		fn(func(data []byte, typeID uint32) error {
+			return p.bus.send(data, typeID, int(segmentID))
+		})
+	}()
+	return <-started
+}
+
+// Wait blocks until every worker started with Go has returned
+func (p *SegmentPinner) Wait() {
+	p.wg.Wait()
+}
+
+// setThreadAffinity restricts the calling OS thread to cpuID
+func setThreadAffinity(cpuID int) error {
+	var mask [maxPinnableCPU / 64]uint64
+	mask[cpuID/64] = 1 << (cpuID % 64)
+
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, 0,
+		uintptr(unsafe.Sizeof(mask)), uintptr(unsafe.Pointer(&mask)))
+	if errno != 0 {
+		return &BusOSError{Errno: int(errno), Syscall: "sched_setaffinity", Op: "pin"}
+	}
+	return nil
+}
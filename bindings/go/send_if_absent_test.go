@@ -0,0 +1,50 @@
+package umsbb_test
+
+import (
+	"testing"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+func TestSendIfAbsentUsesSendPipeline(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t,
+		umsbb.WithRetention(8),
+		umsbb.WithOrderedDelivery(4),
+		umsbb.WithContentDeduplication(1024, 0))
+	bus.EnableEventLog(8)
+
+	if sent, err := bus.SendIfAbsent([]byte("order-1"), 4, []byte("k1")); err != nil || !sent {
+		t.Fatalf("SendIfAbsent = %v, %v; want sent", sent, err)
+	}
+	if sent, err := bus.SendIfAbsent([]byte("order-1 again"), 4, []byte("k1")); err != nil || sent {
+		t.Fatalf("SendIfAbsent with a pending key = %v, %v; want not sent", sent, err)
+	}
+
+	if n := len(bus.Retained()); n != 1 {
+		t.Fatalf("retained %d messages, want 1", n)
+	}
+	if n := bus.TypeIDFrequency()[4]; n != 1 {
+		t.Fatalf("TypeIDFrequency[4] = %d, want 1", n)
+	}
+	sends := 0
+	for _, ev := range bus.EventLog() {
+		if ev.Op == umsbb.EventSend && ev.Error == "" {
+			sends++
+		}
+	}
+	if sends < 1 {
+		t.Fatalf("event log %+v has no successful send", bus.EventLog())
+	}
+
+	// Ordered delivery tagged the message, so receiving strips the tag
+	msg, err := bus.ReceiveData()
+	if err != nil || msg == nil || string(msg.Data) != "order-1" {
+		t.Fatalf("ReceiveData = %v, %v; want order-1", msg, err)
+	}
+
+	// The key is free again, but content deduplication still knows the payload
+	if sent, err := bus.SendIfAbsent([]byte("order-1"), 4, []byte("k1")); err != nil || sent {
+		t.Fatalf("SendIfAbsent of a duplicate payload = %v, %v; want not sent", sent, err)
+	}
+}
@@ -0,0 +1,130 @@
+// Graceful close and ordered shutdown of several buses
+
+package umsbb
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrBusClosing is returned by sends on a bus that CloseGraceful is draining
+var ErrBusClosing = errors.New("bus is closing")
+
+// CloseGraceful stops accepting sends, waits for consumers to drain the bus,
+// then closes it
+//
+// Sends made after CloseGraceful is called return ErrBusClosing, so the
+// bus drains to empty even while producers are still running. If ctx is done first the bus is closed anyway, discarding what is left,
+// and ctx.Err() is returned along with any close error.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	if err := bus.CloseGraceful(ctx); err != nil {
+//	    log.Printf("bus closed with messages pending: %v", err)
+//	}
+func (b *DirectUniversalBus) CloseGraceful(ctx context.Context) error {
+	// Taking mu waits out submits already in progress
+	b.mu.Lock()
+	b.closing = true
+	b.mu.Unlock()
+
+	waitErr := pollContext(ctx, func() (bool, error) {
+		n, err := b.Size()
+		return n == 0, err
+	})
+	return errors.Join(waitErr, b.Close())
+}
+
+// coordinatedBus is one bus registered with a Coordinator
+type coordinatedBus struct {
+	bus   *DirectUniversalBus
+	order int
+}
+
+// Coordinator shuts down several buses in dependency order
+//
+// Register upstream buses with a higher order than the buses they feed, so
+// producers stop before the buses their consumers read from.
+type Coordinator struct {
+	mu    sync.Mutex
+	buses []coordinatedBus
+}
+
+// NewCoordinator creates a coordinator with no buses
+func NewCoordinator() *Coordinator {
+	return &Coordinator{}
+}
+
+// RegisterWithCoordinator adds the bus to c; higher orders shut down first
+//
+// Example:
+//
+//	coord := umsbb.NewCoordinator()
+//	ingest.RegisterWithCoordinator(coord, 2)
+//	storage.RegisterWithCoordinator(coord, 1)
+//	<-sigterm
+//	err := coord.Shutdown(ctx)
+func (b *DirectUniversalBus) RegisterWithCoordinator(c *Coordinator, order int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.buses = append(c.buses, coordinatedBus{bus: b, order: order})
+}
+
+// Shutdown closes every registered bus with CloseGraceful, highest order first
+//
+// Buses with the same order close concurrently. If ctx has a deadline, each
+// order gets an equal share of the time left when it starts, so time saved
+// by a quick group passes to the next. Every bus is closed even after an
+// error; the errors are returned joined.
+func (c *Coordinator) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	buses := c.buses
+	c.buses = nil
+	c.mu.Unlock()
+
+	slices.SortStableFunc(buses, func(a, b coordinatedBus) int {
+		return cmp.Compare(b.order, a.order)
+	})
+
+	var groups [][]coordinatedBus
+	for i, entry := range buses {
+		if i == 0 || entry.order != buses[i-1].order {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], entry)
+	}
+
+	var errs []error
+	for i, group := range groups {
+		groupCtx, cancel := ctx, context.CancelFunc(func() {})
+		if deadline, ok := ctx.Deadline(); ok {
+			share := time.Until(deadline) / time.Duration(len(groups)-i)
+			groupCtx, cancel = context.WithTimeout(ctx, share)
+		}
+
+		groupErrs := make([]error, len(group))
+		var wg sync.WaitGroup
+		for j, entry := range group {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := entry.bus.CloseGraceful(groupCtx); err != nil {
+					groupErrs[j] = fmt.Errorf("bus with order %d: %w", entry.order, err)
+				}
+			}()
+		}
+		wg.Wait()
+		cancel()
+
+		errs = append(errs, groupErrs...)
+	}
+	return errors.Join(errs...)
+}
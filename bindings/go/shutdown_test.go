@@ -0,0 +1,68 @@
+package umsbb_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+func TestCloseGracefulRejectsSendsWhileDraining(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t)
+	if err := bus.Send([]byte("queued"), 1); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	// A producer that never stops would keep the bus from draining unless
+	// its sends are refused
+	var rejected atomic.Bool
+	stop := make(chan struct{})
+	producerDone := make(chan struct{})
+	go func() {
+		defer close(producerDone)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := bus.Send([]byte("late"), 1); errors.Is(err, umsbb.ErrBusClosing) {
+				rejected.Store(true)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	defer func() {
+		close(stop)
+		<-producerDone
+	}()
+
+	consumerDone := make(chan struct{})
+	go func() {
+		defer close(consumerDone)
+		for !rejected.Load() {
+			_, _ = bus.Receive()
+			time.Sleep(time.Millisecond)
+		}
+		for {
+			if n, err := bus.Size(); err != nil || n == 0 {
+				return
+			}
+			_, _ = bus.Receive()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := bus.CloseGraceful(ctx); err != nil {
+		t.Fatalf("CloseGraceful = %v, want nil", err)
+	}
+	<-consumerDone
+	if !rejected.Load() {
+		t.Fatal("no send was rejected with ErrBusClosing")
+	}
+}
@@ -0,0 +1,74 @@
+// Payload size distribution for tuning buffer sizes
+
+package umsbb
+
+import "sync/atomic"
+
+// sizeBuckets are the inclusive upper bounds of the histogram buckets
+var sizeBuckets = [...]struct {
+	limit int
+	label string
+}{
+	{64, "64B"},
+	{256, "256B"},
+	{1 << 10, "1KB"},
+	{4 << 10, "4KB"},
+	{16 << 10, "16KB"},
+	{64 << 10, "64KB"},
+	{256 << 10, "256KB"},
+	{1 << 20, "1MB"},
+}
+
+// sizeOverflowLabel is the bucket for payloads larger than 1MB
+const sizeOverflowLabel = ">1MB"
+
+// MessageSizeHistogram counts payloads by size bucket
+//
+// Buckets are inclusive upper bounds: a 64-byte payload counts under "64B"
+// and a 65-byte one under "256B". Recording is lock-free.
+type MessageSizeHistogram struct {
+	counts [len(sizeBuckets) + 1]atomic.Uint64
+}
+
+// NewMessageSizeHistogram creates an empty histogram
+//
+// Example:
+//
+//	sizes := umsbb.NewMessageSizeHistogram()
+//	bus, err := umsbb.NewDirectUniversalBus(1024*1024, 4, false, false,
+//	    umsbb.WithSizeHistogram(sizes))
+//	// ...
+//	fmt.Println(sizes.Histogram())
+func NewMessageSizeHistogram() *MessageSizeHistogram {
+	return &MessageSizeHistogram{}
+}
+
+// Record counts one payload of size bytes
+func (h *MessageSizeHistogram) Record(size int) {
+	for i, bucket := range sizeBuckets {
+		if size <= bucket.limit {
+			h.counts[i].Add(1)
+			return
+		}
+	}
+	h.counts[len(sizeBuckets)].Add(1)
+}
+
+// Histogram returns the count in every bucket, keyed by bucket label
+//
+// Every label is present, including empty buckets.
+func (h *MessageSizeHistogram) Histogram() map[string]uint64 {
+	hist := make(map[string]uint64, len(h.counts))
+	for i, bucket := range sizeBuckets {
+		hist[bucket.label] = h.counts[i].Load()
+	}
+	hist[sizeOverflowLabel] = h.counts[len(sizeBuckets)].Load()
+	return hist
+}
+
+// WithSizeHistogram records the payload size of every successful send in h
+func WithSizeHistogram(h *MessageSizeHistogram) BusOption {
+	return func(o *busOptions) {
+		o.sizes = h
+	}
+}
@@ -0,0 +1,125 @@
+// Routing of small and large payloads to separate buses
+
+package umsbb
+
+import (
+	"errors"
+	"sync"
+)
+
+// sizeBasedSmallWeight is how many small messages are received for each large one
+// while both buses have messages waiting
+const sizeBasedSmallWeight = 4
+
+var _ Bus = (*SizeBasedRouter)(nil)
+
+// SizeBasedRouter sends payloads up to a threshold to one bus and larger
+// payloads to another
+//
+// Control messages then never queue behind bulk transfers, and each bus can
+// be sized for its traffic. Small and large messages have no ordering
+// relative to each other.
+type SizeBasedRouter struct {
+	threshold int
+	small     *DirectUniversalBus
+	large     *DirectUniversalBus
+	receiver  *SizeBasedReceiver
+}
+
+// NewSizeBasedRouter routes payloads of at most smallThreshold bytes to
+// smallBus and the rest to largeBus; Close closes both
+//
+// Example:
+//
+//	control, _ := umsbb.NewDirectUniversalBus(64*1024, 1, false, false)
+//	bulk, _ := umsbb.NewDirectUniversalBus(16*1024*1024, 4, false, false)
+//	router := umsbb.NewSizeBasedRouter(1024, control, bulk)
+//	defer router.Close()
+func NewSizeBasedRouter(smallThreshold int, smallBus, largeBus *DirectUniversalBus) *SizeBasedRouter {
+	return &SizeBasedRouter{
+		threshold: smallThreshold,
+		small:     smallBus,
+		large:     largeBus,
+		receiver:  SizeBasedReceive(smallBus, largeBus),
+	}
+}
+
+// Send sends data to the small or large bus by its size
+func (r *SizeBasedRouter) Send(data []byte, typeID uint32) error {
+	if len(data) <= r.threshold {
+		return r.small.Send(data, typeID)
+	}
+	return r.large.Send(data, typeID)
+}
+
+// Receive returns the next payload, favouring small messages
+func (r *SizeBasedRouter) Receive() ([]byte, error) {
+	return r.receiver.Receive()
+}
+
+// ReceiveData returns the next message, favouring small messages
+func (r *SizeBasedRouter) ReceiveData() (*UniversalData, error) {
+	return r.receiver.ReceiveData()
+}
+
+// Close closes both buses
+func (r *SizeBasedRouter) Close() error {
+	return errors.Join(r.small.Close(), r.large.Close())
+}
+
+// SizeBasedReceiver merges the drains of a small-message and a large-message bus
+type SizeBasedReceiver struct {
+	small *DirectUniversalBus
+	large *DirectUniversalBus
+
+	mu     sync.Mutex
+	streak int // small messages received since the last large one
+}
+
+// SizeBasedReceive merges drains of small and large with weighted round-robin
+//
+// While both buses have messages, four small messages are received for each
+// large one, so bulk traffic cannot starve control traffic and vice versa.
+// When one bus is empty the other is drained freely.
+//
+// Example:
+//
+//	receiver := umsbb.SizeBasedReceive(control, bulk)
+//	msg, err := receiver.ReceiveData()
+func SizeBasedReceive(small, large *DirectUniversalBus) *SizeBasedReceiver {
+	return &SizeBasedReceiver{small: small, large: large}
+}
+
+// Receive returns the next payload, or nil if both buses are empty
+func (s *SizeBasedReceiver) Receive() ([]byte, error) {
+	return payloadOf(s.ReceiveData())
+}
+
+// ReceiveData returns the next message, or nil if both buses are empty
+func (s *SizeBasedReceiver) ReceiveData() (*UniversalData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	first, second := s.small, s.large
+	if s.streak >= sizeBasedSmallWeight {
+		first, second = s.large, s.small
+	}
+
+	var errs []error
+	for _, bus := range []*DirectUniversalBus{first, second} {
+		msg, err := bus.ReceiveData()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if msg != nil {
+			if bus == s.small {
+				s.streak++
+			} else {
+				s.streak = 0
+			}
+			return msg, nil
+		}
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,187 @@
+// Drainer that persists received messages through database/sql
+
+package umsbb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// sqlIdentifier matches table names that are safe to splice into a statement
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// DrainReport summarizes the work done by a SQL drainer
+type DrainReport struct {
+	RowsInserted int
+	BatchCount   int
+	Errors       []error
+}
+
+// SQLDrainer drains a bus and inserts every message as a row of Table
+type SQLDrainer struct {
+	Bus   Bus
+	DB    *sql.DB
+	Table string
+	// Encode converts a message into the column values of one row
+	Encode func(UniversalData) ([]any, error)
+	// BatchSize is the number of rows inserted per transaction (default: 100)
+	BatchSize int
+	// FlushInterval bounds how long a partial batch waits (default: 1s)
+	FlushInterval time.Duration
+	// Placeholder returns the bind parameter for column n, starting at 1
+	// (default: "?"; use "$n" style for PostgreSQL)
+	Placeholder func(n int) string
+}
+
+// DrainToSQL drains bus into table until ctx is cancelled
+//
+// Example:
+//
+//	report, err := umsbb.DrainToSQL(ctx, bus, db, "readings", func(msg umsbb.UniversalData) ([]any, error) {
+//	    return []any{msg.TypeID, msg.Data}, nil
+//	})
+func DrainToSQL(ctx context.Context, bus Bus, db *sql.DB, table string, encode func(UniversalData) ([]any, error)) (DrainReport, error) {
+	drainer := &SQLDrainer{
+		Bus:    bus,
+		DB:     db,
+		Table:  table,
+		Encode: encode,
+	}
+	return drainer.Run(ctx)
+}
+
+// Run drains the bus until ctx is cancelled, then flushes the pending batch
+//
+// Insert and encode failures are recorded in the report and draining
+// continues; a bus receive error stops the drainer and is returned.
+func (d *SQLDrainer) Run(ctx context.Context) (DrainReport, error) {
+	var report DrainReport
+
+	if !sqlIdentifier.MatchString(d.Table) {
+		return report, fmt.Errorf("invalid table name %q", d.Table)
+	}
+	if d.Bus == nil || d.DB == nil || d.Encode == nil {
+		return report, errors.New("bus, database and encode function are required")
+	}
+
+	batchSize := d.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	flushInterval := d.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([][]any, 0, batchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := d.insert(ctx, batch); err != nil {
+			report.Errors = append(report.Errors, err)
+		} else {
+			report.RowsInserted += len(batch)
+			report.BatchCount++
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		if ctx.Err() != nil {
+			flush(context.WithoutCancel(ctx))
+			return report, nil
+		}
+
+		msg, err := d.Bus.ReceiveData()
+		if err != nil {
+			flush(context.WithoutCancel(ctx))
+			return report, err
+		}
+
+		if msg != nil {
+			row, err := d.Encode(*msg)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Errorf("failed to encode type ID %d: %w", msg.TypeID, err))
+				continue
+			}
+			batch = append(batch, row)
+			if len(batch) >= batchSize {
+				flush(ctx)
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			flush(context.WithoutCancel(ctx))
+			return report, nil
+		case <-ticker.C:
+			flush(ctx)
+		case <-time.After(receivePollInterval):
+		}
+	}
+}
+
+// insert writes rows in a single transaction
+func (d *SQLDrainer) insert(ctx context.Context, rows [][]any) error {
+	query := insertQuery(d.Table, nil, len(rows[0]), d.Placeholder)
+	return insertBatch(ctx, d.DB, query, rows)
+}
+
+// insertQuery builds an INSERT statement for table with one bind parameter per
+// column; columns may be nil to insert positionally
+func insertQuery(table string, columns []string, count int, placeholder func(n int) string) string {
+	placeholders := make([]string, count)
+	for i := range placeholders {
+		if placeholder != nil {
+			placeholders[i] = placeholder(i + 1)
+		} else {
+			placeholders[i] = "?"
+		}
+	}
+
+	if len(columns) == 0 {
+		return fmt.Sprintf("INSERT INTO %s VALUES (%s)", table, strings.Join(placeholders, ", "))
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+}
+
+// insertBatch executes query once per row inside a single transaction
+func insertBatch(ctx context.Context, db *sql.DB, query string, rows [][]any) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin insert batch: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, row := range rows {
+		if len(row) != len(rows[0]) {
+			_ = tx.Rollback()
+			return fmt.Errorf("row %d has %d columns, want %d", i, len(row), len(rows[0]))
+		}
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("failed to insert row %d: %w", i, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit insert batch: %w", err)
+	}
+	return nil
+}
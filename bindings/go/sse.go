@@ -0,0 +1,169 @@
+// Server-Sent Events feed of bus messages
+
+package umsbb
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// sseClientBuffer is the number of events queued for a slow client before
+// further events are dropped for it
+const sseClientBuffer = 64
+
+// sseHandler relays messages drained from a bus to Server-Sent Events clients
+type sseHandler struct {
+	bus     *DirectUniversalBus
+	typeIDs []uint32
+
+	mu      sync.Mutex
+	clients map[chan []byte]struct{}
+	relayOn bool
+
+	busClosed     chan struct{} // closed once the relay finds the bus closed
+	busClosedOnce sync.Once
+}
+
+// SSEHandler serves bus messages as a text/event-stream
+//
+// Each message becomes one event named after its type ID with the payload
+// base64-encoded as data:
+//
+//	event: 1
+//	data: aGVsbG8=
+//
+// Every connected client receives every message. The bus is drained only
+// while at least one client is connected; with typeIDs given, drained
+// messages of other type IDs are discarded, so give the handler a bus of
+// its own. A client that falls 64 events behind misses events until it
+// catches up. The request goroutine returns as soon as the client
+// disconnects, and every stream ends once the bus is closed.
+//
+// Example:
+//
+//	http.Handle("/events", umsbb.SSEHandler(bus, 1, 2))
+//
+//	// In the browser:
+//	// new EventSource("/events").addEventListener("1", e => console.log(atob(e.data)));
+func SSEHandler(bus *DirectUniversalBus, typeIDs ...uint32) http.Handler {
+	return &sseHandler{
+		bus:       bus,
+		typeIDs:   append([]uint32(nil), typeIDs...),
+		clients:   make(map[chan []byte]struct{}),
+		busClosed: make(chan struct{}),
+	}
+}
+
+// ServeHTTP streams events to one client until it disconnects
+func (h *sseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events := make(chan []byte, sseClientBuffer)
+	h.join(events)
+	defer h.leave(events)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.busClosed:
+			return
+		case event := <-events:
+			if _, err := w.Write(event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// join registers a client and starts the relay if it is not running, or
+// ends every stream if the bus is already closed
+func (h *sseHandler) join(events chan []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.clients[events] = struct{}{}
+	switch {
+	case h.bus.closed():
+		h.busClosedOnce.Do(func() { close(h.busClosed) })
+	case !h.relayOn:
+		h.relayOn = true
+		go h.relay()
+	}
+}
+
+// leave unregisters a client
+func (h *sseHandler) leave(events chan []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients, events)
+}
+
+// relay drains the bus and queues each wanted message for every client,
+// stopping once no clients remain or the bus is closed
+func (h *sseHandler) relay() {
+	for {
+		h.mu.Lock()
+		if len(h.clients) == 0 {
+			h.relayOn = false
+			h.mu.Unlock()
+			return
+		}
+		h.mu.Unlock()
+
+		msg, err := h.bus.ReceiveData()
+		if err != nil && h.bus.closed() {
+			h.stop()
+			return
+		}
+		if err != nil || msg == nil {
+			if err != nil {
+				fmt.Printf("[Go SSE] receive failed: %v\n", err)
+			}
+			time.Sleep(receivePollInterval)
+			continue
+		}
+		if len(h.typeIDs) > 0 && !slices.Contains(h.typeIDs, msg.TypeID) {
+			continue
+		}
+
+		event := fmt.Appendf(nil, "event: %d\ndata: %s\n\n", msg.TypeID, base64.StdEncoding.EncodeToString(msg.Data))
+
+		h.mu.Lock()
+		if len(h.clients) == 0 {
+			// Everyone left while receiving; keep the message for the next client
+			_ = h.bus.Send(msg.Data, msg.TypeID)
+		}
+		for events := range h.clients {
+			select {
+			case events <- event:
+			default:
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+// stop marks the relay stopped and ends every stream after the bus closes
+func (h *sseHandler) stop() {
+	h.mu.Lock()
+	h.relayOn = false
+	h.mu.Unlock()
+
+	h.busClosedOnce.Do(func() { close(h.busClosed) })
+}
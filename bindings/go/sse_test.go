@@ -0,0 +1,121 @@
+package umsbb_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+// openSSE connects to url and returns the event stream once headers arrive
+func openSSE(t *testing.T, ctx context.Context, url string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", url, err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	return resp
+}
+
+// readEvent reads one "event:/data:" pair from r
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+
+	var event, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestSSEHandlerStreamsAndCleansUp(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t)
+
+	umsbbtest.RequireNoGoroutineLeak(t, func() {
+		srv := httptest.NewServer(umsbb.SSEHandler(bus, 1))
+		defer srv.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		resp := openSSE(t, ctx, srv.URL)
+
+		if err := bus.Send([]byte("hello"), 1); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		event, data := readEvent(t, bufio.NewReader(resp.Body))
+		if event != "1" || data != base64.StdEncoding.EncodeToString([]byte("hello")) {
+			t.Fatalf("event = %q data = %q, want 1 and base64 of hello", event, data)
+		}
+
+		// Disconnecting the only client stops the relay, so later messages
+		// stay on the bus
+		cancel()
+		_ = resp.Body.Close()
+		time.Sleep(50 * time.Millisecond)
+
+		if err := bus.Send([]byte("kept"), 1); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+		got, err := bus.Receive()
+		if err != nil || string(got) != "kept" {
+			t.Fatalf("Receive = %q, %v; want the message sent after disconnect", got, err)
+		}
+	})
+}
+
+func TestSSEHandlerEndsStreamsWhenBusCloses(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t)
+
+	umsbbtest.RequireNoGoroutineLeak(t, func() {
+		srv := httptest.NewServer(umsbb.SSEHandler(bus))
+		defer srv.Close()
+
+		resp := openSSE(t, context.Background(), srv.URL)
+		defer resp.Body.Close()
+
+		if err := bus.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := io.Copy(io.Discard, resp.Body)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("stream ended with %v, want a clean end", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("stream still open after the bus closed")
+		}
+	})
+}
@@ -0,0 +1,80 @@
+// Lock-free bus statistics
+
+package umsbb
+
+import "sync/atomic"
+
+// BusStats is a point-in-time snapshot of the bus counters
+type BusStats struct {
+	TotalSent     int64 `json:"total_sent"`
+	TotalReceived int64 `json:"total_received"`
+	TotalErrors   int64 `json:"total_errors"`
+	TotalDropped  int64 `json:"total_dropped"`
+	// DuplicateCount counts sends skipped by WithContentDeduplication
+	DuplicateCount int64 `json:"duplicate_count"`
+}
+
+// busCounters holds the hot-path counters updated by Send and Receive
+type busCounters struct {
+	totalSent       atomic.Int64
+	totalReceived   atomic.Int64
+	totalErrors     atomic.Int64
+	totalDropped    atomic.Int64
+	totalDuplicates atomic.Int64
+	bytesSent       atomic.Int64
+	bytesReceived   atomic.Int64
+}
+
+// snapshot reads every counter without taking the bus lock
+func (c *busCounters) snapshot() BusStats {
+	return BusStats{
+		TotalSent:      c.totalSent.Load(),
+		TotalReceived:  c.totalReceived.Load(),
+		TotalErrors:    c.totalErrors.Load(),
+		TotalDropped:   c.totalDropped.Load(),
+		DuplicateCount: c.totalDuplicates.Load(),
+	}
+}
+
+// Stats returns the bus counters without acquiring any lock
+//
+// TotalDropped counts messages the C layer refused to enqueue; TotalErrors
+// counts every other failed Send or Receive. The counters are read
+// independently, so a snapshot taken under load may be off by in-flight calls.
+//
+// Example:
+//
+//	stats := bus.Stats()
+//	fmt.Printf("sent=%d received=%d\n", stats.TotalSent, stats.TotalReceived)
+func (b *DirectUniversalBus) Stats() BusStats {
+	return b.counters.snapshot()
+}
+
+// QueueDepthAbsolute returns the payload bytes queued and the total capacity
+//
+// Both values come from lock-free counters: used is bytes sent minus bytes
+// received by this bus and total is the buffer size times the segment count.
+// Framing overhead and traffic from other language bindings are not included.
+func (b *DirectUniversalBus) QueueDepthAbsolute() (used uint64, total uint64) {
+	sent := b.counters.bytesSent.Load()
+	received := b.counters.bytesReceived.Load()
+	if sent > received {
+		used = uint64(sent - received)
+	}
+	return used, b.bufferSize * uint64(b.segments)
+}
+
+// QueueDepth returns the fraction of total capacity in use, in [0.0, 1.0]
+//
+// QueueDepth never locks, so it is cheap enough to poll from dashboards.
+//
+// Example:
+//
+//	gauge.Set(bus.QueueDepth())
+func (b *DirectUniversalBus) QueueDepth() float64 {
+	used, total := b.QueueDepthAbsolute()
+	if total == 0 {
+		return 0
+	}
+	return min(float64(used)/float64(total), 1)
+}
@@ -0,0 +1,55 @@
+// Sticky producer-to-segment routing
+
+package umsbb
+
+import "context"
+
+// StickySegment maps producerKey onto one of segments using jump consistent hashing
+//
+// Jump consistent hashing (Lamping and Veach, 2014) spreads keys uniformly
+// with no lookup table, and when the segment count grows from n to n+1 only
+// about 1/(n+1) of keys move. Distinct keys share a segment by design; with k
+// producers over n segments each segment expects k/n of them, so collisions
+// only matter for ordering if producers rely on exclusive segments.
+func StickySegment(producerKey uint64, segments uint32) uint32 {
+	if segments == 0 {
+		return 0
+	}
+
+	var b, j int64 = -1, 0
+	for j < int64(segments) {
+		b = j
+		producerKey = producerKey*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((producerKey>>33)+1)))
+	}
+	return uint32(b)
+}
+
+// SendSticky sends data to the segment selected for producerKey
+//
+// Every message with the same producer key lands in the same segment, so a
+// logical stream keeps its order regardless of type ID. The segment is chosen
+// with StickySegment and bypasses the C library's type ID routing.
+//
+// Example:
+//
+//	err := bus.SendSticky(payload, 1, deviceID)
+func (b *DirectUniversalBus) SendSticky(data []byte, typeID uint32, producerKey uint64) error {
+	_, span := b.opts().tracer.StartSpan(ContextWithTypeID(context.Background(), typeID), "umsbb.SendSticky")
+	err := b.validatePayload(data, typeID)
+	if err == nil {
+		err = b.sendSticky(data, typeID, producerKey)
+	}
+	endSpan(span, err)
+	return err
+}
+
+// sendSticky resolves the producer's segment and sends to it
+func (b *DirectUniversalBus) sendSticky(data []byte, typeID uint32, producerKey uint64) error {
+	segments, err := b.activeSegmentCount()
+	if err != nil {
+		b.counters.totalErrors.Add(1)
+		return err
+	}
+	return b.send(data, typeID, int(StickySegment(producerKey, segments)))
+}
@@ -0,0 +1,76 @@
+package umsbb_test
+
+import (
+	"math"
+	"testing"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+)
+
+func TestStickySegmentIsStableAndInRange(t *testing.T) {
+	for key := uint64(0); key < 1000; key++ {
+		seg := umsbb.StickySegment(key, 7)
+		if seg >= 7 {
+			t.Fatalf("StickySegment(%d, 7) = %d, out of range", key, seg)
+		}
+		if again := umsbb.StickySegment(key, 7); again != seg {
+			t.Fatalf("StickySegment(%d, 7) = %d then %d", key, seg, again)
+		}
+	}
+	if seg := umsbb.StickySegment(42, 0); seg != 0 {
+		t.Fatalf("StickySegment with no segments = %d, want 0", seg)
+	}
+}
+
+// TestStickySegmentCollisionAnalysis checks that keys collide no more than a
+// uniform spread would: each segment's share stays near keys/segments and a
+// chi-square test over the counts does not reject uniformity
+func TestStickySegmentCollisionAnalysis(t *testing.T) {
+	const keys = 100000
+
+	for _, segments := range []uint32{2, 8, 16} {
+		counts := make([]int, segments)
+		for key := uint64(0); key < keys; key++ {
+			counts[umsbb.StickySegment(key*0x9e3779b97f4a7c15, segments)]++
+		}
+
+		expected := float64(keys) / float64(segments)
+		chiSquare := 0.0
+		for seg, n := range counts {
+			if math.Abs(float64(n)-expected) > expected*0.05 {
+				t.Errorf("%d segments: segment %d holds %d keys, want %.0f ± 5%%", segments, seg, n, expected)
+			}
+			chiSquare += (float64(n) - expected) * (float64(n) - expected) / expected
+		}
+
+		// Generous bound: the 99.9th percentile for 15 degrees of freedom is 37.7
+		if chiSquare > 40 {
+			t.Errorf("%d segments: chi-square %.1f suggests a non-uniform spread", segments, chiSquare)
+		}
+	}
+}
+
+func TestStickySegmentMovesFewKeysOnGrowth(t *testing.T) {
+	const keys = 100000
+
+	for _, n := range []uint32{4, 8, 15} {
+		moved := 0
+		for key := uint64(0); key < keys; key++ {
+			before := umsbb.StickySegment(key, n)
+			after := umsbb.StickySegment(key, n+1)
+			if before == after {
+				continue
+			}
+			if after != n {
+				t.Fatalf("growing to %d segments moved key %d from %d to %d, want only moves to the new segment",
+					n+1, key, before, after)
+			}
+			moved++
+		}
+
+		want := 1 / float64(n+1)
+		if got := float64(moved) / keys; math.Abs(got-want) > want*0.1 {
+			t.Errorf("growing to %d segments moved %.3f of keys, want about %.3f", n+1, got, want)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Session-oriented byte streams multiplexed over a bus
+
+package umsbb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Stream framing headers and defaults
+const (
+	// HeaderStreamSession identifies the stream a chunk belongs to
+	HeaderStreamSession = "umsbb-stream-session"
+	// HeaderStreamEOF marks the last chunk of a stream
+	HeaderStreamEOF = "umsbb-stream-eof"
+	// HeaderStreamOrigin identifies the BusStream that wrote a chunk, so a
+	// stream never reads back its own writes
+	HeaderStreamOrigin = "umsbb-stream-origin"
+	// StreamTypeID tags every stream chunk so chunks share a segment and stay in order
+	StreamTypeID uint32 = 0xFFFF0001
+	// DefaultStreamChunkSize is the largest payload Write puts in one message
+	DefaultStreamChunkSize = 64 * 1024
+	// streamMaxPendingBytes bounds the chunks a mux holds for streams that
+	// have not read them yet
+	streamMaxPendingBytes = 16 << 20
+)
+
+// streamChunk is a received chunk waiting for its reader
+type streamChunk struct {
+	origin string
+	data   []byte
+	eof    bool
+}
+
+// streamMux demultiplexes stream chunks received from one bus by session
+type streamMux struct {
+	bus     Bus
+	options busOptions
+	refs    int
+
+	mu           sync.Mutex
+	pending      map[string][]streamChunk
+	pendingBytes int
+	open         map[string]int // local streams per session
+}
+
+// streamMuxes holds one mux per bus with open streams
+var (
+	streamMuxesMu sync.Mutex
+	streamMuxes   = make(map[Bus]*streamMux)
+)
+
+// acquireStreamMux returns the shared mux for bus, creating it if needed,
+// and registers a stream of sessionID with it
+func acquireStreamMux(bus Bus, sessionID string) *streamMux {
+	streamMuxesMu.Lock()
+	defer streamMuxesMu.Unlock()
+
+	m := streamMuxes[bus]
+	if m == nil {
+		m = &streamMux{
+			bus:     bus,
+			options: newBusOptions(nil),
+			pending: make(map[string][]streamChunk),
+			open:    make(map[string]int),
+		}
+		streamMuxes[bus] = m
+	}
+	m.refs++
+
+	m.mu.Lock()
+	m.open[sessionID]++
+	m.mu.Unlock()
+	return m
+}
+
+// release drops one reference, discarding the chunks of sessionID once no
+// local stream has it open, and forgets the mux when no stream uses it
+func (m *streamMux) release(sessionID string) {
+	m.mu.Lock()
+	if m.open[sessionID]--; m.open[sessionID] == 0 {
+		delete(m.open, sessionID)
+		for _, chunk := range m.pending[sessionID] {
+			m.pendingBytes -= len(chunk.data)
+		}
+		delete(m.pending, sessionID)
+	}
+	m.mu.Unlock()
+
+	streamMuxesMu.Lock()
+	defer streamMuxesMu.Unlock()
+
+	if m.refs--; m.refs == 0 {
+		delete(streamMuxes, m.bus)
+	}
+}
+
+// next returns the next chunk for sessionID written by a stream other than
+// origin, or io.EOF for its end-of-stream marker, filing chunks of other
+// sessions for their own streams
+//
+// While the filed chunks fill streamMaxPendingBytes nothing more is received,
+// so a reader that falls behind holds up the other sessions on the bus
+// rather than losing data.
+func (m *streamMux) next(sessionID, origin string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for {
+		if chunk, ok := m.take(sessionID, origin); ok {
+			if chunk.eof {
+				return nil, false, io.EOF
+			}
+			return chunk.data, true, nil
+		}
+		if m.pendingBytes >= streamMaxPendingBytes {
+			return nil, false, nil
+		}
+
+		msg, err := m.bus.ReceiveData()
+		if err != nil || msg == nil {
+			return nil, false, err
+		}
+
+		headers, payload, err := decodeMessage(msg.Data, m.options)
+		if err != nil {
+			continue // Not stream traffic
+		}
+		session, ok := headers[HeaderStreamSession]
+		if !ok {
+			continue
+		}
+		_, eof := headers[HeaderStreamEOF]
+		if len(payload) > 0 || eof {
+			m.pending[session] = append(m.pending[session], streamChunk{
+				origin: headers[HeaderStreamOrigin],
+				data:   payload,
+				eof:    eof,
+			})
+			m.pendingBytes += len(payload)
+		}
+	}
+}
+
+// take removes the first chunk of sessionID not written by origin
+//
+// Callers must hold m.mu.
+func (m *streamMux) take(sessionID, origin string) (streamChunk, bool) {
+	chunks := m.pending[sessionID]
+	for i, chunk := range chunks {
+		if chunk.origin == origin {
+			continue // Our own write, for the other end of the session
+		}
+		m.pending[sessionID] = append(chunks[:i:i], chunks[i+1:]...)
+		m.pendingBytes -= len(chunk.data)
+		return chunk, true
+	}
+	return streamChunk{}, false
+}
+
+// newStreamOrigin returns a random ID for a new BusStream
+func newStreamOrigin() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// BusStream is a byte stream carried over a bus in chunks tagged with a session ID
+//
+// A session is one pipe: what one BusStream writes is read by another
+// opened with the same session ID, typically in another process attached
+// to the same bus, and never by the writer itself. Several sessions can share one bus. The bus
+// should carry only stream traffic: streams reading from it discard messages
+// that are not stream chunks. Read and Write are each safe for use by one
+// goroutine at a time.
+type BusStream struct {
+	ctx       context.Context
+	mux       *streamMux
+	sessionID string
+	origin    string
+	eof       bool
+
+	// ChunkSize is the largest payload sent per message (default: 64KiB)
+	ChunkSize int
+
+	buf       []byte
+	closeOnce sync.Once
+	closeErr  error
+}
+
+var _ io.ReadWriteCloser = (*BusStream)(nil)
+
+// Stream opens the stream sessionID on bus
+//
+// Read blocks until data arrives or ctx is done. Close the stream to send the
+// end-of-stream marker and release it.
+//
+// Example:
+//
+//	s := umsbb.Stream(ctx, bus, "upload-42")
+//	defer s.Close()
+//	_, err := io.Copy(s, file)
+func Stream(ctx context.Context, bus Bus, sessionID string) *BusStream {
+	return &BusStream{
+		ctx:       ctx,
+		mux:       acquireStreamMux(bus, sessionID),
+		sessionID: sessionID,
+		origin:    newStreamOrigin(),
+		ChunkSize: DefaultStreamChunkSize,
+	}
+}
+
+// Write sends p as one or more chunks, waiting while the bus is full
+func (s *BusStream) Write(p []byte) (int, error) {
+	chunkSize := s.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultStreamChunkSize
+	}
+
+	written := 0
+	for written < len(p) {
+		end := min(written+chunkSize, len(p))
+		if err := s.send(p[written:end], false); err != nil {
+			return written, err
+		}
+		written = end
+	}
+	return written, nil
+}
+
+// Read reads reassembled stream data into p
+//
+// Returns io.EOF once the peer has closed the stream and all data is read.
+func (s *BusStream) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for len(s.buf) == 0 {
+		if s.eof {
+			return 0, io.EOF
+		}
+		var chunk []byte
+		err := pollContext(s.ctx, func() (bool, error) {
+			var ok bool
+			var err error
+			chunk, ok, err = s.mux.next(s.sessionID, s.origin)
+			return ok, err
+		})
+		if err == io.EOF {
+			s.eof = true
+		}
+		if err != nil {
+			return 0, err
+		}
+		s.buf = chunk
+	}
+
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
+
+// Close sends the end-of-stream marker and releases the stream
+func (s *BusStream) Close() error {
+	s.closeOnce.Do(func() {
+		s.closeErr = s.send(nil, true)
+		s.mux.release(s.sessionID)
+	})
+	return s.closeErr
+}
+
+// send frames one chunk with the session headers
+func (s *BusStream) send(chunk []byte, eof bool) error {
+	headers := map[string]string{HeaderStreamSession: s.sessionID, HeaderStreamOrigin: s.origin}
+	if eof {
+		headers[HeaderStreamEOF] = "1"
+	}
+
+	framed, err := encodeMessage(headers, chunk)
+	if err != nil {
+		return err
+	}
+	if err := sendContext(s.ctx, s.mux.bus, framed, StreamTypeID); err != nil {
+		return fmt.Errorf("stream write failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package umsbb_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+func TestStreamEndsDoNotReadTheirOwnWrites(t *testing.T) {
+	bus := umsbbtest.NewTestBus(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	a := umsbb.Stream(ctx, bus, "pipe")
+	b := umsbb.Stream(ctx, bus, "pipe")
+	defer b.Close()
+
+	if _, err := a.Write([]byte("from a")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := b.Write([]byte("from b")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// a reads first, so it must skip its own chunk to find b's
+	buf := make([]byte, 16)
+	if n, err := a.Read(buf); err != nil || string(buf[:n]) != "from b" {
+		t.Fatalf("a.Read = %q, %v; want b's write", buf[:n], err)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Closing a keeps its unread chunk for b, followed by the end of stream
+	got, err := io.ReadAll(b)
+	if err != nil || string(got) != "from a" {
+		t.Fatalf("b read %q, %v; want a's write then EOF", got, err)
+	}
+}
@@ -0,0 +1,140 @@
+// Sliding-window send throughput
+
+package umsbb
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultThroughputWindow is the window used when StartThroughputMeasurement is given 0
+const DefaultThroughputWindow = time.Second
+
+// throughputSlots is the number of samples kept per window
+const throughputSlots = 10
+
+// throughputMinInterval bounds the sampling rate of very short windows
+const throughputMinInterval = time.Millisecond
+
+// ThroughputReport is the send rate over the measurement window
+type ThroughputReport struct {
+	MessagesPerSecond  float64 `json:"messages_per_second"`
+	MegabytesPerSecond float64 `json:"megabytes_per_second"`
+}
+
+// throughputSample is a reading of the send counters
+type throughputSample struct {
+	at       time.Time
+	messages int64
+	bytes    int64
+}
+
+// throughputMeter samples the send counters throughputSlots times per window
+type throughputMeter struct {
+	mu      sync.Mutex
+	window  time.Duration
+	samples []throughputSample
+	stop    chan struct{}
+}
+
+// throughputSample reads the bus send counters
+func (b *DirectUniversalBus) throughputSample() throughputSample {
+	return throughputSample{
+		at:       time.Now(),
+		messages: b.counters.totalSent.Load(),
+		bytes:    b.counters.bytesSent.Load(),
+	}
+}
+
+// StartThroughputMeasurement starts measuring sends over a sliding window
+//
+// A window of 0 uses DefaultThroughputWindow. Samples are taken at most once
+// a millisecond, so windows under 10ms hold fewer samples. Calling it again
+// restarts the measurement with the new window; Close stops it.
+//
+// Example:
+//
+//	bus.StartThroughputMeasurement(5 * time.Second)
+//	defer bus.StopThroughputMeasurement()
+//	report := bus.Throughput()
+//	fmt.Printf("%.0f msg/s, %.2f MB/s\n", report.MessagesPerSecond, report.MegabytesPerSecond)
+func (b *DirectUniversalBus) StartThroughputMeasurement(window time.Duration) {
+	if window <= 0 {
+		window = DefaultThroughputWindow
+	}
+
+	m := &b.throughput
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stop != nil {
+		close(m.stop)
+	}
+	m.window = window
+	m.samples = []throughputSample{b.throughputSample()}
+	m.stop = make(chan struct{})
+
+	go b.sampleThroughput(max(window/throughputSlots, throughputMinInterval), m.stop)
+}
+
+// sampleThroughput records a sample every interval until stop is closed
+func (b *DirectUniversalBus) sampleThroughput(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	m := &b.throughput
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		sample := b.throughputSample()
+		m.mu.Lock()
+		m.samples = append(m.samples, sample)
+		// Keep one sample at or beyond the window edge as the baseline
+		for len(m.samples) > 2 && sample.at.Sub(m.samples[1].at) >= m.window {
+			m.samples = m.samples[1:]
+		}
+		m.mu.Unlock()
+	}
+}
+
+// StopThroughputMeasurement stops the sampler started by StartThroughputMeasurement
+func (b *DirectUniversalBus) StopThroughputMeasurement() {
+	m := &b.throughput
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+	m.samples = nil
+}
+
+// Throughput returns the send rate over the measurement window
+//
+// The rate covers messages accepted by Send and is zero while the bus is
+// idle or no measurement is running. Megabytes are 10^6 bytes of payload.
+func (b *DirectUniversalBus) Throughput() ThroughputReport {
+	m := &b.throughput
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.samples) == 0 {
+		return ThroughputReport{}
+	}
+
+	now := b.throughputSample()
+	base := m.samples[0]
+	elapsed := now.at.Sub(base.at).Seconds()
+	if elapsed <= 0 {
+		return ThroughputReport{}
+	}
+	return ThroughputReport{
+		MessagesPerSecond:  float64(now.messages-base.messages) / elapsed,
+		MegabytesPerSecond: float64(now.bytes-base.bytes) / elapsed / 1e6,
+	}
+}
@@ -0,0 +1,29 @@
+package umsbb_test
+
+import (
+	"testing"
+	"time"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+func TestThroughputTinyWindowAndClose(t *testing.T) {
+	umsbbtest.RequireLibrary(t)
+
+	umsbbtest.RequireNoGoroutineLeak(t, func() {
+		bus, err := umsbb.NewDirectUniversalBus(64*1024, 1, false, false)
+		if err != nil {
+			t.Fatalf("NewDirectUniversalBus failed: %v", err)
+		}
+
+		// A window under 10ns once made the sampler's ticker panic
+		bus.StartThroughputMeasurement(5 * time.Nanosecond)
+		time.Sleep(5 * time.Millisecond)
+
+		// Close stops the sampler without StopThroughputMeasurement
+		if err := bus.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+// Periodic metric sampling into the bus
+
+package umsbb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// timePointSize is the encoded size of a TimePoint: float64 value then int64 unix nanoseconds
+const timePointSize = 16
+
+// TimePoint is one sampled metric reading
+type TimePoint struct {
+	Value float64
+	Time  time.Time
+}
+
+// EncodeTimePoint encodes p as a 16 byte little-endian payload
+func EncodeTimePoint(p TimePoint) []byte {
+	buf := make([]byte, timePointSize)
+	binary.LittleEndian.PutUint64(buf[0:8], math.Float64bits(p.Value))
+	binary.LittleEndian.PutUint64(buf[8:16], uint64(p.Time.UnixNano()))
+	return buf
+}
+
+// DecodeTimePoint decodes a payload written by EncodeTimePoint
+func DecodeTimePoint(data []byte) (TimePoint, error) {
+	if len(data) != timePointSize {
+		return TimePoint{}, fmt.Errorf("time point payload is %d bytes, want %d", len(data), timePointSize)
+	}
+	return TimePoint{
+		Value: math.Float64frombits(binary.LittleEndian.Uint64(data[0:8])),
+		Time:  time.Unix(0, int64(binary.LittleEndian.Uint64(data[8:16]))),
+	}, nil
+}
+
+// TimeSeriesProducer samples a metric on an interval and sends each reading
+type TimeSeriesProducer struct {
+	bus      Bus
+	sample   func() float64
+	typeID   uint32
+	interval time.Duration
+	errors   atomic.Int64
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewTimeSeriesProducer starts sampling sample every interval and sending readings tagged with typeID
+//
+// Example:
+//
+//	producer := umsbb.NewTimeSeriesProducer(bus, func() float64 {
+//	    return float64(runtime.NumGoroutine())
+//	}, 10, time.Second)
+//	defer producer.Stop()
+func NewTimeSeriesProducer(bus Bus, sample func() float64, typeID uint32, interval time.Duration) *TimeSeriesProducer {
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	p := &TimeSeriesProducer{
+		bus:      bus,
+		sample:   sample,
+		typeID:   typeID,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// run sends one reading per tick until Stop
+func (p *TimeSeriesProducer) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			reading := TimePoint{Value: p.sample(), Time: now}
+			if err := p.bus.Send(EncodeTimePoint(reading), p.typeID); err != nil {
+				p.errors.Add(1)
+			}
+		}
+	}
+}
+
+// SendErrors returns how many readings the bus refused
+func (p *TimeSeriesProducer) SendErrors() int64 {
+	return p.errors.Load()
+}
+
+// Stop stops sampling and waits for the producer goroutine to exit
+func (p *TimeSeriesProducer) Stop() {
+	p.once.Do(func() { close(p.stop) })
+	<-p.done
+}
+
+// TimeSeriesConsumer decodes readings sent by a TimeSeriesProducer
+//
+// The consumer expects a bus dedicated to the series; messages with another
+// type ID are drained and counted as skipped.
+type TimeSeriesConsumer struct {
+	bus     Bus
+	typeID  uint32
+	skipped atomic.Int64
+}
+
+// NewTimeSeriesConsumer creates a consumer for readings tagged with typeID
+func NewTimeSeriesConsumer(bus Bus, typeID uint32) *TimeSeriesConsumer {
+	return &TimeSeriesConsumer{
+		bus:    bus,
+		typeID: typeID,
+	}
+}
+
+// Collect drains up to max readings currently available
+//
+// Example:
+//
+//	points, err := consumer.Collect(1000)
+//	for _, p := range points {
+//	    fmt.Printf("%s %.2f\n", p.Time.Format(time.RFC3339), p.Value)
+//	}
+func (c *TimeSeriesConsumer) Collect(max int) ([]TimePoint, error) {
+	var points []TimePoint
+	for len(points) < max {
+		msg, err := c.bus.ReceiveData()
+		if err != nil {
+			return points, err
+		}
+		if msg == nil {
+			break
+		}
+
+		if msg.TypeID != c.typeID {
+			c.skipped.Add(1)
+			continue
+		}
+
+		point, err := DecodeTimePoint(msg.Data)
+		if err != nil {
+			return points, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// Skipped returns how many messages with another type ID were drained
+func (c *TimeSeriesConsumer) Skipped() int64 {
+	return c.skipped.Load()
+}
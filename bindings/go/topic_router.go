@@ -0,0 +1,179 @@
+// Hierarchical topic routing with MQTT-style wildcards
+
+package umsbb
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Topic wildcards: '+' matches exactly one level, '#' matches any remaining levels
+const (
+	topicSeparator      = "/"
+	topicSingleWildcard = "+"
+	topicMultiWildcard  = "#"
+)
+
+// ErrNoRoute is returned by Publish when no subscription matches a topic
+var ErrNoRoute = errors.New("no subscription matches topic")
+
+// topicNode is one level of the subscription prefix tree
+type topicNode struct {
+	children map[string]*topicNode
+	exact    []uint32 // type IDs of subscriptions ending at this level
+	rest     []uint32 // type IDs of "#" subscriptions below this level
+}
+
+// child returns the node for level, creating it if needed
+func (n *topicNode) child(level string) *topicNode {
+	if n.children == nil {
+		n.children = make(map[string]*topicNode)
+	}
+	c, ok := n.children[level]
+	if !ok {
+		c = &topicNode{}
+		n.children[level] = c
+	}
+	return c
+}
+
+// TopicRouter maps hierarchical topics such as "sensors/temperature/room1"
+// onto type IDs through wildcard subscriptions
+//
+// Subscriptions are stored in a prefix tree keyed by topic level, so routing
+// a topic only visits the branches its levels can match.
+type TopicRouter struct {
+	mu   sync.RWMutex
+	root topicNode
+}
+
+// NewTopicRouter creates a router with no subscriptions
+func NewTopicRouter() *TopicRouter {
+	return &TopicRouter{}
+}
+
+// validateTopicPattern checks wildcard placement in pattern
+func validateTopicPattern(pattern string) ([]string, error) {
+	if pattern == "" {
+		return nil, errors.New("topic pattern cannot be empty")
+	}
+
+	levels := strings.Split(pattern, topicSeparator)
+	for i, level := range levels {
+		if strings.Contains(level, topicMultiWildcard) && (level != topicMultiWildcard || i != len(levels)-1) {
+			return nil, fmt.Errorf("topic pattern %q: '#' must be a whole final level", pattern)
+		}
+		if strings.Contains(level, topicSingleWildcard) && level != topicSingleWildcard {
+			return nil, fmt.Errorf("topic pattern %q: '+' must be a whole level", pattern)
+		}
+	}
+	return levels, nil
+}
+
+// Subscribe routes topics matching pattern to typeID
+//
+// Example:
+//
+//	router := umsbb.NewTopicRouter()
+//	_ = router.Subscribe("sensors/#", 10)             // everything under sensors
+//	_ = router.Subscribe("sensors/temperature/+", 11) // one room level
+func (r *TopicRouter) Subscribe(pattern string, typeID uint32) error {
+	levels, err := validateTopicPattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	node := &r.root
+	for _, level := range levels {
+		if level == topicMultiWildcard {
+			if !slices.Contains(node.rest, typeID) {
+				node.rest = append(node.rest, typeID)
+			}
+			return nil
+		}
+		node = node.child(level)
+	}
+	if !slices.Contains(node.exact, typeID) {
+		node.exact = append(node.exact, typeID)
+	}
+	return nil
+}
+
+// Unsubscribe removes the route from pattern to typeID
+func (r *TopicRouter) Unsubscribe(pattern string, typeID uint32) error {
+	levels, err := validateTopicPattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	node := &r.root
+	for _, level := range levels {
+		if level == topicMultiWildcard {
+			node.rest = slices.DeleteFunc(node.rest, func(id uint32) bool { return id == typeID })
+			return nil
+		}
+		if node = node.children[level]; node == nil {
+			return nil
+		}
+	}
+	node.exact = slices.DeleteFunc(node.exact, func(id uint32) bool { return id == typeID })
+	return nil
+}
+
+// Route returns the distinct type IDs of every subscription matching topic
+func (r *TopicRouter) Route(topic string) []uint32 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []uint32
+	r.match(&r.root, strings.Split(topic, topicSeparator), &matched)
+
+	slices.Sort(matched)
+	return slices.Compact(matched)
+}
+
+// match collects type IDs for levels below node
+func (r *TopicRouter) match(node *topicNode, levels []string, matched *[]uint32) {
+	// "a/#" also matches "a" itself
+	*matched = append(*matched, node.rest...)
+
+	if len(levels) == 0 {
+		*matched = append(*matched, node.exact...)
+		return
+	}
+
+	if c := node.children[levels[0]]; c != nil {
+		r.match(c, levels[1:], matched)
+	}
+	if c := node.children[topicSingleWildcard]; c != nil {
+		r.match(c, levels[1:], matched)
+	}
+}
+
+// Publish sends data to bus once for every type ID routed from topic
+//
+// Returns:
+//   - sent: Number of type IDs the payload was sent to
+//   - error: ErrNoRoute if nothing matched, or the first send error
+func (r *TopicRouter) Publish(bus BusInterface, topic string, data []byte) (int, error) {
+	typeIDs := r.Route(topic)
+	if len(typeIDs) == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrNoRoute, topic)
+	}
+
+	for i, typeID := range typeIDs {
+		if err := bus.Send(data, typeID); err != nil {
+			return i, err
+		}
+	}
+	return len(typeIDs), nil
+}
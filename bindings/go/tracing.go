@@ -0,0 +1,60 @@
+// Minimal pluggable tracing without an OpenTelemetry dependency
+
+package umsbb
+
+import "context"
+
+// Span is an in-progress traced operation
+type Span interface {
+	// RecordError marks the span as failed
+	RecordError(err error)
+	// End completes the span
+	End()
+}
+
+// Tracer creates spans for bus operations
+//
+// Wrap an OpenTelemetry tracer, or any other, to adapt it:
+//
+//	type otelTracer struct{ t trace.Tracer }
+//
+//	func (o otelTracer) StartSpan(ctx context.Context, name string) (context.Context, umsbb.Span) {
+//	    ctx, span := o.t.Start(ctx, name)
+//	    return ctx, otelSpan{span}
+//	}
+type Tracer interface {
+	// StartSpan starts a span named name as a child of any span in ctx
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+	// Inject writes the span context in ctx into carrier for propagation
+	Inject(ctx context.Context, carrier map[string]string)
+}
+
+// NoopTracer is the default Tracer; it records nothing
+type NoopTracer struct{}
+
+var _ Tracer = NoopTracer{}
+
+// noopSpan is returned by NoopTracer
+type noopSpan struct{}
+
+// StartSpan returns ctx unchanged and a span that does nothing
+func (NoopTracer) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+// Inject leaves carrier unchanged
+func (NoopTracer) Inject(ctx context.Context, carrier map[string]string) {}
+
+// RecordError does nothing
+func (noopSpan) RecordError(err error) {}
+
+// End does nothing
+func (noopSpan) End() {}
+
+// endSpan records err, if any, and ends span
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
@@ -0,0 +1,69 @@
+// Per-type-ID send frequency
+
+package umsbb
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// TypeIDCount is the number of messages sent with one type ID
+type TypeIDCount struct {
+	TypeID uint32 `json:"type_id"`
+	Count  uint64 `json:"count"`
+}
+
+// typeFrequency counts successful sends per type ID
+type typeFrequency struct {
+	counts sync.Map // uint32 -> *atomic.Uint64
+}
+
+// record counts one message of typeID
+func (f *typeFrequency) record(typeID uint32) {
+	counter, ok := f.counts.Load(typeID)
+	if !ok {
+		counter, _ = f.counts.LoadOrStore(typeID, new(atomic.Uint64))
+	}
+	counter.(*atomic.Uint64).Add(1)
+}
+
+// TypeIDFrequency returns how many messages of each type ID were sent since
+// the last ResetTypeIDFrequency
+//
+// Example:
+//
+//	for typeID, n := range bus.TypeIDFrequency() {
+//	    fmt.Printf("type %d: %d\n", typeID, n)
+//	}
+func (b *DirectUniversalBus) TypeIDFrequency() map[uint32]uint64 {
+	freq := make(map[uint32]uint64)
+	b.typeFreq.counts.Range(func(key, value any) bool {
+		freq[key.(uint32)] = value.(*atomic.Uint64).Load()
+		return true
+	})
+	return freq
+}
+
+// TypeIDFrequencyRanked returns TypeIDFrequency sorted by count, highest
+// first, with ties ordered by type ID
+func (b *DirectUniversalBus) TypeIDFrequencyRanked() []TypeIDCount {
+	freq := b.TypeIDFrequency()
+	ranked := make([]TypeIDCount, 0, len(freq))
+	for typeID, n := range freq {
+		ranked = append(ranked, TypeIDCount{TypeID: typeID, Count: n})
+	}
+	slices.SortFunc(ranked, func(a, b TypeIDCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.TypeID, b.TypeID))
+	})
+	return ranked
+}
+
+// ResetTypeIDFrequency clears the per-type-ID send counts
+func (b *DirectUniversalBus) ResetTypeIDFrequency() {
+	b.typeFreq.counts.Range(func(key, _ any) bool {
+		b.typeFreq.counts.Delete(key)
+		return true
+	})
+}
@@ -0,0 +1,169 @@
+// Type registry - resolves Go types from bus type IDs at runtime
+
+package umsbb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrTypeIDConflict is returned when a type ID is already bound to a different Go type
+var ErrTypeIDConflict = errors.New("type ID already registered with a different type")
+
+// ErrUnknownTypeID is returned when a type ID has no registered Go type
+var ErrUnknownTypeID = errors.New("type ID not registered")
+
+// DynamicTypeRegistry maps bus type IDs to Go types at runtime
+type DynamicTypeRegistry struct {
+	mu      sync.RWMutex
+	types   map[uint32]reflect.Type
+	typeIDs map[reflect.Type]uint32
+}
+
+// NewDynamicTypeRegistry creates an empty type registry
+func NewDynamicTypeRegistry() *DynamicTypeRegistry {
+	return &DynamicTypeRegistry{
+		types:   make(map[uint32]reflect.Type),
+		typeIDs: make(map[reflect.Type]uint32),
+	}
+}
+
+// Register binds typeID to the type of prototype
+//
+// Pointer prototypes register their element type, so Register(1, Reading{})
+// and Register(1, &Reading{}) are equivalent. Registering the same pair twice
+// is a no-op; binding a type ID to a second type returns ErrTypeIDConflict.
+//
+// Example:
+//
+//	registry := umsbb.NewDynamicTypeRegistry()
+//	if err := registry.Register(1, SensorReading{}); err != nil {
+//	    log.Fatal(err)
+//	}
+func (r *DynamicTypeRegistry) Register(typeID uint32, prototype any) error {
+	if prototype == nil {
+		return errors.New("prototype cannot be nil")
+	}
+
+	t := reflect.TypeOf(prototype)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.types[typeID]; ok {
+		if existing == t {
+			return nil
+		}
+		return fmt.Errorf("%w: type ID %d is bound to %s, not %s", ErrTypeIDConflict, typeID, existing, t)
+	}
+
+	r.types[typeID] = t
+	if _, ok := r.typeIDs[t]; !ok {
+		r.typeIDs[t] = typeID
+	}
+	return nil
+}
+
+// Resolve returns the Go type registered for typeID
+func (r *DynamicTypeRegistry) Resolve(typeID uint32) (reflect.Type, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.types[typeID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownTypeID, typeID)
+	}
+	return t, nil
+}
+
+// TypeIDOf returns the type ID registered for the type of v
+func (r *DynamicTypeRegistry) TypeIDOf(v any) (uint32, bool) {
+	if v == nil {
+		return 0, false
+	}
+
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	typeID, ok := r.typeIDs[t]
+	return typeID, ok
+}
+
+// TypedBus sends and receives registered Go values as JSON payloads
+type TypedBus struct {
+	bus      *DirectUniversalBus
+	registry *DynamicTypeRegistry
+}
+
+// NewTypedBus creates a typed view over bus using registry for type resolution
+func NewTypedBus(bus *DirectUniversalBus, registry *DynamicTypeRegistry) *TypedBus {
+	return &TypedBus{
+		bus:      bus,
+		registry: registry,
+	}
+}
+
+// Registry returns the type registry used by the typed bus
+func (tb *TypedBus) Registry() *DynamicTypeRegistry {
+	return tb.registry
+}
+
+// SendTyped encodes v and sends it with the type ID registered for its type
+//
+// Example:
+//
+//	err := typed.SendTyped(SensorReading{Celsius: 21.5})
+func (tb *TypedBus) SendTyped(v any) error {
+	typeID, ok := tb.registry.TypeIDOf(v)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrUnknownTypeID, v)
+	}
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %T: %w", v, err)
+	}
+	return tb.bus.Send(payload, typeID)
+}
+
+// ReceiveTyped receives the next message and decodes it into its registered type
+//
+// Returns:
+//   - value: Pointer to a new value of the registered type, or nil if nothing available
+//   - error: ErrUnknownTypeID if the message type ID is not registered
+//
+// Example:
+//
+//	v, err := typed.ReceiveTyped()
+//	switch msg := v.(type) {
+//	case *SensorReading:
+//	    fmt.Printf("Reading: %.1f\n", msg.Celsius)
+//	}
+func (tb *TypedBus) ReceiveTyped() (any, error) {
+	msg, err := tb.bus.ReceiveData()
+	if err != nil || msg == nil {
+		return nil, err
+	}
+
+	t, err := tb.registry.Resolve(msg.TypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	value := reflect.New(t)
+	if err := json.Unmarshal(msg.Data, value.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to decode type ID %d as %s: %w", msg.TypeID, t, err)
+	}
+	return value.Interface(), nil
+}
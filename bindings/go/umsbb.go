@@ -3,90 +3,16 @@
 
 package umsbb
 
-/*
-#cgo CFLAGS: -I../../include
-#cgo LDFLAGS: -L../../lib -luniversal_multi_segmented_bi_buffer_bus
-
-#include <stdlib.h>
-#include <stdint.h>
-#include <stdbool.h>
-
-// Language types
-typedef enum {
-    LANG_C = 0,
-    LANG_CPP,
-    LANG_PYTHON,
-    LANG_JAVASCRIPT,
-    LANG_RUST,
-    LANG_GO,
-    LANG_JAVA,
-    LANG_CSHARP,
-    LANG_KOTLIN,
-    LANG_SWIFT
-} language_type_t;
-
-// Universal data structure
-typedef struct {
-    void* data;
-    size_t size;
-    uint32_t type_id;
-    language_type_t source_lang;
-} universal_data_t;
-
-// Scaling configuration
-typedef struct {
-    uint32_t min_producers;
-    uint32_t max_producers;
-    uint32_t min_consumers;
-    uint32_t max_consumers;
-    uint32_t scale_threshold_percent;
-    uint32_t scale_cooldown_ms;
-    bool gpu_preferred;
-    bool auto_balance_load;
-} scaling_config_t;
-
-// GPU capabilities
-typedef struct {
-    bool has_cuda;
-    bool has_opencl;
-    bool has_compute;
-    bool has_memory_pool;
-    size_t memory_size;
-    int compute_capability;
-    size_t max_threads;
-} gpu_capabilities_t;
-
-// Core functions
-void* umsbb_create_direct(size_t buffer_size, uint32_t segment_count, language_type_t lang);
-bool umsbb_submit_direct(void* handle, const universal_data_t* data);
-universal_data_t* umsbb_drain_direct(void* handle, language_type_t target_lang);
-void umsbb_destroy_direct(void* handle);
-
-// GPU functions
-bool initialize_gpu();
-bool gpu_available();
-gpu_capabilities_t get_gpu_capabilities();
-
-// Scaling functions
-bool configure_auto_scaling(const scaling_config_t* config);
-uint32_t get_optimal_producer_count();
-uint32_t get_optimal_consumer_count();
-void trigger_scale_evaluation();
-
-// Memory management
-universal_data_t* create_universal_data(void* data, size_t size, uint32_t type_id, language_type_t lang);
-void free_universal_data(universal_data_t* data);
-*/
-import "C"
-
 import (
+	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
-	"unsafe"
 )
 
 // LanguageType represents the supported language types
@@ -105,6 +31,42 @@ const (
 	LangSwift
 )
 
+// languageNames are the lowercase names of the LanguageType constants, in order
+var languageNames = [...]string{"c", "cpp", "python", "javascript", "rust", "go", "java", "csharp", "kotlin", "swift"}
+
+// String returns the lowercase language name, such as "go" or "rust"
+func (l LanguageType) String() string {
+	if l >= 0 && int(l) < len(languageNames) {
+		return languageNames[l]
+	}
+	return fmt.Sprintf("LanguageType(%d)", int(l))
+}
+
+// ParseLanguageType returns the LanguageType named s, ignoring case
+//
+// It accepts the names returned by String and the aliases "c++", "js" and "c#".
+//
+// Example:
+//
+//	lang, err := umsbb.ParseLanguageType("rust")
+func ParseLanguageType(s string) (LanguageType, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	switch name {
+	case "c++":
+		return LangCPP, nil
+	case "js":
+		return LangJavaScript, nil
+	case "c#":
+		return LangCSharp, nil
+	}
+	for i, n := range languageNames {
+		if n == name {
+			return LanguageType(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown language %q", s)
+}
+
 // UniversalData represents cross-language data
 type UniversalData struct {
 	Data       []byte
@@ -114,14 +76,14 @@ type UniversalData struct {
 
 // ScalingConfig represents auto-scaling configuration
 type ScalingConfig struct {
-	MinProducers          uint32
-	MaxProducers          uint32
-	MinConsumers          uint32
-	MaxConsumers          uint32
-	ScaleThresholdPercent uint32
-	ScaleCooldownMs       uint32
-	GPUPreferred          bool
-	AutoBalanceLoad       bool
+	MinProducers          uint32 `json:"min_producers"`
+	MaxProducers          uint32 `json:"max_producers"`
+	MinConsumers          uint32 `json:"min_consumers"`
+	MaxConsumers          uint32 `json:"max_consumers"`
+	ScaleThresholdPercent uint32 `json:"scale_threshold_percent"`
+	ScaleCooldownMs       uint32 `json:"scale_cooldown_ms"`
+	GPUPreferred          bool   `json:"gpu_preferred"`
+	AutoBalanceLoad       bool   `json:"auto_balance_load"`
 }
 
 // GPUInfo represents GPU capabilities
@@ -144,11 +106,37 @@ type ScalingStatus struct {
 
 // DirectUniversalBus provides direct access to the Universal Multi-Segmented Bi-Buffer Bus
 type DirectUniversalBus struct {
-	handle       unsafe.Pointer
+	handle       busHandle
 	bufferSize   uint64
 	segmentCount uint32
+	segments     uint32 // segments created by the C library; fixed for the bus lifetime
 	gpuEnabled   bool
 	mu           sync.RWMutex
+	counters     busCounters
+	options      atomic.Pointer[busOptions] // replaced as a whole by ApplyOptions
+	fanout       *fanoutHub
+	acks         ackTable
+	retries      *retryTracker
+	wal          *walLog
+	segJournal   *segmentJournal
+	retention    *retentionBuffer
+	leases       leaseTable
+	dedup        *dedupWindow
+	contentDedup *contentFilter
+	affinity     affinityTable
+	reconnects   reconnectListeners
+	sent         sendSignal
+	ordered      *orderedDelivery
+	throughput   throughputMeter
+	rates        atomic.Pointer[RateMeter]
+	typeFreq     typeFrequency
+	generation   uint64 // incremented by recreateHandle; guarded by mu
+	closing      bool   // set by CloseGraceful to refuse new sends; guarded by mu
+	closeOnce    sync.Once
+	closeErr     error // result of the first Close
+	events       atomic.Pointer[eventLog]
+	canary       canaryState
+	sendLimiter  atomic.Pointer[LocalLimiter] // set by WarmDown
 }
 
 // NewDirectUniversalBus creates a new Direct Universal Bus
@@ -158,6 +146,7 @@ type DirectUniversalBus struct {
 //   - segmentCount: Number of segments (0 = auto-determine)
 //   - gpuPreferred: Prefer GPU processing for large operations
 //   - autoScale: Enable automatic scaling
+//   - opts: Optional behaviour such as WithFanout
 //
 // Example:
 //
@@ -166,51 +155,100 @@ type DirectUniversalBus struct {
 //	    log.Fatal(err)
 //	}
 //	defer bus.Close()
-func NewDirectUniversalBus(bufferSize uint64, segmentCount uint32, gpuPreferred, autoScale bool) (*DirectUniversalBus, error) {
+func NewDirectUniversalBus(bufferSize uint64, segmentCount uint32, gpuPreferred, autoScale bool, opts ...BusOption) (*DirectUniversalBus, error) {
+	options := newBusOptions(opts)
+	if options.bufferSize != nil {
+		bufferSize = *options.bufferSize
+	}
+	if options.segmentCount != nil {
+		segmentCount = *options.segmentCount
+	}
+	if options.gpuPreferred != nil {
+		gpuPreferred = *options.gpuPreferred
+	}
+	if limit, err := maxSupportedSegments(); err == nil && segmentCount > limit {
+		return nil, fmt.Errorf("%w: %d > %d", ErrSegmentCountTooLarge, segmentCount, limit)
+	}
+
 	if autoScale {
 		if err := configureAutoScalingInternal(gpuPreferred); err != nil {
 			return nil, fmt.Errorf("failed to configure auto-scaling: %w", err)
 		}
 	}
 
-	handle := C.umsbb_create_direct(C.size_t(bufferSize), C.uint32_t(segmentCount), C.LANG_GO)
-	if handle == nil {
-		return nil, errors.New("failed to create Universal Bus")
+	var wal *walLog
+	if options.walPath != "" {
+		var err error
+		if wal, err = openWAL(options.walPath); err != nil {
+			return nil, err
+		}
+	}
+
+	journal, err := openSegmentJournal(options.journalDir)
+	if err != nil {
+		_ = wal.close()
+		return nil, err
+	}
+
+	handle, err := createHandle("create", bufferSize, segmentCount, options.languagePriority)
+	if err != nil {
+		_ = wal.close()
+		_ = journal.close()
+		return nil, err
 	}
 
 	gpuEnabled := false
 	if gpuPreferred {
-		gpuEnabled = bool(C.initialize_gpu())
+		gpuEnabled = initializeGPU()
 	}
 
 	bus := &DirectUniversalBus{
 		handle:       handle,
 		bufferSize:   bufferSize,
 		segmentCount: segmentCount,
+		segments:     handleSegmentCount(handle),
 		gpuEnabled:   gpuEnabled,
+		retries:      newRetryTracker(options.retryBudgets),
+		wal:          wal,
+		segJournal:   journal,
+		retention:    newRetentionBuffer(options.retainMessages, options.retainBytes, options.retainPerType),
+		dedup:        newDedupWindow(options.dedupWindow),
+		contentDedup: newContentFilter(options.contentWindow, options.contentHashes),
+		ordered:      newOrderedDelivery(options.orderedTypeIDs),
+	}
+	bus.options.Store(&options)
+	if options.fanout {
+		bus.fanout = newFanoutHub(options.fanoutQueueSize)
 	}
 
 	// Set finalizer to ensure cleanup
 	runtime.SetFinalizer(bus, (*DirectUniversalBus).Close)
 
+	for _, initFunc := range options.initFuncs {
+		if err := initFunc(bus); err != nil {
+			_ = bus.Close()
+			return nil, fmt.Errorf("bus init function failed: %w", err)
+		}
+	}
+
 	fmt.Printf("[Go Direct] Bus created with %d byte segments, GPU: %t\n", bufferSize, gpuEnabled)
 	return bus, nil
 }
 
 // configureAutoScalingInternal configures automatic scaling parameters
 func configureAutoScalingInternal(gpuPreferred bool) error {
-	config := C.scaling_config_t{
-		min_producers:            1,
-		max_producers:            16,
-		min_consumers:            1,
-		max_consumers:            8,
-		scale_threshold_percent:  75,
-		scale_cooldown_ms:        1000,
-		gpu_preferred:            C.bool(gpuPreferred),
-		auto_balance_load:        C.bool(true),
-	}
-
-	if !bool(C.configure_auto_scaling(&config)) {
+	config := ScalingConfig{
+		MinProducers:          1,
+		MaxProducers:          16,
+		MinConsumers:          1,
+		MaxConsumers:          8,
+		ScaleThresholdPercent: 75,
+		ScaleCooldownMs:       1000,
+		GPUPreferred:          gpuPreferred,
+		AutoBalanceLoad:       true,
+	}
+
+	if !configureAutoScaling(config) {
 		return errors.New("failed to configure auto-scaling")
 	}
 	return nil
@@ -229,42 +267,242 @@ func configureAutoScalingInternal(gpuPreferred bool) error {
 //	    log.Printf("Send failed: %v", err)
 //	}
 func (b *DirectUniversalBus) Send(data []byte, typeID uint32) error {
+	return b.sendFramed(data, data, typeID)
+}
+
+// sendFramed validates payload against the schema registered for typeID
+// and sends framed, the bytes that carry it on the bus
+//
+// Wrappers that add their own framing, such as ChecksumBus, send through
+// here so the schema sees the caller's payload rather than the frame.
+func (b *DirectUniversalBus) sendFramed(payload, framed []byte, typeID uint32) error {
+	_, span := b.opts().tracer.StartSpan(ContextWithTypeID(context.Background(), typeID), "umsbb.Send")
+	err := b.validatePayload(payload, typeID)
+	if err == nil {
+		err = b.send(framed, typeID, -1)
+	}
+	endSpan(span, err)
+	return err
+}
+
+// validatePayload checks a caller's payload against the schema registered
+// for typeID, and rejects the canary type ID; the public send methods call
+// it before any framing
+func (b *DirectUniversalBus) validatePayload(data []byte, typeID uint32) error {
+	if typeID == CanaryTypeID {
+		b.counters.totalErrors.Add(1)
+		return ErrReservedTypeID
+	}
+	if err := b.opts().schemas.validateSend(typeID, data); err != nil {
+		b.counters.totalErrors.Add(1)
+		return err
+	}
+	return nil
+}
+
+// errKeyPending is returned by submitTo when a message with the same key is
+// already pending
+var errKeyPending = errors.New("a message with this key is pending")
+
+// send logs data to the WAL, if any, and submits it to segment, or routes it
+// by segment affinity and then type ID when segment is negative
+//
+// Schemas are not checked here; data may already be framed.
+func (b *DirectUniversalBus) send(data []byte, typeID uint32, segment int) error {
+	_, err := b.sendKeyed(data, typeID, segment, nil, false)
+	return err
+}
+
+// requeue sends a message that was already sent once back onto the bus
+//
+// It skips content deduplication, which would otherwise drop the copy as a
+// duplicate of the original.
+func (b *DirectUniversalBus) requeue(data []byte, typeID uint32) error {
+	_, err := b.sendKeyed(data, typeID, -1, nil, true)
+	return err
+}
+
+// sendKeyed is send that, given a key, submits data only if no message with
+// that key is pending, reporting whether data was submitted
+//
+// Keyed messages are routed by type ID, whatever the segment. A requeue
+// bypasses content deduplication.
+func (b *DirectUniversalBus) sendKeyed(data []byte, typeID uint32, segment int, key []byte, requeue bool) (sent bool, err error) {
+	if events := b.events.Load(); events != nil {
+		defer func() { events.record(EventSend, typeID, len(data), err) }()
+	}
+
+	if limiter := b.sendLimiter.Load(); limiter != nil && !limiter.Allow() {
+		return false, ErrSendThrottled
+	}
+
+	if segment < 0 {
+		segment = b.affinity.lookup(typeID)
+	}
+
+	var digest [sha256.Size]byte
+	dedup := b.contentDedup != nil && !requeue
+	if dedup {
+		digest = contentDigest(data, typeID)
+		if b.contentDedup.seen(digest) {
+			b.counters.totalDuplicates.Add(1)
+			return false, nil
+		}
+	}
+
+	payload := data
+	stream := b.ordered.stream(typeID)
+	if stream != nil && len(data) > 0 {
+		stream.sendMu.Lock()
+		defer stream.sendMu.Unlock()
+		payload = stream.tag(data)
+	}
+
+	gen := b.handleGeneration()
+	err = b.walSubmit(payload, typeID, segment, key)
+	if handleInvalid(err) && b.opts().reconnect.enabled() {
+		start := time.Now()
+		err = b.reconnectAndRetry(err, gen, func() error {
+			return b.walSubmit(payload, typeID, segment, key)
+		})
+		b.opts().backpressure.observe(typeID, time.Since(start))
+	}
+	if errors.Is(err, errKeyPending) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if stream != nil {
+		stream.nextSend.Add(1)
+	}
+	if sizes := b.opts().sizes; sizes != nil {
+		sizes.Record(len(data))
+	}
+	if rates := b.rates.Load(); rates != nil {
+		rates.send.mark()
+	}
+	b.typeFreq.record(typeID)
+	if dedup {
+		b.contentDedup.add(digest)
+	}
+	b.retention.add(data, typeID)
+	return true, nil
+}
+
+// walSubmit submits data, logging it ahead to the WAL if one is configured
+func (b *DirectUniversalBus) walSubmit(data []byte, typeID uint32, segment int, key []byte) error {
+	if b.wal == nil {
+		return b.submitTo(data, typeID, segment, key)
+	}
+
+	// Log ahead of the submit and balance the entry if the bus refuses it
+	if err := b.wal.appendEntry(typeID, data); err != nil {
+		b.counters.totalErrors.Add(1)
+		return err
+	}
+	if err := b.submitTo(data, typeID, segment, key); err != nil {
+		_ = b.wal.appendCommit(typeID, data)
+		return err
+	}
+	return nil
+}
+
+// submit copies data into C memory and enqueues it on its type ID's segment
+func (b *DirectUniversalBus) submit(data []byte, typeID uint32) error {
+	return b.submitTo(data, typeID, -1, nil)
+}
+
+// submitTo enqueues data on segment, or routes
+// it by type ID when segment is negative
+//
+// With a key, data is routed by type ID and enqueued only if no message
+// with the key is pending, returning errKeyPending otherwise.
+func (b *DirectUniversalBus) submitTo(data []byte, typeID uint32, segment int, key []byte) error {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
 	if b.handle == nil {
+		b.counters.totalErrors.Add(1)
 		return errors.New("bus is closed")
 	}
+	if b.closing {
+		b.counters.totalErrors.Add(1)
+		return ErrBusClosing
+	}
 
 	if len(data) == 0 {
+		b.counters.totalErrors.Add(1)
 		return errors.New("data cannot be empty")
 	}
 
-	// Create C data pointer
-	cData := C.malloc(C.size_t(len(data)))
-	if cData == nil {
-		return errors.New("memory allocation failed")
+	var err error
+	if key != nil {
+		segment = -1
+		var sent bool
+		sent, err = submitHandleIfAbsent(b.handle, data, typeID, key)
+		if !sent && err == nil {
+			err = errKeyPending
+		}
+	} else {
+		err = submitHandle(b.handle, data, typeID, segment)
 	}
-	defer C.free(cData)
-
-	// Copy Go data to C memory
-	C.memcpy(cData, unsafe.Pointer(&data[0]), C.size_t(len(data)))
-
-	// Create universal data structure
-	udata := C.create_universal_data(cData, C.size_t(len(data)), C.uint32_t(typeID), C.LANG_GO)
-	if udata == nil {
-		return errors.New("failed to create universal data")
+	runtime.KeepAlive(b)
+	if errors.Is(err, errKeyPending) {
+		return err
+	}
+	if errors.Is(err, ErrSubmitFailed) {
+		b.counters.totalDropped.Add(1)
+		return err
+	}
+	if err != nil {
+		b.counters.totalErrors.Add(1)
+		return err
 	}
-	defer C.free_universal_data(udata)
 
-	// Submit data
-	if !bool(C.umsbb_submit_direct(b.handle, udata)) {
-		return errors.New("failed to submit data")
+	if typeID == CanaryTypeID {
+		b.sent.notify()
+		return nil
 	}
 
+	b.counters.totalSent.Add(1)
+	b.counters.bytesSent.Add(int64(len(data)))
+	b.sent.notify()
+	b.journal(data, typeID, segment)
 	return nil
 }
 
+// SendIfAbsent sends data only if no message with the same key is pending
+//
+// The key lookup and enqueue happen atomically in the C library, and a key is
+// released when its message is drained. A duplicate is reported as
+// sent=false with a nil error, as is a message dropped by
+// WithContentDeduplication. Otherwise data goes through the same steps as
+// Send, including rate limits, ordered delivery, retention and the WAL, but
+// is always routed by type ID.
+//
+// Example:
+//
+//	sent, err := bus.SendIfAbsent(payload, 4, []byte(orderID))
+//	if err == nil && !sent {
+//	    log.Printf("order %s already queued", orderID)
+//	}
+func (b *DirectUniversalBus) SendIfAbsent(data []byte, typeID uint32, key []byte) (bool, error) {
+	if len(key) == 0 {
+		b.counters.totalErrors.Add(1)
+		return false, errors.New("key cannot be empty")
+	}
+
+	_, span := b.opts().tracer.StartSpan(ContextWithTypeID(context.Background(), typeID), "umsbb.SendIfAbsent")
+	err := b.validatePayload(data, typeID)
+	sent := false
+	if err == nil {
+		sent, err = b.sendKeyed(data, typeID, -1, key, false)
+	}
+	endSpan(span, err)
+	return sent, err
+}
+
 // Receive receives data from the bus
 //
 // Returns:
@@ -280,30 +518,287 @@ func (b *DirectUniversalBus) Send(data []byte, typeID uint32) error {
 //	    fmt.Printf("Received: %s\n", string(data))
 //	}
 func (b *DirectUniversalBus) Receive() ([]byte, error) {
+	msg, err := b.ReceiveData()
+	if err != nil || msg == nil {
+		return nil, err
+	}
+	return msg.Data, nil
+}
+
+// ReceiveData receives data from the bus together with its type ID
+//
+// Returns:
+//   - data: Received message, or nil if nothing available
+//   - error: Error if any
+//
+// Example:
+//
+//	msg, err := bus.ReceiveData()
+//	if err == nil && msg != nil {
+//	    fmt.Printf("Type %d: %s\n", msg.TypeID, string(msg.Data))
+//	}
+func (b *DirectUniversalBus) ReceiveData() (*UniversalData, error) {
+	if b.fanout != nil {
+		return nil, ErrFanoutEnabled
+	}
+
+	_, span := b.opts().tracer.StartSpan(context.Background(), "umsbb.Receive")
+	msg, err := b.drainData()
+	endSpan(span, err)
+	return msg, err
+}
+
+// drainData takes the next message out of the C bus
+func (b *DirectUniversalBus) drainData() (*UniversalData, error) {
+	return b.drainFrom(-1)
+}
+
+// drainFrom takes the next message out of one segment, or out of any
+// segment when segment is negative, holding back out-of-order messages of
+// ordered type IDs
+func (b *DirectUniversalBus) drainFrom(segment int) (*UniversalData, error) {
+	msg, _, err := b.drainInOrder(segment)
+	return msg, err
+}
+
+// drainInOrder is drainFrom that also returns the message's delivery order
+// on a bus with WithOrderedDelivery, or 0 otherwise
+func (b *DirectUniversalBus) drainInOrder(segment int) (msg *UniversalData, order uint64, err error) {
+	if events := b.events.Load(); events != nil {
+		defer func() {
+			if msg != nil {
+				events.record(EventReceive, msg.TypeID, len(msg.Data), nil)
+			} else if err != nil {
+				events.record(EventReceive, 0, 0, err)
+			}
+		}()
+	}
+
+	if b.ordered == nil {
+		msg, err = b.drainSkippingCanaries(segment)
+		b.commitDrained(msg)
+		return msg, 0, err
+	}
+	msg, order, err = b.ordered.drain(segment, func() (*UniversalData, error) {
+		return b.drainSkippingCanaries(segment)
+	})
+	b.commitDrained(msg)
+	b.ordered.strip(msg)
+	return msg, order, err
+}
+
+// drainSkippingCanaries drains the next message that is not a canary
+func (b *DirectUniversalBus) drainSkippingCanaries(segment int) (*UniversalData, error) {
+	for {
+		msg, err := b.drainOne(segment)
+		if err != nil || msg == nil || !b.interceptCanary(msg) {
+			return msg, err
+		}
+	}
+}
+
+// commitDrained balances the WAL entry of a delivered message, as drained
+//
+// Ordered messages are committed when they are delivered rather than when
+// they are held back, so a held message is still recoverable.
+func (b *DirectUniversalBus) commitDrained(msg *UniversalData) {
+	if msg == nil {
+		return
+	}
+	if err := b.wal.appendCommit(msg.TypeID, msg.Data); err != nil {
+		fmt.Printf("[Go Direct] WAL commit failed: %v\n", err)
+	}
+}
+
+// drainOne takes the next message out of the C bus
+func (b *DirectUniversalBus) drainOne(segment int) (*UniversalData, error) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
 	if b.handle == nil {
+		b.counters.totalErrors.Add(1)
 		return nil, errors.New("bus is closed")
 	}
 
-	udataPtr := C.umsbb_drain_direct(b.handle, C.LANG_GO)
-	if udataPtr == nil {
+	msg := drainHandle(b.handle, segment)
+	runtime.KeepAlive(b)
+	if msg == nil {
 		return nil, nil // No data available
 	}
-	defer C.free_universal_data(udataPtr)
+	if msg.TypeID == CanaryTypeID {
+		return msg, nil
+	}
+
+	b.counters.totalReceived.Add(1)
+	b.counters.bytesReceived.Add(int64(len(msg.Data)))
+	if rates := b.rates.Load(); rates != nil {
+		rates.receive.mark()
+	}
+	return msg, nil
+}
+
+// warmSegments faults in every page of every segment and returns the number
+// of pages touched
+func (b *DirectUniversalBus) warmSegments() (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.handle == nil {
+		return 0, errors.New("bus is closed")
+	}
+	pages := warmHandle(b.handle)
+	runtime.KeepAlive(b)
+	if pages < 0 {
+		return 0, ErrWarmUnsupported
+	}
+	return pages, nil
+}
+
+// scanOne copies the next message of typeID at or after cursor in segment
+// without draining it, returning nil when none remain
+//
+// The scan reads capsules in place, and a concurrent drain frees the payload
+// of the capsule it takes, so scanOne holds b.mu exclusively to keep drains
+// out while it copies.
+func (b *DirectUniversalBus) scanOne(segment uint32, cursor *uint64, typeID uint32) (*UniversalData, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.handle == nil {
+		return nil, errors.New("bus is closed")
+	}
+
+	msg := scanHandle(b.handle, segment, cursor, typeID)
+	runtime.KeepAlive(b)
+	return msg, nil
+}
+
+// activeSegmentCount returns the number of segments the C library created
+func (b *DirectUniversalBus) activeSegmentCount() (uint32, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
 
-	// Extract data from universal data structure
-	udata := *udataPtr
-	if udata.data == nil || udata.size == 0 {
-		return nil, nil
+	if b.handle == nil {
+		return 0, errors.New("bus is closed")
 	}
+	segments := handleSegmentCount(b.handle)
+	runtime.KeepAlive(b)
+	return segments, nil
+}
 
-	// Copy C data to Go slice
-	result := make([]byte, udata.size)
-	C.memcpy(unsafe.Pointer(&result[0]), udata.data, udata.size)
+// recreateHandle replaces the C handle with a fresh one of the same geometry
+// and advances the handle generation
+//
+// The old handle is only destroyed once the new one exists, so a failed
+// recreation leaves the bus as it was. Callers must hold b.mu for writing.
+func (b *DirectUniversalBus) recreateHandle() error {
+	handle, err := createHandle("reconnect", b.bufferSize, b.segmentCount, b.opts().languagePriority)
+	if err != nil {
+		return err
+	}
+	if b.handle != nil {
+		destroyHandle(b.handle)
+		runtime.KeepAlive(b)
+	}
+	b.handle = handle
+	b.generation++
+	return nil
+}
+
+// handleGeneration returns how many times the handle has been recreated
+func (b *DirectUniversalBus) handleGeneration() uint64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.generation
+}
 
-	return result, nil
+// ErrSegmentCountTooLarge is returned by NewDirectUniversalBus when the
+// segment count exceeds MaxSupportedSegments
+var ErrSegmentCountTooLarge = errors.New("segment count exceeds C library limit")
+
+// maxSupportedSegments queries the C library's segment limit
+func maxSupportedSegments() (uint32, error) {
+	limit := librarySegmentLimit()
+	if limit == 0 {
+		return 0, errors.New("C library does not report a segment limit")
+	}
+	return limit, nil
+}
+
+// MaxSupportedSegments returns the largest segment count the C library supports
+//
+// Older libraries that do not export umsbb_max_segment_count return an error,
+// and NewDirectUniversalBus then does not check the segment count.
+//
+// Example:
+//
+//	if limit, err := bus.MaxSupportedSegments(); err == nil {
+//	    fmt.Printf("up to %d segments\n", limit)
+//	}
+func (b *DirectUniversalBus) MaxSupportedSegments() (uint32, error) {
+	return maxSupportedSegments()
+}
+
+// ErrCompactUnsupported is returned by Compact when the C library cannot
+// resize segments
+var ErrCompactUnsupported = errors.New("C library does not support segment compaction")
+
+// Compact reallocates every empty segment with segmentCapacity bytes and
+// returns how many segments it resized
+//
+// Segments still holding messages, and segments the C library cannot
+// reallocate, keep their size; compacting again later picks them up.
+// Passing the bus's original buffer size restores full capacity.
+func (b *DirectUniversalBus) Compact(segmentCapacity uint64) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.handle == nil {
+		return 0, errors.New("bus is closed")
+	}
+	resized := compactHandle(b.handle, segmentCapacity)
+	runtime.KeepAlive(b)
+	switch resized {
+	case -2:
+		return 0, ErrCompactUnsupported
+	case -1:
+		return 0, fmt.Errorf("cannot compact segments to %d bytes", segmentCapacity)
+	}
+	return resized, nil
+}
+
+// Size returns the approximate number of messages queued across all segments
+//
+// The count comes from the C library when it exports umsbb_message_count,
+// and otherwise from this bus's sent and received counters. Either way it is
+// an estimate: messages submitted or drained concurrently, or through other
+// language bindings sharing the handle, may not be reflected yet, and the two
+// sources can disagree. Messages held back by WithOrderedDelivery are
+// counted too.
+//
+// Example:
+//
+//	if n, err := bus.Size(); err == nil && n > 10000 {
+//	    log.Printf("bus backlog: %d messages", n)
+//	}
+func (b *DirectUniversalBus) Size() (int, error) {
+	held := b.ordered.heldCount() // before b.mu; drains take it under the ordered lock
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.handle == nil {
+		return 0, errors.New("bus is closed")
+	}
+
+	count := handleMessageCount(b.handle)
+	runtime.KeepAlive(b)
+	if count >= 0 {
+		return int(count) + held, nil
+	}
+
+	stats := b.counters.snapshot()
+	return int(max(stats.TotalSent-stats.TotalReceived, 0)) + held, nil
 }
 
 // SendAndReceive sends data and waits for a response
@@ -349,25 +844,16 @@ func (b *DirectUniversalBus) SendAndReceive(data []byte, typeID uint32, timeoutM
 }
 
 // GetGPUInfo returns GPU capabilities information
+//
+// Builds with the nogpu or nocgo tag always report an unavailable GPU.
 func (b *DirectUniversalBus) GetGPUInfo() GPUInfo {
-	caps := C.get_gpu_capabilities()
-	available := bool(C.gpu_available())
-
-	return GPUInfo{
-		Available:         available,
-		HasCUDA:           bool(caps.has_cuda),
-		HasOpenCL:         bool(caps.has_opencl),
-		HasCompute:        bool(caps.has_compute),
-		MemorySize:        uint64(caps.memory_size),
-		ComputeCapability: int(caps.compute_capability),
-		MaxThreads:        uint64(caps.max_threads),
-	}
+	return gpuInfo()
 }
 
 // GetScalingStatus returns current auto-scaling status
 func (b *DirectUniversalBus) GetScalingStatus() ScalingStatus {
-	optimalProducers := uint32(C.get_optimal_producer_count())
-	optimalConsumers := uint32(C.get_optimal_consumer_count())
+	optimalProducers := optimalProducerCount()
+	optimalConsumers := optimalConsumerCount()
 	gpuInfo := b.GetGPUInfo()
 
 	return ScalingStatus{
@@ -379,21 +865,38 @@ func (b *DirectUniversalBus) GetScalingStatus() ScalingStatus {
 
 // TriggerScaleEvaluation triggers manual scale evaluation
 func (b *DirectUniversalBus) TriggerScaleEvaluation() {
-	C.trigger_scale_evaluation()
+	triggerScaleEvaluation()
 }
 
 // Close closes the bus and cleanup resources
+//
+// The C handle is destroyed exactly once, however many times Close is
+// called and whether it races the finalizer; every call returns the result
+// of the first.
 func (b *DirectUniversalBus) Close() error {
+	b.closeOnce.Do(func() {
+		b.StopCanary() // before teardown, which blocks the canary's Send
+		b.StopThroughputMeasurement()
+		b.closeErr = b.teardown()
+	})
+	return b.closeErr
+}
+
+// teardown destroys the C handle and closes the WAL and segment journal
+func (b *DirectUniversalBus) teardown() error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	runtime.SetFinalizer(b, nil)
 	if b.handle != nil {
-		C.umsbb_destroy_direct(b.handle)
+		destroyHandle(b.handle)
+		runtime.KeepAlive(b)
 		b.handle = nil
-		runtime.SetFinalizer(b, nil)
-		fmt.Println("[Go Direct] Bus closed")
 	}
-	return nil
+	fmt.Println("[Go Direct] Bus closed")
+	err := errors.Join(b.wal.close(), b.segJournal.close())
+	b.events.Load().record(EventClose, 0, 0, err)
+	return err
 }
 
 // AutoScalingBus provides auto-scaling producer-consumer system
@@ -403,21 +906,46 @@ type AutoScalingBus struct {
 	consumers []chan struct{}
 	shutdown  int32
 	wg        sync.WaitGroup
+	slots     chan struct{} // goroutine semaphore; nil when uncapped
 }
 
 // NewAutoScalingBus creates a new auto-scaling bus
-func NewAutoScalingBus(bufferSize uint64, segmentCount uint32, gpuPreferred bool) (*AutoScalingBus, error) {
-	bus, err := NewDirectUniversalBus(bufferSize, segmentCount, gpuPreferred, true)
+func NewAutoScalingBus(bufferSize uint64, segmentCount uint32, gpuPreferred bool, opts ...BusOption) (*AutoScalingBus, error) {
+	bus, err := NewDirectUniversalBus(bufferSize, segmentCount, gpuPreferred, true, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &AutoScalingBus{
+	ab := &AutoScalingBus{
 		bus:       bus,
 		producers: make([]chan struct{}, 0),
 		consumers: make([]chan struct{}, 0),
 		shutdown:  0,
-	}, nil
+	}
+	if n := bus.opts().maxGoroutines; n > 0 {
+		ab.slots = make(chan struct{}, n)
+	}
+	return ab, nil
+}
+
+// acquireSlot reserves room for one worker goroutine without blocking
+func (ab *AutoScalingBus) acquireSlot() bool {
+	if ab.slots == nil {
+		return true
+	}
+	select {
+	case ab.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// releaseSlot frees the room reserved by acquireSlot
+func (ab *AutoScalingBus) releaseSlot() {
+	if ab.slots != nil {
+		<-ab.slots
+	}
 }
 
 // StartAutoProducers starts auto-scaling producers
@@ -436,13 +964,22 @@ func (ab *AutoScalingBus) StartAutoProducers(producerFunc func(uint32) []byte, c
 		count = ab.bus.GetScalingStatus().OptimalProducers
 	}
 
+	started := uint32(0)
 	for i := uint32(0); i < count; i++ {
+		if !ab.acquireSlot() {
+			fmt.Printf("[Go AutoScale] Warning: goroutine cap %d reached, started %d of %d producers\n",
+				cap(ab.slots), started, count)
+			break
+		}
+		started++
+
 		stopCh := make(chan struct{})
 		ab.producers = append(ab.producers, stopCh)
 
 		ab.wg.Add(1)
 		go func(workerID uint32, stop <-chan struct{}) {
 			defer ab.wg.Done()
+			defer ab.releaseSlot()
 			
 			ticker := time.NewTicker(100 * time.Microsecond)
 			defer ticker.Stop()
@@ -465,7 +1002,7 @@ func (ab *AutoScalingBus) StartAutoProducers(producerFunc func(uint32) []byte, c
 		}(i, stopCh)
 	}
 
-	fmt.Printf("Started %d auto-scaling producers\n", count)
+	fmt.Printf("Started %d auto-scaling producers\n", started)
 }
 
 // StartAutoConsumers starts auto-scaling consumers
@@ -474,23 +1011,50 @@ func (ab *AutoScalingBus) StartAutoProducers(producerFunc func(uint32) []byte, c
 //   - consumerFunc: Function that processes data
 //   - count: Number of consumers (0 = auto-determine)
 //
+// The consumers busy-poll; use StartConsumers with the Park idle policy to
+// stop them using CPU while the bus is empty.
+//
 // Example:
 //
 //	bus.StartAutoConsumers(func(data []byte, workerID uint32) {
 //	    fmt.Printf("Consumer %d received: %s\n", workerID, string(data))
 //	}, 0)
 func (ab *AutoScalingBus) StartAutoConsumers(consumerFunc func([]byte, uint32), count uint32) {
+	ab.StartConsumers(consumerFunc, WorkerConfig{Count: count})
+}
+
+// StartConsumers starts consumers configured by cfg
+//
+// Example:
+//
+//	bus.StartConsumers(handle, umsbb.WorkerConfig{Count: 4, IdlePolicy: umsbb.Park})
+func (ab *AutoScalingBus) StartConsumers(consumerFunc func([]byte, uint32), cfg WorkerConfig) {
+	count := cfg.Count
 	if count == 0 {
 		count = ab.bus.GetScalingStatus().OptimalConsumers
 	}
 
+	started := uint32(0)
 	for i := uint32(0); i < count; i++ {
+		if !ab.acquireSlot() {
+			fmt.Printf("[Go AutoScale] Warning: goroutine cap %d reached, started %d of %d consumers\n",
+				cap(ab.slots), started, count)
+			break
+		}
+		started++
+
 		stopCh := make(chan struct{})
 		ab.consumers = append(ab.consumers, stopCh)
 
 		ab.wg.Add(1)
-		go func(workerID uint32, stop <-chan struct{}) {
+		if cfg.IdlePolicy == Park {
+			go ab.parkingConsumer(consumerFunc, i, ab.bus.AddConsumer())
+			continue
+		}
+		go func(workerID uint32, stop <-chan struct{}, consumer *Consumer) {
 			defer ab.wg.Done()
+			defer ab.releaseSlot()
+			defer consumer.Close()
 			
 			ticker := time.NewTicker(100 * time.Microsecond)
 			defer ticker.Stop()
@@ -504,21 +1068,40 @@ func (ab *AutoScalingBus) StartAutoConsumers(consumerFunc func([]byte, uint32),
 						return
 					}
 
-					data, err := ab.bus.Receive()
+					data, err := consumer.Receive()
 					if err == nil && data != nil {
 						consumerFunc(data, workerID)
 					}
 				}
 			}
-		}(i, stopCh)
+		}(i, stopCh, ab.bus.AddConsumer())
 	}
 
-	fmt.Printf("Started %d auto-scaling consumers\n", count)
+	fmt.Printf("Started %d auto-scaling consumers\n", started)
+}
+
+// parkingConsumer receives until the bus is empty, then parks until the next send
+func (ab *AutoScalingBus) parkingConsumer(consumerFunc func([]byte, uint32), workerID uint32, consumer *Consumer) {
+	defer ab.wg.Done()
+	defer ab.releaseSlot()
+	defer consumer.Close()
+
+	stopped := func() bool { return atomic.LoadInt32(&ab.shutdown) != 0 }
+	for !stopped() {
+		seq := ab.bus.sent.seq.Load()
+		data, err := consumer.Receive()
+		if err == nil && data != nil {
+			consumerFunc(data, workerID)
+			continue
+		}
+		ab.bus.sent.wait(seq, stopped)
+	}
 }
 
 // Stop stops all producers and consumers
 func (ab *AutoScalingBus) Stop() {
 	atomic.StoreInt32(&ab.shutdown, 1)
+	ab.bus.sent.wake()
 
 	// Stop all producers
 	for _, stopCh := range ab.producers {
@@ -0,0 +1,259 @@
+// Package umsbbtest provides helpers for exercising a real bus in
+// table-driven tests
+//
+// It lives apart from package umsbb so that programs importing the bus do
+// not link the testing package or register the -stub flag.
+package umsbbtest
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+)
+
+// Test bus geometry: small, single segment and no GPU so tests are deterministic
+const (
+	testBusBufferSize   = 64 * 1024
+	testBusSegmentCount = 1
+	testReceiveTimeout  = time.Second
+	testStubCapacity    = 1024
+	testPollInterval    = 100 * time.Microsecond
+)
+
+// GoroutineLeakTolerance is how many extra goroutines RequireNoGoroutineLeak
+// and RunTestMain accept, for runtime and testing package goroutines that
+// start lazily
+var GoroutineLeakTolerance = 2
+
+// goroutineSettleTimeout is how long the leak check waits for goroutines
+// that are already exiting
+const goroutineSettleTimeout = time.Second
+
+// testStubMode is set by RunTestMain when tests run against ChannelBus stubs
+var testStubMode atomic.Bool
+
+// RunTestMain probes the C library and runs the tests, returning the exit code
+//
+// A run whose tests pass but leave goroutines running past
+// GoroutineLeakTolerance fails with their stacks; see RequireNoGoroutineLeak
+// for checking a single test.
+//
+// If the probe cannot create a bus, or the -stub flag is given, tests run in
+// stub mode: NewTestBusOrStub and RunTableTest use a loopback ChannelBus,
+// and NewTestBus and RequireLibrary skip the test. A shared library that is
+// missing entirely stops the process in the dynamic loader before TestMain
+// runs; link against the static library to get stub mode there too.
+//
+// Example:
+//
+//	func TestMain(m *testing.M) {
+//	    os.Exit(umsbbtest.RunTestMain(m))
+//	}
+//
+//	// go test ./... -args -stub
+func RunTestMain(m *testing.M) int {
+	stub := flag.Bool("stub", false, "run tests against ChannelBus stubs instead of the C library")
+	flag.Parse()
+
+	if !*stub && !probeLibrary() {
+		fmt.Println("[Go Test] C library unavailable; running against ChannelBus stubs")
+		*stub = true
+	}
+	testStubMode.Store(*stub)
+
+	before := runtime.NumGoroutine()
+	code := m.Run()
+	if code == 0 {
+		if after, leaked := goroutinesLeaked(before); leaked {
+			fmt.Printf("[Go Test] goroutine leak: %d goroutines before tests, %d after\n%s\n",
+				before, after, goroutineStacks())
+			code = 1
+		}
+	}
+	return code
+}
+
+// RequireNoGoroutineLeak fails the test if fn leaves more than
+// GoroutineLeakTolerance goroutines running
+//
+// Goroutines get a moment to exit before the check fails, so buses stopped
+// at the end of fn are not reported. Tests that run in parallel with
+// t.Parallel skew the count and should not use it.
+//
+// Example:
+//
+//	umsbbtest.RequireNoGoroutineLeak(t, func() {
+//	    ab, _ := umsbb.NewAutoScalingBus(64*1024, 1, false)
+//	    ab.StartAutoConsumers(handle, 2)
+//	    ab.Stop() // without this the test fails
+//	})
+func RequireNoGoroutineLeak(t testing.TB, fn func()) {
+	t.Helper()
+
+	before := runtime.NumGoroutine()
+	fn()
+	if after, leaked := goroutinesLeaked(before); leaked {
+		t.Errorf("goroutine leak: %d goroutines before, %d after\n%s", before, after, goroutineStacks())
+	}
+}
+
+// goroutinesLeaked waits up to goroutineSettleTimeout for the goroutine
+// count to fall within GoroutineLeakTolerance of before
+func goroutinesLeaked(before int) (int, bool) {
+	deadline := time.Now().Add(goroutineSettleTimeout)
+	for {
+		after := runtime.NumGoroutine()
+		if after <= before+GoroutineLeakTolerance {
+			return after, false
+		}
+		if time.Now().After(deadline) {
+			return after, true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// goroutineStacks returns the stacks of all goroutines for leak reports
+func goroutineStacks() string {
+	buf := make([]byte, 64*1024)
+	return string(buf[:runtime.Stack(buf, true)])
+}
+
+// probeLibrary reports whether the C library can create a bus
+func probeLibrary() bool {
+	bus, err := umsbb.NewDirectUniversalBus(testBusBufferSize, testBusSegmentCount, false, false)
+	if err != nil {
+		return false
+	}
+	_ = bus.Close()
+	return true
+}
+
+// RequireLibrary skips the test when running in stub mode
+func RequireLibrary(t testing.TB) {
+	t.Helper()
+	if testStubMode.Load() {
+		t.Skip("C library unavailable (stub mode)")
+	}
+}
+
+// NewTestBusOrStub returns a real test bus, or a loopback ChannelBus in stub
+// mode, and closes it when the test finishes
+//
+// Tests written against the Bus interface run in both modes.
+func NewTestBusOrStub(t testing.TB) umsbb.Bus {
+	t.Helper()
+	if !testStubMode.Load() {
+		return NewTestBus(t)
+	}
+
+	bus := umsbb.NewLoopbackChannelBus(testStubCapacity)
+	t.Cleanup(func() { _ = bus.Close() })
+	return bus
+}
+
+// TestCase is one send/receive round trip checked by RunTableTest
+type TestCase struct {
+	Name           string
+	Input          []byte
+	TypeID         uint32
+	ExpectedOutput []byte
+	WantErr        bool
+}
+
+// NewTestBus creates a small bus without GPU or auto-scaling and closes it
+// when the test finishes
+//
+// In stub mode the test is skipped.
+//
+// Example:
+//
+//	func TestPing(t *testing.T) {
+//	    bus := umsbbtest.NewTestBus(t)
+//	    _ = bus.Send([]byte("ping"), 1)
+//	}
+func NewTestBus(t testing.TB, opts ...umsbb.BusOption) *umsbb.DirectUniversalBus {
+	t.Helper()
+	RequireLibrary(t)
+
+	bus, err := umsbb.NewDirectUniversalBus(testBusBufferSize, testBusSegmentCount, false, false, opts...)
+	if err != nil {
+		t.Fatalf("failed to create test bus: %v", err)
+	}
+	t.Cleanup(func() { _ = bus.Close() })
+	return bus
+}
+
+// RunTableTest sends each case's input on a fresh test bus and checks what comes back
+//
+// Cases with WantErr expect Send to fail; all others expect the received
+// payload and type ID to match. In stub mode the cases run on a ChannelBus.
+//
+// Example:
+//
+//	umsbbtest.RunTableTest(t, []umsbbtest.TestCase{
+//	    {Name: "text", Input: []byte("hello"), TypeID: 1, ExpectedOutput: []byte("hello")},
+//	    {Name: "empty", Input: nil, TypeID: 1, WantErr: true},
+//	})
+func RunTableTest(t *testing.T, cases []TestCase) {
+	t.Helper()
+
+	for i, tc := range cases {
+		name := tc.Name
+		if name == "" {
+			name = fmt.Sprintf("case_%d", i)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			bus := NewTestBusOrStub(t)
+
+			err := bus.Send(tc.Input, tc.TypeID)
+			if tc.WantErr {
+				if err == nil {
+					t.Fatalf("Send succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), testReceiveTimeout)
+			defer cancel()
+
+			msg, err := receiveData(ctx, bus)
+			if err != nil {
+				t.Fatalf("Receive failed: %v", err)
+			}
+			if msg.TypeID != tc.TypeID {
+				t.Errorf("type ID = %d, want %d", msg.TypeID, tc.TypeID)
+			}
+			if !bytes.Equal(msg.Data, tc.ExpectedOutput) {
+				t.Errorf("payload = %q, want %q", msg.Data, tc.ExpectedOutput)
+			}
+		})
+	}
+}
+
+// receiveData polls bus until a message arrives or ctx is done
+func receiveData(ctx context.Context, bus umsbb.Bus) (*umsbb.UniversalData, error) {
+	for {
+		msg, err := bus.ReceiveData()
+		if err != nil || msg != nil {
+			return msg, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(testPollInterval):
+		}
+	}
+}
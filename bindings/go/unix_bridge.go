@@ -0,0 +1,186 @@
+// Unix domain socket forwarding of bus messages between processes on one host
+
+package umsbb
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// unixFrameHeaderSize is the uint64 type ID, the uint64 payload size and the
+// source language byte
+const unixFrameHeaderSize = 17
+
+// DefaultMaxUnixFrameSize bounds the payload of one packet
+//
+// SOCK_SEQPACKET delivers each message as a single datagram, so the limit is
+// also bounded by the socket buffer size (net.core.wmem_max on Linux).
+const DefaultMaxUnixFrameSize = 1 << 20
+
+// UnixSocketBridge accepts SOCK_SEQPACKET connections on socketPath and sends
+// every message they carry into bus
+//
+// Each packet is one message: a big-endian uint64 type ID, a big-endian
+// uint64 payload size, one source language byte and the payload. The
+// language byte is informational; the bus records the messages as sent from
+// Go. Any stale socket file at socketPath is removed first. UnixSocketBridge
+// blocks until ctx is done, then closes the listener, every connection and
+// the socket file and returns ctx.Err().
+//
+// Example:
+//
+//	go umsbb.UnixSocketBridge(bus, "/run/umsbb/bus.sock", ctx)
+//
+//	// In the producing process:
+//	go umsbb.UnixSocketForward(local, "/run/umsbb/bus.sock", ctx)
+func UnixSocketBridge(bus *DirectUniversalBus, socketPath string, ctx context.Context) error {
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var lc net.ListenConfig
+	ln, err := lc.Listen(ctx, "unixpacket", socketPath)
+	if err != nil {
+		return err
+	}
+
+	return serveConns(ln, ctx, func(conn net.Conn) {
+		if err := forwardUnixPackets(ctx, conn, bus); err != nil && ctx.Err() == nil {
+			fmt.Printf("[Go Unix] connection closed: %v\n", err)
+		}
+	})
+}
+
+// forwardUnixPackets reads packets from conn and sends them into bus until
+// the peer disconnects
+func forwardUnixPackets(ctx context.Context, conn net.Conn, bus *DirectUniversalBus) error {
+	// One spare byte detects packets the kernel truncated to fit the buffer
+	packet := make([]byte, unixFrameHeaderSize+DefaultMaxUnixFrameSize+1)
+	for {
+		n, err := conn.Read(packet)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if n < unixFrameHeaderSize {
+			return fmt.Errorf("short packet of %d bytes", n)
+		}
+
+		typeID := binary.BigEndian.Uint64(packet[0:8])
+		size := binary.BigEndian.Uint64(packet[8:16])
+		if size == 0 || size != uint64(n-unixFrameHeaderSize) || typeID > 0xFFFFFFFF {
+			return fmt.Errorf("invalid packet: type %d, size %d, %d bytes read", typeID, size, n)
+		}
+
+		data := append([]byte(nil), packet[unixFrameHeaderSize:n]...)
+		if err := sendContext(ctx, bus, data, uint32(typeID)); err != nil {
+			return err
+		}
+	}
+}
+
+// UnixSocketForward connects to a UnixSocketBridge at socketPath and
+// forwards every message received from bus to it
+//
+// UnixSocketForward blocks until ctx is done, returning ctx.Err(), or until
+// the connection fails. As with BusProxy.Connect, a message whose write
+// fails is sent back into bus.
+func UnixSocketForward(bus *DirectUniversalBus, socketPath string, ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unixpacket", socketPath)
+	if err != nil {
+		return err
+	}
+	return forwardTo(ctx, conn, bus, writeUnixFrame)
+}
+
+// writeUnixFrame writes msg as one packet
+func writeUnixFrame(conn net.Conn, msg *UniversalData) error {
+	if len(msg.Data) > DefaultMaxUnixFrameSize {
+		return fmt.Errorf("payload of %d bytes exceeds the %d byte packet limit", len(msg.Data), DefaultMaxUnixFrameSize)
+	}
+
+	frame := make([]byte, unixFrameHeaderSize+len(msg.Data))
+	binary.BigEndian.PutUint64(frame[0:8], uint64(msg.TypeID))
+	binary.BigEndian.PutUint64(frame[8:16], uint64(len(msg.Data)))
+	frame[16] = byte(msg.SourceLang)
+	copy(frame[unixFrameHeaderSize:], msg.Data)
+
+	_, err := conn.Write(frame)
+	return err
+}
+
+// BenchmarkBridgeLatency returns the mean time for a message written by a
+// peer to arrive on the bus behind a bridge, over iterations messages
+//
+// With unix set the peer writes to a UnixSocketBridge, otherwise to
+// BusProxy.ListenAndForward on loopback TCP. Zero is returned if the bridge
+// could not be started.
+//
+// Example:
+//
+//	tcp := umsbb.BenchmarkBridgeLatency(10000, false)
+//	unix := umsbb.BenchmarkBridgeLatency(10000, true)
+//	fmt.Printf("tcp=%v unix=%v\n", tcp, unix)
+func BenchmarkBridgeLatency(iterations int, unix bool) time.Duration {
+	bus, err := NewDirectUniversalBus(1024*1024, 4, false, false)
+	if err != nil || iterations <= 0 {
+		return 0
+	}
+	defer bus.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	network, addr := "unixpacket", filepath.Join(os.TempDir(), fmt.Sprintf("umsbb-bench-%d.sock", os.Getpid()))
+	write := writeUnixFrame
+	if unix {
+		go UnixSocketBridge(bus, addr, ctx)
+	} else {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return 0
+		}
+		network, addr = "tcp", ln.Addr().String()
+		ln.Close()
+
+		var proxy BusProxy
+		go proxy.ListenAndForward(addr, bus, ctx)
+		write = func(conn net.Conn, msg *UniversalData) error { return writeProxyFrame(conn, msg) }
+	}
+
+	var conn net.Conn
+	for deadline := time.Now().Add(time.Second); ; {
+		if conn, err = net.Dial(network, addr); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			return 0
+		}
+		time.Sleep(time.Millisecond)
+	}
+	defer conn.Close()
+
+	msg := &UniversalData{Data: []byte("benchmark test message"), TypeID: 1, SourceLang: LangGo}
+	var total time.Duration
+	for i := 0; i < iterations; i++ {
+		start := time.Now()
+		if err := write(conn, msg); err != nil {
+			return 0
+		}
+		if _, err := receiveDataContext(ctx, bus); err != nil {
+			return 0
+		}
+		total += time.Since(start)
+	}
+	return total / time.Duration(iterations)
+}
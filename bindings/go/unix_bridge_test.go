@@ -0,0 +1,58 @@
+package umsbb_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	umsbb "github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go"
+	"github.com/K-dubey09/universal-multi-segmented-bi-buffer-bus/bindings/go/umsbbtest"
+)
+
+func TestUnixSocketForwardReachesBridge(t *testing.T) {
+	// t.TempDir can exceed the 108-byte socket path limit
+	dir, err := os.MkdirTemp("", "umsbb")
+	if err != nil {
+		t.Fatalf("MkdirTemp failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bus.sock")
+
+	remote := umsbbtest.NewTestBus(t)
+	local := umsbbtest.NewTestBus(t)
+
+	umsbbtest.RequireNoGoroutineLeak(t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		bridged := make(chan error, 1)
+		go func() { bridged <- umsbb.UnixSocketBridge(remote, path, ctx) }()
+		dialRetry(t, "unixpacket", path).Close()
+
+		forwarded := make(chan error, 1)
+		go func() { forwarded <- umsbb.UnixSocketForward(local, path, ctx) }()
+
+		if err := local.Send([]byte("over the socket"), 3); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		var msg *umsbb.UniversalData
+		for deadline := time.Now().Add(time.Second); msg == nil && time.Now().Before(deadline); {
+			if msg, err = remote.ReceiveData(); err != nil {
+				t.Fatalf("ReceiveData failed: %v", err)
+			}
+			time.Sleep(time.Millisecond)
+		}
+		if msg == nil || string(msg.Data) != "over the socket" || msg.TypeID != 3 {
+			t.Fatalf("ReceiveData = %v; want the forwarded message", msg)
+		}
+
+		cancel()
+		for _, done := range []chan error{bridged, forwarded} {
+			if err := <-done; err != context.Canceled {
+				t.Fatalf("bridge returned %v, want context.Canceled", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,357 @@
+// Write-ahead log for crash recovery of in-flight messages
+
+package umsbb
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// WAL record kinds
+const (
+	walEntry  byte = 'E'
+	walCommit byte = 'C'
+)
+
+// walHeaderSize is kind, type ID, content hash and payload length
+const walHeaderSize = 1 + 4 + sha256.Size + 4
+
+// walCompactMinSize is the log size below which it is never compacted
+const walCompactMinSize = 4 << 20
+
+// walLog appends entry and commit records and tracks which payloads are
+// currently in the bus
+//
+// An entry is written before a payload is submitted; a commit is written when
+// it is drained, or when the submit fails. Records are matched by content
+// hash, so a payload is outstanding while it has more entries than commits.
+//
+// Entries are synced to disk before the payload is submitted; a commit lost
+// in a crash only means its message may be replayed. Once the log has grown
+// to twice its size after the last compaction, and past walCompactMinSize,
+// it is rewritten with only its outstanding entries.
+type walLog struct {
+	path      string
+	mu        sync.Mutex
+	file      *os.File
+	pending   map[[sha256.Size]byte]int
+	size      int64 // bytes in file
+	compactAt int64 // size that triggers the next compaction
+}
+
+// walRecord is one decoded WAL record
+type walRecord struct {
+	kind   byte
+	typeID uint32
+	hash   [sha256.Size]byte
+	data   []byte
+}
+
+// openWAL opens or creates the log at path for appending
+func openWAL(path string) (*walLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WAL: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to open WAL: %w", err)
+	}
+	return &walLog{
+		path:      path,
+		file:      file,
+		pending:   make(map[[sha256.Size]byte]int),
+		size:      info.Size(),
+		compactAt: max(2*info.Size(), walCompactMinSize),
+	}, nil
+}
+
+// appendEntry logs data as submitted
+func (w *walLog) appendEntry(typeID uint32, data []byte) error {
+	if w == nil {
+		return nil
+	}
+	return w.append(walEntry, typeID, sha256.Sum256(data), data, 1)
+}
+
+// appendCommit logs data as drained
+func (w *walLog) appendCommit(typeID uint32, data []byte) error {
+	if w == nil {
+		return nil
+	}
+	return w.append(walCommit, typeID, sha256.Sum256(data), nil, -1)
+}
+
+// append writes one record and adjusts the pending count for hash
+func (w *walLog) append(kind byte, typeID uint32, hash [sha256.Size]byte, data []byte, delta int) error {
+	record := encodeWALRecord(kind, typeID, hash, data)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return errors.New("WAL is closed")
+	}
+	n, err := w.file.Write(record)
+	w.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write WAL record: %w", err)
+	}
+	if kind == walEntry {
+		if err := w.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync WAL: %w", err)
+		}
+	}
+
+	if w.pending[hash] += delta; w.pending[hash] <= 0 {
+		delete(w.pending, hash)
+	}
+
+	if w.size >= w.compactAt {
+		if err := w.compact(); err != nil {
+			log.Printf("[Go WAL] compaction failed: %v", err)
+			w.compactAt = 2 * w.size
+		}
+	}
+	return nil
+}
+
+// compact rewrites the log with only its outstanding entries, replacing the
+// file atomically
+//
+// Callers must hold w.mu.
+func (w *walLog) compact() error {
+	records, err := readWAL(w.path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), filepath.Base(w.path)+".compact-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once renamed
+
+	out := bufio.NewWriter(tmp)
+	for _, rec := range outstandingWAL(records) {
+		if rec != nil {
+			_, _ = out.Write(encodeWALRecord(rec.kind, rec.typeID, rec.hash, rec.data))
+		}
+	}
+	if err := errors.Join(out.Flush(), tmp.Sync(), tmp.Close()); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), w.path); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	_ = w.file.Close()
+	w.file = file
+	w.size = info.Size()
+	w.compactAt = max(2*w.size, walCompactMinSize)
+	return nil
+}
+
+// encodeWALRecord returns the on-disk form of one record
+func encodeWALRecord(kind byte, typeID uint32, hash [sha256.Size]byte, data []byte) []byte {
+	record := make([]byte, walHeaderSize, walHeaderSize+len(data))
+	record[0] = kind
+	binary.LittleEndian.PutUint32(record[1:5], typeID)
+	copy(record[5:5+sha256.Size], hash[:])
+	binary.LittleEndian.PutUint32(record[5+sha256.Size:], uint32(len(data)))
+	return append(record, data...)
+}
+
+// claimPending reports whether a payload with hash is already in the bus,
+// reserving one pending copy so it is not matched twice
+func (w *walLog) claimPending(hash [sha256.Size]byte, claimed map[[sha256.Size]byte]int) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.pending[hash] > claimed[hash] {
+		claimed[hash]++
+		return true
+	}
+	return false
+}
+
+// close closes the log file
+func (w *walLog) close() error {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// readWAL returns every complete record in the log at path
+//
+// A record truncated by a crash mid-write ends the log.
+func readWAL(path string) ([]walRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WAL: %w", err)
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	var records []walRecord
+	header := make([]byte, walHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return records, nil
+			}
+			return nil, fmt.Errorf("failed to read WAL: %w", err)
+		}
+
+		rec := walRecord{
+			kind:   header[0],
+			typeID: binary.LittleEndian.Uint32(header[1:5]),
+		}
+		copy(rec.hash[:], header[5:5+sha256.Size])
+		if rec.kind != walEntry && rec.kind != walCommit {
+			return nil, fmt.Errorf("corrupt WAL record kind %q", rec.kind)
+		}
+
+		rec.data = make([]byte, binary.LittleEndian.Uint32(header[5+sha256.Size:]))
+		if _, err := io.ReadFull(r, rec.data); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return records, nil
+			}
+			return nil, fmt.Errorf("failed to read WAL: %w", err)
+		}
+		records = append(records, rec)
+	}
+}
+
+// outstandingWAL returns the entries of records in log order, with nil in
+// place of each one balanced by a commit
+//
+// Each commit is matched against the oldest uncommitted entry with the same
+// content.
+func outstandingWAL(records []walRecord) []*walRecord {
+	var outstanding []*walRecord
+	byHash := make(map[[sha256.Size]byte][]int)
+	for i := range records {
+		rec := &records[i]
+		switch rec.kind {
+		case walEntry:
+			byHash[rec.hash] = append(byHash[rec.hash], len(outstanding))
+			outstanding = append(outstanding, rec)
+		case walCommit:
+			if idx := byHash[rec.hash]; len(idx) > 0 {
+				outstanding[idx[0]] = nil
+				byHash[rec.hash] = idx[1:]
+			}
+		}
+	}
+	return outstanding
+}
+
+// RecoverWAL resubmits every logged message that was sent but never drained
+//
+// Entries are replayed in their original order. Replay is idempotent: a
+// message whose content is already in the bus, for example because
+// RecoverWAL ran before, is skipped. Messages of WithOrderedDelivery type IDs
+// are given new sequence numbers after those already sent, since the logged
+// ones belong to the process that wrote them.
+//
+// Returns:
+//   - replayed: Number of messages resubmitted
+//   - error: Error if the log cannot be read, a submit fails or ctx is done
+//
+// Example:
+//
+//	bus, err := umsbb.NewDirectUniversalBus(1024*1024, 0, false, false, umsbb.WithWAL("bus.wal"))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	replayed, err := bus.RecoverWAL(ctx)
+//	log.Printf("replayed %d messages", replayed)
+func (b *DirectUniversalBus) RecoverWAL(ctx context.Context) (int, error) {
+	if b.wal == nil {
+		return 0, errors.New("bus was created without WithWAL")
+	}
+
+	records, err := readWAL(b.wal.path)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	claimed := make(map[[sha256.Size]byte]int)
+	for _, rec := range outstandingWAL(records) {
+		if rec == nil {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return replayed, err
+		}
+		if b.wal.claimPending(rec.hash, claimed) {
+			continue
+		}
+
+		if err := b.replayWALRecord(rec, claimed); err != nil {
+			return replayed, fmt.Errorf("failed to replay WAL entry: %w", err)
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// replayWALRecord resubmits one outstanding entry
+func (b *DirectUniversalBus) replayWALRecord(rec *walRecord, claimed map[[sha256.Size]byte]int) error {
+	stream := b.ordered.stream(rec.typeID)
+	if stream == nil || len(rec.data) <= orderedSeqSize {
+		// The original entry stays in the log and is balanced by the commit
+		// written when the replayed copy is drained
+		if err := b.submit(rec.data, rec.typeID); err != nil {
+			return err
+		}
+		b.wal.mu.Lock()
+		b.wal.pending[rec.hash]++
+		claimed[rec.hash]++
+		b.wal.mu.Unlock()
+		return nil
+	}
+
+	// Re-sequenced payloads differ from the logged one, so they are logged
+	// afresh and the original entry is balanced now
+	stream.sendMu.Lock()
+	defer stream.sendMu.Unlock()
+
+	if err := b.walSubmit(stream.tag(rec.data[orderedSeqSize:]), rec.typeID, -1, nil); err != nil {
+		return err
+	}
+	stream.nextSend.Add(1)
+	return b.wal.appendCommit(rec.typeID, rec.data)
+}
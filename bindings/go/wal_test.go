@@ -0,0 +1,64 @@
+package umsbb
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWALCompactsBalancedRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bus.wal")
+	w, err := openWAL(path)
+	if err != nil {
+		t.Fatalf("openWAL failed: %v", err)
+	}
+	defer w.close()
+	w.compactAt = 64 * walHeaderSize
+
+	if err := w.appendEntry(1, []byte("kept")); err != nil {
+		t.Fatalf("appendEntry failed: %v", err)
+	}
+	for range 100 {
+		if err := w.appendEntry(2, []byte("drained")); err != nil {
+			t.Fatalf("appendEntry failed: %v", err)
+		}
+		if err := w.appendCommit(2, []byte("drained")); err != nil {
+			t.Fatalf("appendCommit failed: %v", err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	written := int64(201*walHeaderSize + len("kept") + 100*len("drained"))
+	if info.Size() >= written {
+		t.Fatalf("WAL is %d bytes, want it compacted below the %d written", info.Size(), written)
+	}
+	if info.Size() != w.size {
+		t.Fatalf("WAL is %d bytes, tracked size is %d", info.Size(), w.size)
+	}
+
+	records, err := readWAL(path)
+	if err != nil {
+		t.Fatalf("readWAL failed: %v", err)
+	}
+	var outstanding []string
+	for _, rec := range outstandingWAL(records) {
+		if rec != nil {
+			outstanding = append(outstanding, string(rec.data))
+		}
+	}
+	if len(outstanding) != 1 || outstanding[0] != "kept" {
+		t.Fatalf("outstanding entries = %q, want only kept", outstanding)
+	}
+	if w.pending[sha256.Sum256([]byte("kept"))] != 1 {
+		t.Fatalf("pending count for kept = %d, want 1", w.pending[sha256.Sum256([]byte("kept"))])
+	}
+
+	matches, _ := filepath.Glob(path + ".compact-*")
+	if len(matches) != 0 {
+		t.Fatalf("compaction left temporary files %q", matches)
+	}
+}
@@ -0,0 +1,77 @@
+// Gradual throttling of sends ahead of a graceful close
+
+package umsbb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ErrSendThrottled is returned by Send while WarmDown is limiting the send
+// rate; it wraps ErrSubmitFailed, so retrying senders back off and retry
+var ErrSendThrottled = fmt.Errorf("%w: send rate limited by warm-down", ErrSubmitFailed)
+
+// warmDownSteps is how many times WarmDown lowers the send rate limit
+const warmDownSteps = 20
+
+// WarmDown lowers the allowed send rate step by step from its current level
+// to zero over duration, then closes the bus with CloseGraceful
+//
+// The starting rate is the EWMA send rate when EnableRateMeter is on, and
+// otherwise is measured over the first step without a limit. Sends over
+// the limit fail with ErrSendThrottled. If ctx is done first, the bus is
+// closed at once as with CloseGraceful.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//	defer cancel()
+//	if err := bus.WarmDown(ctx, 30*time.Second); err != nil {
+//	    log.Printf("warm-down ended early: %v", err)
+//	}
+func (b *DirectUniversalBus) WarmDown(ctx context.Context, duration time.Duration) error {
+	step := duration / warmDownSteps
+	if step <= 0 {
+		return b.CloseGraceful(ctx)
+	}
+
+	first := 0
+	var rate float64
+	if b.rates.Load() != nil {
+		rate = b.SendRate()
+	} else {
+		sent := b.counters.totalSent.Load()
+		if !sleepContext(ctx, step) {
+			return b.CloseGraceful(ctx)
+		}
+		rate = float64(b.counters.totalSent.Load()-sent) / step.Seconds()
+		first = 1
+	}
+
+	// Allow one step's worth of sends in a burst so the limit does not
+	// reject traffic that merely arrives unevenly
+	limiter := NewLocalLimiter(rate, int(rate*step.Seconds()))
+	b.sendLimiter.Store(limiter)
+	for i := first + 1; i <= warmDownSteps; i++ {
+		if !sleepContext(ctx, step) {
+			break
+		}
+		limiter.SetLimit(rate * float64(warmDownSteps-i) / float64(warmDownSteps-first))
+	}
+	limiter.SetLimit(0)
+	return b.CloseGraceful(ctx)
+}
+
+// sleepContext sleeps for d and reports whether ctx was still live throughout
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
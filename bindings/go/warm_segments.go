@@ -0,0 +1,80 @@
+// Pre-faulting segment memory to cut first-message latency
+
+package umsbb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrWarmUnsupported is returned by WarmSegments when the C library cannot
+// pre-fault segments
+var ErrWarmUnsupported = errors.New("C library does not support segment warming")
+
+// WarmSegments touches every memory page of every segment of bus so the
+// page faults happen now rather than on the first messages
+//
+// Segment memory is demand-paged, so the first write to each page faults.
+// Each page is written with the byte it already holds, so queued messages
+// are not disturbed, but sends and receives wait while WarmSegments runs;
+// call it right after creating the bus.
+//
+// Example:
+//
+//	bus, err := umsbb.NewDirectUniversalBus(16*1024*1024, 4, false, false)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := umsbb.WarmSegments(bus); err != nil {
+//	    log.Printf("segments not warmed: %v", err)
+//	}
+func WarmSegments(bus *DirectUniversalBus) error {
+	pages, err := bus.warmSegments()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[Go Direct] Warmed %d segment pages\n", pages)
+	return nil
+}
+
+// BenchmarkFirstMessageLatency returns the time to send and receive the
+// first message on a freshly created bus, after WarmSegments when warm is
+// set
+//
+// Zero is returned if the bus could not be created or warmed, or the
+// message did not arrive within a second.
+//
+// Example:
+//
+//	cold := umsbb.BenchmarkFirstMessageLatency(false)
+//	warm := umsbb.BenchmarkFirstMessageLatency(true)
+//	fmt.Printf("cold=%v warm=%v\n", cold, warm)
+func BenchmarkFirstMessageLatency(warm bool) time.Duration {
+	bus, err := NewDirectUniversalBus(16*1024*1024, 4, false, false)
+	if err != nil {
+		return 0
+	}
+	defer bus.Close()
+
+	if warm {
+		if err := WarmSegments(bus); err != nil {
+			return 0
+		}
+	}
+
+	start := time.Now()
+	if err := bus.Send([]byte("benchmark test message"), 1); err != nil {
+		return 0
+	}
+	for deadline := start.Add(time.Second); time.Now().Before(deadline); {
+		data, err := bus.Receive()
+		if err != nil {
+			return 0
+		}
+		if data != nil {
+			return time.Since(start)
+		}
+	}
+	return 0
+}
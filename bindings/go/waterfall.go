@@ -0,0 +1,123 @@
+// Linear multi-stage pipelines built from chained buses
+
+package umsbb
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var _ Bus = (*WaterfallBus)(nil)
+
+// WaterfallBus chains buses into a linear pipeline
+//
+// Send writes to the first stage and Receive reads from the last. A
+// background goroutine per hop moves messages from each stage into the next,
+// so any middleware wrapped around a stage runs as messages pass through it.
+type WaterfallBus struct {
+	stages []Bus
+	stop   chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// Waterfall links stages in order and starts moving messages between them
+//
+// Example:
+//
+//	pipeline, err := umsbb.Waterfall(ingest, decode, validate, store)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer pipeline.Close()
+func Waterfall(stages ...Bus) (*WaterfallBus, error) {
+	if len(stages) == 0 {
+		return nil, errors.New("waterfall needs at least one stage")
+	}
+	for _, stage := range stages {
+		if stage == nil {
+			return nil, errors.New("waterfall stage cannot be nil")
+		}
+	}
+
+	wb := &WaterfallBus{
+		stages: stages,
+		stop:   make(chan struct{}),
+	}
+
+	for i := 0; i < len(stages)-1; i++ {
+		wb.wg.Add(1)
+		go wb.transfer(stages[i], stages[i+1])
+	}
+	return wb, nil
+}
+
+// transfer moves messages from one stage to the next until the pipeline stops
+//
+// A message the next stage refuses is held and retried, so a full stage
+// applies backpressure to the one before it rather than losing data.
+func (wb *WaterfallBus) transfer(from, to Bus) {
+	defer wb.wg.Done()
+
+	var held *UniversalData
+	for {
+		select {
+		case <-wb.stop:
+			return
+		default:
+		}
+
+		if held == nil {
+			msg, err := from.ReceiveData()
+			if err != nil || msg == nil {
+				wb.idle()
+				continue
+			}
+			held = msg
+		}
+
+		if err := to.Send(held.Data, held.TypeID); err != nil {
+			wb.idle()
+			continue
+		}
+		held = nil
+	}
+}
+
+// idle waits one poll interval or until the pipeline stops
+func (wb *WaterfallBus) idle() {
+	select {
+	case <-wb.stop:
+	case <-time.After(receivePollInterval):
+	}
+}
+
+// Send writes data to the first stage
+func (wb *WaterfallBus) Send(data []byte, typeID uint32) error {
+	return wb.stages[0].Send(data, typeID)
+}
+
+// Receive returns the next payload from the last stage
+func (wb *WaterfallBus) Receive() ([]byte, error) {
+	return wb.stages[len(wb.stages)-1].Receive()
+}
+
+// ReceiveData returns the next message from the last stage
+func (wb *WaterfallBus) ReceiveData() (*UniversalData, error) {
+	return wb.stages[len(wb.stages)-1].ReceiveData()
+}
+
+// Close stops the transfer goroutines and closes every stage
+func (wb *WaterfallBus) Close() error {
+	wb.once.Do(func() { close(wb.stop) })
+	wb.wg.Wait()
+
+	var errs []error
+	for _, stage := range wb.stages {
+		if err := stage.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
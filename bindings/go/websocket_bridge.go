@@ -0,0 +1,223 @@
+// WebSocket bridge for browser clients
+
+package umsbb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// WebSocketFrame is the JSON message exchanged with WebSocket clients
+//
+// Data is base64-encoded in JSON. SourceLang is set on messages relayed to
+// clients and ignored on messages from them, which the bus records as Go.
+type WebSocketFrame struct {
+	TypeID     uint32       `json:"typeID"`
+	SourceLang LanguageType `json:"sourceLang"`
+	Data       []byte       `json:"data"`
+}
+
+// WebSocketOption configures a WebSocketBridge
+type WebSocketOption func(*webSocketConfig)
+
+// webSocketConfig holds the settings of a WebSocketBridge
+type webSocketConfig struct {
+	allowedOrigins []string
+}
+
+// WithAllowedOrigins accepts connections from browser pages on origins, such
+// as "https://app.example.com", as well as from the bridge's own origin;
+// "*" accepts every origin
+func WithAllowedOrigins(origins ...string) WebSocketOption {
+	return func(c *webSocketConfig) {
+		c.allowedOrigins = append(c.allowedOrigins, origins...)
+	}
+}
+
+// webSocketHub tracks the connected clients of a bridge
+type webSocketHub struct {
+	mu      sync.Mutex
+	clients map[*wsConn]struct{}
+	joined  chan struct{}
+}
+
+// add registers a client and wakes the relay if it was idle
+func (h *webSocketHub) add(c *wsConn) {
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+
+	select {
+	case h.joined <- struct{}{}:
+	default:
+	}
+}
+
+// remove unregisters and closes a client
+func (h *webSocketHub) remove(c *wsConn) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+	c.Close()
+}
+
+// snapshot returns the clients connected now
+func (h *webSocketHub) snapshot() []*wsConn {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	clients := make([]*wsConn, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	return clients
+}
+
+// WebSocketBridge serves bus messages to WebSocket clients on addr
+//
+// Every message drained from bus is sent to every connected client as a
+// JSON WebSocketFrame, and every frame a client sends is submitted to bus,
+// so a client also receives its own messages back. The bus is only drained
+// while at least one client is connected. WebSocketBridge blocks until ctx
+// is done, then closes the server and every client and returns ctx.Err().
+//
+// Clients are not authenticated. Browser pages may only connect from the
+// bridge's own origin or those set with WithAllowedOrigins, so other sites
+// cannot reach the bus through a visitor's browser; clients that send no
+// Origin header are always accepted.
+//
+// Example:
+//
+//	go umsbb.WebSocketBridge(bus, "localhost:8080", ctx,
+//	    umsbb.WithAllowedOrigins("https://app.example.com"))
+//
+//	// In the browser:
+//	// const ws = new WebSocket("ws://localhost:8080/");
+//	// ws.send(JSON.stringify({typeID: 1, data: btoa("hello")}));
+func WebSocketBridge(bus *DirectUniversalBus, addr string, ctx context.Context, opts ...WebSocketOption) error {
+	var cfg webSocketConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	hub := &webSocketHub{
+		clients: make(map[*wsConn]struct{}),
+		joined:  make(chan struct{}, 1),
+	}
+
+	// Cancelled when the caller's ctx ends or the server fails on its own
+	bridgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	srv := &http.Server{
+		Addr:        addr,
+		BaseContext: func(net.Listener) context.Context { return bridgeCtx },
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, err := upgradeWebSocket(w, r, cfg.allowedOrigins)
+			if err != nil {
+				return
+			}
+			hub.add(c)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer hub.remove(c)
+				if err := relayFromWebSocket(bridgeCtx, c, bus); err != nil &&
+					!errors.Is(err, errWebSocketClosed) && bridgeCtx.Err() == nil {
+					fmt.Printf("[Go WebSocket] client %s closed: %v\n", c.conn.RemoteAddr(), err)
+				}
+			}()
+		}),
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		relayToWebSockets(bridgeCtx, hub, bus)
+	}()
+
+	stop := context.AfterFunc(bridgeCtx, func() {
+		srv.Close()
+		for _, c := range hub.snapshot() {
+			c.Close()
+		}
+	})
+	defer stop()
+
+	err := srv.ListenAndServe()
+	cancel()
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// relayFromWebSocket submits every frame a client sends until it disconnects
+func relayFromWebSocket(ctx context.Context, c *wsConn, bus *DirectUniversalBus) error {
+	for {
+		message, err := c.readMessage()
+		if err != nil {
+			return err
+		}
+
+		var frame WebSocketFrame
+		if err := json.Unmarshal(message, &frame); err != nil {
+			return fmt.Errorf("invalid frame: %w", err)
+		}
+		if err := sendContext(ctx, bus, frame.Data, frame.TypeID); err != nil {
+			return err
+		}
+	}
+}
+
+// relayToWebSockets drains bus and broadcasts each message while clients are connected
+func relayToWebSockets(ctx context.Context, hub *webSocketHub, bus *DirectUniversalBus) {
+	for {
+		clients := hub.snapshot()
+		if len(clients) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hub.joined:
+				continue
+			}
+		}
+
+		msg, err := receiveDataContext(ctx, bus)
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Printf("[Go WebSocket] receive failed, relay stopped: %v\n", err)
+			}
+			return
+		}
+
+		payload, err := json.Marshal(WebSocketFrame{
+			TypeID:     msg.TypeID,
+			SourceLang: msg.SourceLang,
+			Data:       msg.Data,
+		})
+		if err != nil {
+			continue
+		}
+		clients = hub.snapshot()
+		if len(clients) == 0 {
+			// Everyone left while waiting; keep the message for the next client
+			_ = bus.Send(msg.Data, msg.TypeID)
+			continue
+		}
+		for _, c := range clients {
+			if err := c.writeFrame(wsOpText, payload); err != nil {
+				hub.remove(c)
+			}
+		}
+	}
+}
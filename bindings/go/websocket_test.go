@@ -0,0 +1,216 @@
+package umsbb
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// wsTestKey and wsTestAccept are the sample handshake from RFC 6455 section 1.3
+const (
+	wsTestKey    = "dGhlIHNhbXBsZSBub25jZQ=="
+	wsTestAccept = "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="
+)
+
+// newEchoServer upgrades every request and echoes each message back
+func newEchoServer(t *testing.T, allowedOrigins ...string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgradeWebSocket(w, r, allowedOrigins)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		for {
+			message, err := c.readMessage()
+			if err != nil {
+				return
+			}
+			if err := c.writeFrame(wsOpBinary, message); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// dialWebSocket sends an opening handshake with origin, if not empty, and
+// returns the connection and the response
+func dialWebSocket(t *testing.T, srv *httptest.Server, origin string) (net.Conn, *bufio.Reader, *http.Response) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", wsTestKey)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatalf("writing handshake: %v", err)
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		t.Fatalf("reading handshake response: %v", err)
+	}
+	return conn, r, resp
+}
+
+// writeClientFrame writes one masked frame as a browser would
+func writeClientFrame(t *testing.T, conn net.Conn, fin bool, opcode byte, payload []byte) {
+	t.Helper()
+
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	var mask [4]byte
+	_, _ = rand.Read(mask[:])
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatalf("writing frame: %v", err)
+	}
+}
+
+// readServerFrame reads one unmasked, unfragmented frame
+func readServerFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		t.Fatalf("reading frame: %v", err)
+	}
+	if head[0]&0x80 == 0 || head[1]&0x80 != 0 {
+		t.Fatalf("frame header %#x %#x, want FIN set and no mask", head[0], head[1])
+	}
+	size := int(head[1] & 0x7F)
+	if size == 126 {
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			t.Fatalf("reading frame length: %v", err)
+		}
+		size = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatalf("reading frame payload: %v", err)
+	}
+	return head[0] & 0x0F, payload
+}
+
+func TestUpgradeWebSocketHandshake(t *testing.T) {
+	srv := newEchoServer(t)
+
+	_, _, resp := dialWebSocket(t, srv, "")
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want 101", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != wsTestAccept {
+		t.Fatalf("Sec-WebSocket-Accept = %q, want %q", got, wsTestAccept)
+	}
+}
+
+func TestUpgradeWebSocketOrigin(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		origin  string
+		allowed []string
+		want    int
+	}{
+		{name: "no origin", want: http.StatusSwitchingProtocols},
+		{name: "same origin", origin: "self", want: http.StatusSwitchingProtocols},
+		{name: "cross origin", origin: "https://evil.example", want: http.StatusForbidden},
+		{name: "allowed origin", origin: "https://app.example", allowed: []string{"https://app.example"}, want: http.StatusSwitchingProtocols},
+		{name: "wildcard", origin: "https://evil.example", allowed: []string{"*"}, want: http.StatusSwitchingProtocols},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newEchoServer(t, tc.allowed...)
+			origin := tc.origin
+			if origin == "self" {
+				origin = srv.URL
+			}
+
+			_, _, resp := dialWebSocket(t, srv, origin)
+			if resp.StatusCode != tc.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tc.want)
+			}
+		})
+	}
+}
+
+func TestWebSocketFraming(t *testing.T) {
+	srv := newEchoServer(t)
+	conn, r, resp := dialWebSocket(t, srv, "")
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want 101", resp.StatusCode)
+	}
+
+	// A fragmented message with a ping between its fragments: the ping is
+	// answered first, then the reassembled message is echoed
+	writeClientFrame(t, conn, false, wsOpText, []byte("hello, "))
+	writeClientFrame(t, conn, true, wsOpPing, []byte("are you there"))
+	writeClientFrame(t, conn, true, wsOpContinuation, []byte("world"))
+
+	if op, payload := readServerFrame(t, r); op != wsOpPong || string(payload) != "are you there" {
+		t.Fatalf("got opcode %#x %q, want pong with the ping payload", op, payload)
+	}
+	if op, payload := readServerFrame(t, r); op != wsOpBinary || string(payload) != "hello, world" {
+		t.Fatalf("got opcode %#x %q, want the reassembled message", op, payload)
+	}
+
+	// Payloads over 125 bytes use the 16-bit extended length
+	long := bytes.Repeat([]byte("x"), 300)
+	writeClientFrame(t, conn, true, wsOpBinary, long)
+	if _, payload := readServerFrame(t, r); !bytes.Equal(payload, long) {
+		t.Fatalf("echoed %d bytes, want %d", len(payload), len(long))
+	}
+
+	// A close frame is answered with a close frame
+	writeClientFrame(t, conn, true, wsOpClose, nil)
+	if op, _ := readServerFrame(t, r); op != wsOpClose {
+		t.Fatalf("got opcode %#x, want close", op)
+	}
+}
+
+func TestWebSocketRejectsUnmaskedFrames(t *testing.T) {
+	srv := newEchoServer(t)
+	conn, r, _ := dialWebSocket(t, srv, "")
+
+	if _, err := conn.Write([]byte{0x80 | wsOpText, 2, 'h', 'i'}); err != nil {
+		t.Fatalf("writing frame: %v", err)
+	}
+	if _, err := r.ReadByte(); err == nil {
+		t.Fatalf("read after unmasked frame = %v, want the connection closed", err)
+	}
+}
@@ -49,7 +49,9 @@ void* bi_buffer_claim(BiBuffer* buf, size_t size);
 void bi_buffer_commit(BiBuffer* buf, void* ptr, size_t size);
 void* bi_buffer_read(BiBuffer* buf, size_t* size);
 void bi_buffer_release(BiBuffer* buf);
-void bi_buffer_resize(BiBuffer* buf, size_t newCap);
+// Reallocates the regions with newCap bytes, discarding their contents.
+// Returns false and keeps the old regions if an allocation fails.
+bool bi_buffer_resize(BiBuffer* buf, size_t newCap);
 void bi_buffer_destroy(BiBuffer* buf);
 
 /* State machine operations */
@@ -78,7 +78,38 @@ uint32_t get_optimal_consumer_count();
 // Direct language bindings (no API wrapper)
 void* umsbb_create_direct(size_t buffer_size, uint32_t segment_count, language_type_t lang);
 bool umsbb_submit_direct(void* bus_handle, const universal_data_t* data);
+// Submits to an explicit segment, bypassing type_id routing
+bool umsbb_submit_direct_to(void* bus_handle, const universal_data_t* data, uint32_t segment_id);
+uint32_t umsbb_segment_count_direct(void* bus_handle);
+// Largest segment_count umsbb_create_direct supports
+uint32_t umsbb_max_segment_count(void);
+// Reallocates every drained segment with segment_capacity bytes; returns the
+// number of segments resized, or -1 on error. A segment whose new regions
+// cannot be allocated keeps its old ones and is not counted. Callers must stop
+// submits and drains while it runs.
+int umsbb_compact_direct(void* bus_handle, size_t segment_capacity);
+// Faults in every memory page of every segment without changing its
+// contents; returns the number of pages touched. Callers must stop submits
+// and drains while it runs.
+size_t umsbb_warm_segments(void* bus_handle);
+// Submits only if no message with the same key is pending in the bus.
+// Returns 1 if submitted, 0 if a duplicate is pending, -1 on failure.
+int umsbb_submit_direct_if_absent(void* bus_handle, const universal_data_t* data,
+                                  const void* key, size_t key_len);
 universal_data_t* umsbb_drain_direct(void* bus_handle, language_type_t target_lang);
+// Drains only the given segment; independent segments may be drained concurrently
+universal_data_t* umsbb_drain_direct_from(void* bus_handle, uint32_t segment_id, language_type_t target_lang);
+// Copies the next message of type_id at or after *cursor in a segment without
+// draining it, advancing *cursor past it; returns NULL when none remain.
+// Start with *cursor = 0. Draining a message frees the buffer a scan reads,
+// so callers must not drain the segment while a scan runs.
+universal_data_t* umsbb_scan_segment(void* bus_handle, uint32_t segment_id, size_t* cursor,
+                                     uint32_t type_id, language_type_t target_lang);
+// Routes each listed language to its own segment, in priority order, so drains
+// return their messages first. A count of 0 restores type_id routing.
+bool umsbb_set_language_priority_direct(void* bus_handle, const language_type_t* order, uint32_t count);
+// Approximate number of messages queued in a direct bus
+size_t umsbb_message_count(void* bus_handle);
 void umsbb_destroy_direct(void* bus_handle);
 
 #ifdef __cplusplus
@@ -159,6 +159,10 @@ void umsbb_free(UniversalMultiSegmentedBiBufferBus* bus);
 // Basic message operations
 bool umsbb_submit_to(UniversalMultiSegmentedBiBufferBus* bus, size_t laneIndex, const char* msg, size_t size);
 void* umsbb_drain_from(UniversalMultiSegmentedBiBufferBus* bus, size_t laneIndex, size_t* size);
+// Like umsbb_drain_from, but hands the submitted payload pointer to the caller
+// instead of a copy; for submitters that heap-allocate payloads and give the
+// bus ownership of them
+void* umsbb_drain_owned_from(UniversalMultiSegmentedBiBufferBus* bus, size_t laneIndex, size_t* size);
 
 #if UMSBB_API_LEVEL >= 1
 // ============================================================================
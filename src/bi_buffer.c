@@ -89,19 +89,31 @@ void bi_buffer_release(BiBuffer* buf) {
     bi_buffer_set_message_state(buf, readIndex, MSG_STATE_FREE);
 }
 
-void bi_buffer_resize(BiBuffer* buf, size_t newCap) {
-    soma_aligned_free(buf->regionA);
-    soma_aligned_free(buf->regionB);
-    soma_aligned_free(buf->regionC);
+bool bi_buffer_resize(BiBuffer* buf, size_t newCap) {
+    // Allocate the new regions first so a failure leaves the buffer usable
+    void* regionA = soma_aligned_alloc(SOMA_ALIGNMENT, newCap);
+    void* regionB = soma_aligned_alloc(SOMA_ALIGNMENT, newCap);
+    void* regionC = soma_aligned_alloc(SOMA_ALIGNMENT, newCap / 4);
+    if (!regionA || !regionB || !regionC) {
+        if (regionA) soma_aligned_free(regionA);
+        if (regionB) soma_aligned_free(regionB);
+        if (regionC) soma_aligned_free(regionC);
+        return false;
+    }
+    
+    if (buf->regionA) soma_aligned_free(buf->regionA);
+    if (buf->regionB) soma_aligned_free(buf->regionB);
+    if (buf->regionC) soma_aligned_free(buf->regionC);
     buf->capacity = newCap;
-    buf->regionA = soma_aligned_alloc(SOMA_ALIGNMENT, newCap);
-    buf->regionB = soma_aligned_alloc(SOMA_ALIGNMENT, newCap);
-    buf->regionC = soma_aligned_alloc(SOMA_ALIGNMENT, newCap / 4);
+    buf->regionA = regionA;
+    buf->regionB = regionB;
+    buf->regionC = regionC;
     atomic_store_size(&buf->writeIndex, 0);
     atomic_store_size(&buf->readIndex, 0);
     atomic_store_size(&buf->commitIndex, 0);
     atomic_store_size(&buf->feedbackIndex, 0);
     memset(buf->regionC, MSG_STATE_FREE, newCap / 4);
+    return true;
 }
 
 void bi_buffer_destroy(BiBuffer* buf) {
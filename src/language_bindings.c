@@ -1,11 +1,13 @@
 #include "language_bindings.h"
 #include "universal_multi_segmented_bi_buffer_bus.h"
 #include "gpu_delegate.h"
+#include <errno.h>
 #include <stdio.h>
 #include <stdlib.h>
 #include <string.h>
 #include <pthread.h>
 #include <time.h>
+#include <unistd.h>
 
 // Global state
 static language_runtime_t registered_runtimes[16];
@@ -211,6 +213,87 @@ uint32_t get_optimal_consumer_count() {
 }
 
 // Direct language bindings (no API wrapper)
+
+// Envelope stored ahead of every direct payload so drains can report the
+// producer's type_id and language instead of the segment the message landed in.
+// key_len is non-zero for keyed submits; the key follows the envelope.
+typedef struct {
+    uint32_t type_id;
+    uint32_t key_len;
+    uint32_t source_lang;
+} direct_envelope_t;
+
+// Index of keys currently pending in a bus, used by conditional submits.
+// Keys are inserted under the same lock as the submit and removed when the
+// keyed message is drained, so check-and-enqueue is atomic.
+#define PENDING_KEY_BUCKETS 1024
+
+typedef struct pending_key {
+    struct pending_key* next;
+    void* bus;
+    uint64_t hash;
+    uint32_t len;
+    char key[];
+} pending_key_t;
+
+static pending_key_t* pending_keys[PENDING_KEY_BUCKETS];
+static pthread_mutex_t pending_keys_mutex = PTHREAD_MUTEX_INITIALIZER;
+
+static uint64_t pending_key_hash(void* bus, const void* key, uint32_t len) {
+    // FNV-1a over the key, seeded with the bus handle
+    uint64_t hash = 1469598103934665603ULL ^ (uint64_t)(uintptr_t)bus;
+    const unsigned char* bytes = key;
+    for (uint32_t i = 0; i < len; i++) {
+        hash ^= bytes[i];
+        hash *= 1099511628211ULL;
+    }
+    return hash;
+}
+
+// Caller must hold pending_keys_mutex
+static pending_key_t** pending_key_find(void* bus, const void* key, uint32_t len, uint64_t hash) {
+    pending_key_t** link = &pending_keys[hash % PENDING_KEY_BUCKETS];
+    while (*link) {
+        pending_key_t* entry = *link;
+        if (entry->bus == bus && entry->hash == hash && entry->len == len &&
+            memcmp(entry->key, key, len) == 0) {
+            return link;
+        }
+        link = &entry->next;
+    }
+    return NULL;
+}
+
+static void pending_key_remove(void* bus, const void* key, uint32_t len) {
+    uint64_t hash = pending_key_hash(bus, key, len);
+    
+    pthread_mutex_lock(&pending_keys_mutex);
+    pending_key_t** link = pending_key_find(bus, key, len, hash);
+    if (link) {
+        pending_key_t* entry = *link;
+        *link = entry->next;
+        free(entry);
+    }
+    pthread_mutex_unlock(&pending_keys_mutex);
+}
+
+static void pending_keys_purge(void* bus) {
+    pthread_mutex_lock(&pending_keys_mutex);
+    for (int b = 0; b < PENDING_KEY_BUCKETS; b++) {
+        pending_key_t** link = &pending_keys[b];
+        while (*link) {
+            pending_key_t* entry = *link;
+            if (entry->bus == bus) {
+                *link = entry->next;
+                free(entry);
+            } else {
+                link = &entry->next;
+            }
+        }
+    }
+    pthread_mutex_unlock(&pending_keys_mutex);
+}
+
 void* umsbb_create_direct(size_t buffer_size, uint32_t segment_count, language_type_t lang) {
     // Initialize GPU if configured for GPU preference
     if (current_scaling_config.gpu_preferred) {
@@ -233,11 +316,142 @@ void* umsbb_create_direct(size_t buffer_size, uint32_t segment_count, language_t
     return bus;
 }
 
-bool umsbb_submit_direct(void* bus_handle, const universal_data_t* data) {
-    if (!bus_handle || !data) return false;
-    
-    UniversalMultiSegmentedBiBufferBus* bus = (UniversalMultiSegmentedBiBufferBus*)bus_handle;
-    
+// Messages currently queued in each direct bus, reported by umsbb_message_count
+typedef struct direct_bus_count {
+    struct direct_bus_count* next;
+    void* bus;
+    uint64_t queued;
+} direct_bus_count_t;
+
+static direct_bus_count_t* direct_counts = NULL;
+static pthread_mutex_t direct_counts_mutex = PTHREAD_MUTEX_INITIALIZER;
+
+static void direct_count_adjust(void* bus, int64_t delta) {
+    pthread_mutex_lock(&direct_counts_mutex);
+    direct_bus_count_t* entry = direct_counts;
+    while (entry && entry->bus != bus) {
+        entry = entry->next;
+    }
+    if (!entry && delta > 0) {
+        entry = calloc(1, sizeof(direct_bus_count_t));
+        if (entry) {
+            entry->bus = bus;
+            entry->next = direct_counts;
+            direct_counts = entry;
+        }
+    }
+    if (entry) {
+        if (delta < 0 && entry->queued < (uint64_t)-delta) {
+            entry->queued = 0;
+        } else {
+            entry->queued += delta;
+        }
+    }
+    pthread_mutex_unlock(&direct_counts_mutex);
+}
+
+static void direct_count_remove(void* bus) {
+    pthread_mutex_lock(&direct_counts_mutex);
+    direct_bus_count_t** link = &direct_counts;
+    while (*link) {
+        direct_bus_count_t* entry = *link;
+        if (entry->bus == bus) {
+            *link = entry->next;
+            free(entry);
+            break;
+        }
+        link = &entry->next;
+    }
+    pthread_mutex_unlock(&direct_counts_mutex);
+}
+
+size_t umsbb_message_count(void* bus_handle) {
+    if (!bus_handle) return 0;
+    
+    size_t count = 0;
+    pthread_mutex_lock(&direct_counts_mutex);
+    for (direct_bus_count_t* entry = direct_counts; entry; entry = entry->next) {
+        if (entry->bus == bus_handle) {
+            count = (size_t)entry->queued;
+            break;
+        }
+    }
+    pthread_mutex_unlock(&direct_counts_mutex);
+    return count;
+}
+
+// Language lanes of each direct bus, set by umsbb_set_language_priority_direct.
+// The language at position i of the order is routed to segment i, and drains
+// scan segments in ascending order, so higher-priority languages drain first.
+#define MAX_LANGUAGE_LANES 16
+
+typedef struct direct_bus_lanes {
+    struct direct_bus_lanes* next;
+    void* bus;
+    uint32_t count;
+    language_type_t order[MAX_LANGUAGE_LANES];
+} direct_bus_lanes_t;
+
+static direct_bus_lanes_t* direct_lanes = NULL;
+static pthread_mutex_t direct_lanes_mutex = PTHREAD_MUTEX_INITIALIZER;
+
+static void direct_lanes_remove(void* bus) {
+    pthread_mutex_lock(&direct_lanes_mutex);
+    direct_bus_lanes_t** link = &direct_lanes;
+    while (*link) {
+        direct_bus_lanes_t* entry = *link;
+        if (entry->bus == bus) {
+            *link = entry->next;
+            free(entry);
+            break;
+        }
+        link = &entry->next;
+    }
+    pthread_mutex_unlock(&direct_lanes_mutex);
+}
+
+bool umsbb_set_language_priority_direct(void* bus_handle, const language_type_t* order, uint32_t count) {
+    if (!bus_handle || count > MAX_LANGUAGE_LANES || (count > 0 && !order)) return false;
+    
+    direct_lanes_remove(bus_handle);
+    if (count == 0) return true;
+    
+    direct_bus_lanes_t* entry = calloc(1, sizeof(direct_bus_lanes_t));
+    if (!entry) return false;
+    entry->bus = bus_handle;
+    entry->count = count;
+    memcpy(entry->order, order, count * sizeof(language_type_t));
+    
+    pthread_mutex_lock(&direct_lanes_mutex);
+    entry->next = direct_lanes;
+    direct_lanes = entry;
+    pthread_mutex_unlock(&direct_lanes_mutex);
+    return true;
+}
+
+// Picks the segment for a submit: the language lane if the producer's language
+// has a priority, otherwise type_id routing
+static uint32_t direct_route(UniversalMultiSegmentedBiBufferBus* bus, const universal_data_t* data) {
+    uint32_t segment = data->type_id % bus->segment_count;
+    
+    pthread_mutex_lock(&direct_lanes_mutex);
+    for (direct_bus_lanes_t* entry = direct_lanes; entry; entry = entry->next) {
+        if (entry->bus != bus) continue;
+        for (uint32_t i = 0; i < entry->count; i++) {
+            if (entry->order[i] == data->source_lang) {
+                segment = i % bus->segment_count;
+                break;
+            }
+        }
+        break;
+    }
+    pthread_mutex_unlock(&direct_lanes_mutex);
+    return segment;
+}
+
+// Frames and submits a payload to a segment, optionally carrying a pending key
+static bool submit_framed(UniversalMultiSegmentedBiBufferBus* bus, const universal_data_t* data,
+                          const void* key, uint32_t key_len, uint32_t segment_id) {
     // Try GPU execution for large data
     bool gpu_used = false;
     if (current_scaling_config.gpu_preferred && data->size > 1024 * 1024) {
@@ -247,19 +461,254 @@ bool umsbb_submit_direct(void* bus_handle, const universal_data_t* data) {
         }
     }
     
-    // Submit to appropriate segment
-    uint32_t segment_id = data->type_id % bus->segment_count;
-    bool result = umsbb_submit_to(bus, segment_id, data->data, data->size);
+    // Prefix the payload with its envelope and key
+    size_t framed_size = sizeof(direct_envelope_t) + key_len + data->size;
+    char* framed = malloc(framed_size);
+    if (!framed) return false;
+    
+    direct_envelope_t envelope = {
+        .type_id = data->type_id,
+        .key_len = key_len,
+        .source_lang = (uint32_t)data->source_lang,
+    };
+    memcpy(framed, &envelope, sizeof(envelope));
+    if (key_len > 0) {
+        memcpy(framed + sizeof(envelope), key, key_len);
+    }
+    memcpy(framed + sizeof(envelope) + key_len, data->data, data->size);
+    
+    // The capsule keeps a pointer to framed, so on success the bus owns it
+    // until drain_segment or umsbb_destroy_direct frees it
+    bool result = umsbb_submit_to(bus, segment_id, framed, framed_size);
     
     if (result) {
+        direct_count_adjust(bus, 1);
         performance_stats.total_operations++;
         // Update performance stats for auto-scaling
         trigger_scale_evaluation();
+    } else {
+        free(framed);
+        errno = ENOSPC; // Segment full or throttled; the handle is still good
     }
     
     return result;
 }
 
+// Failed submits set errno: EBADF for a missing handle, EINVAL for bad
+// arguments, ENOMEM when framing fails and ENOSPC when the segment is full,
+// so callers can tell a dead handle from backpressure
+bool umsbb_submit_direct(void* bus_handle, const universal_data_t* data) {
+    if (!bus_handle) {
+        errno = EBADF;
+        return false;
+    }
+    if (!data) {
+        errno = EINVAL;
+        return false;
+    }
+    
+    UniversalMultiSegmentedBiBufferBus* bus = (UniversalMultiSegmentedBiBufferBus*)bus_handle;
+    
+    // Submit to appropriate segment
+    return submit_framed(bus, data, NULL, 0, direct_route(bus, data));
+}
+
+bool umsbb_submit_direct_to(void* bus_handle, const universal_data_t* data, uint32_t segment_id) {
+    if (!bus_handle) {
+        errno = EBADF;
+        return false;
+    }
+    
+    UniversalMultiSegmentedBiBufferBus* bus = (UniversalMultiSegmentedBiBufferBus*)bus_handle;
+    if (!data || segment_id >= bus->segment_count) {
+        errno = EINVAL;
+        return false;
+    }
+    
+    return submit_framed(bus, data, NULL, 0, segment_id);
+}
+
+uint32_t umsbb_segment_count_direct(void* bus_handle) {
+    if (!bus_handle) return 0;
+    
+    return ((UniversalMultiSegmentedBiBufferBus*)bus_handle)->segment_count;
+}
+
+uint32_t umsbb_max_segment_count(void) {
+    // Each segment is one buffer of the segment ring
+    return MAX_AGENTS;
+}
+
+// Writes one byte back to itself on every page of a region, faulting the
+// pages in without changing their contents
+static size_t touch_pages(void* region, size_t size, size_t page_size) {
+    if (!region) return 0;
+    
+    volatile uint8_t* bytes = (volatile uint8_t*)region;
+    size_t pages = 0;
+    for (size_t offset = 0; offset < size; offset += page_size) {
+        bytes[offset] = bytes[offset];
+        pages++;
+    }
+    return pages;
+}
+
+size_t umsbb_warm_segments(void* bus_handle) {
+    if (!bus_handle) return 0;
+    
+    long page_size = sysconf(_SC_PAGESIZE);
+    if (page_size <= 0) page_size = 4096;
+    
+    UniversalMultiSegmentedBiBufferBus* bus = (UniversalMultiSegmentedBiBufferBus*)bus_handle;
+    size_t pages = 0;
+    for (uint32_t i = 0; i < bus->segment_count; i++) {
+        BiBuffer* buf = &bus->ring.buffers[i];
+        pages += touch_pages(buf->regionA, buf->capacity, (size_t)page_size);
+        pages += touch_pages(buf->regionB, buf->capacity, (size_t)page_size);
+        pages += touch_pages(buf->regionC, buf->capacity / 4, (size_t)page_size);
+    }
+    return pages;
+}
+
+int umsbb_compact_direct(void* bus_handle, size_t segment_capacity) {
+    if (!bus_handle || segment_capacity < sizeof(MessageCapsule)) return -1;
+    
+    UniversalMultiSegmentedBiBufferBus* bus = (UniversalMultiSegmentedBiBufferBus*)bus_handle;
+    int resized = 0;
+    for (uint32_t i = 0; i < bus->segment_count; i++) {
+        BiBuffer* buf = &bus->ring.buffers[i];
+        if (buf->capacity == segment_capacity) continue;
+        
+        // Resizing discards the regions, so only drained segments are touched
+        if (atomic_load_size(&buf->readIndex) < atomic_load_size(&buf->commitIndex)) continue;
+        
+        // A segment that cannot be reallocated keeps its size and is not counted
+        if (bi_buffer_resize(buf, segment_capacity)) {
+            resized++;
+        }
+    }
+    return resized;
+}
+
+int umsbb_submit_direct_if_absent(void* bus_handle, const universal_data_t* data,
+                                  const void* key, size_t key_len) {
+    if (!bus_handle) {
+        errno = EBADF;
+        return -1;
+    }
+    if (!data || !key || key_len == 0 || key_len > UINT32_MAX) {
+        errno = EINVAL;
+        return -1;
+    }
+    
+    uint32_t len = (uint32_t)key_len;
+    uint64_t hash = pending_key_hash(bus_handle, key, len);
+    
+    pthread_mutex_lock(&pending_keys_mutex);
+    if (pending_key_find(bus_handle, key, len, hash)) {
+        pthread_mutex_unlock(&pending_keys_mutex);
+        return 0;
+    }
+    
+    pending_key_t* entry = malloc(sizeof(pending_key_t) + len);
+    if (!entry) {
+        pthread_mutex_unlock(&pending_keys_mutex);
+        return -1;
+    }
+    entry->bus = bus_handle;
+    entry->hash = hash;
+    entry->len = len;
+    memcpy(entry->key, key, len);
+    
+    UniversalMultiSegmentedBiBufferBus* bus = (UniversalMultiSegmentedBiBufferBus*)bus_handle;
+    if (!submit_framed(bus, data, key, len, direct_route(bus, data))) {
+        free(entry);
+        pthread_mutex_unlock(&pending_keys_mutex);
+        return -1;
+    }
+    
+    pending_key_t** bucket = &pending_keys[hash % PENDING_KEY_BUCKETS];
+    entry->next = *bucket;
+    *bucket = entry;
+    pthread_mutex_unlock(&pending_keys_mutex);
+    return 1;
+}
+
+// Drains one framed message from a segment, or returns NULL if it is empty.
+// The framed buffer allocated by submit_framed comes back from the bus and is
+// freed here once the payload is copied out.
+static universal_data_t* drain_segment(UniversalMultiSegmentedBiBufferBus* bus, uint32_t segment_id,
+                                       language_type_t target_lang) {
+    size_t size;
+    void* data = umsbb_drain_owned_from(bus, segment_id, &size);
+    if (!data) return NULL;
+    if (size < sizeof(direct_envelope_t)) {
+        free(data); // Runt frame without an envelope
+        return NULL;
+    }
+    
+    direct_envelope_t envelope;
+    memcpy(&envelope, data, sizeof(envelope));
+    
+    size_t header_size = sizeof(envelope) + envelope.key_len;
+    if (size < header_size) {
+        free(data); // Truncated key
+        return NULL;
+    }
+    if (envelope.key_len > 0) {
+        pending_key_remove(bus, (char*)data + sizeof(envelope), envelope.key_len);
+    }
+    
+    // Create universal data structure
+    universal_data_t* udata = create_universal_data((char*)data + header_size,
+                                                    size - header_size,
+                                                    envelope.type_id, target_lang);
+    free(data); // Free the framed buffer
+    if (udata) {
+        udata->source_lang = (language_type_t)envelope.source_lang;
+    }
+    
+    direct_count_adjust(bus, -1);
+    performance_stats.total_operations++;
+    return udata;
+}
+
+universal_data_t* umsbb_scan_segment(void* bus_handle, uint32_t segment_id, size_t* cursor,
+                                     uint32_t type_id, language_type_t target_lang) {
+    if (!bus_handle || !cursor) return NULL;
+    
+    UniversalMultiSegmentedBiBufferBus* bus = (UniversalMultiSegmentedBiBufferBus*)bus_handle;
+    if (segment_id >= bus->segment_count) return NULL;
+    
+    BiBuffer* buf = &bus->ring.buffers[segment_id];
+    size_t offset = atomic_load_size(&buf->readIndex);
+    size_t end = atomic_load_size(&buf->commitIndex);
+    if (*cursor > offset) offset = *cursor; // Drains may have passed the cursor
+    
+    // Walk committed capsules without moving the read index
+    for (; offset + sizeof(MessageCapsule) <= end; offset += sizeof(MessageCapsule)) {
+        const MessageCapsule* cap = (const MessageCapsule*)((uint8_t*)buf->regionA + offset);
+        if (!capsule_validate(cap) || cap->size < sizeof(direct_envelope_t)) continue;
+        
+        direct_envelope_t envelope;
+        memcpy(&envelope, cap->payload, sizeof(envelope));
+        size_t header_size = sizeof(envelope) + envelope.key_len;
+        if (envelope.type_id != type_id || cap->size < header_size) continue;
+        
+        universal_data_t* udata = create_universal_data(cap->payload + header_size,
+                                                        cap->size - header_size,
+                                                        envelope.type_id, target_lang);
+        if (udata) {
+            udata->source_lang = (language_type_t)envelope.source_lang;
+        }
+        *cursor = offset + sizeof(MessageCapsule);
+        return udata;
+    }
+    
+    *cursor = offset;
+    return NULL;
+}
+
 universal_data_t* umsbb_drain_direct(void* bus_handle, language_type_t target_lang) {
     if (!bus_handle) return NULL;
     
@@ -267,26 +716,40 @@ universal_data_t* umsbb_drain_direct(void* bus_handle, language_type_t target_la
     
     // Try draining from multiple segments
     for (uint32_t i = 0; i < bus->segment_count; i++) {
-        size_t size;
-        void* data = umsbb_drain_from(bus, i, &size);
-        if (data && size > 0) {
-            // Create universal data structure
-            universal_data_t* udata = create_universal_data(data, size, i, target_lang);
-            free(data); // Free original data
-            
-            performance_stats.total_operations++;
-            return udata;
-        }
+        universal_data_t* udata = drain_segment(bus, i, target_lang);
+        if (udata) return udata;
     }
     
     return NULL;
 }
 
+universal_data_t* umsbb_drain_direct_from(void* bus_handle, uint32_t segment_id, language_type_t target_lang) {
+    if (!bus_handle) return NULL;
+    
+    UniversalMultiSegmentedBiBufferBus* bus = (UniversalMultiSegmentedBiBufferBus*)bus_handle;
+    if (segment_id >= bus->segment_count) return NULL;
+    
+    return drain_segment(bus, segment_id, target_lang);
+}
+
 void umsbb_destroy_direct(void* bus_handle) {
     if (!bus_handle) return;
     
     UniversalMultiSegmentedBiBufferBus* bus = (UniversalMultiSegmentedBiBufferBus*)bus_handle;
+    
+    // Queued capsules own their framed buffers
+    for (uint32_t i = 0; i < bus->segment_count; i++) {
+        size_t size;
+        void* framed;
+        while ((framed = umsbb_drain_owned_from(bus, i, &size))) {
+            free(framed);
+        }
+    }
+    
     umsbb_free(bus);
+    pending_keys_purge(bus_handle);
+    direct_count_remove(bus_handle);
+    direct_lanes_remove(bus_handle);
     
     printf("[Direct] Bus destroyed\n");
 }
\ No newline at end of file
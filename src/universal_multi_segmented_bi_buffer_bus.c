@@ -228,6 +228,38 @@ void* umsbb_drain_from(UniversalMultiSegmentedBiBufferBus* bus, size_t laneIndex
     return result;
 }
 
+void* umsbb_drain_owned_from(UniversalMultiSegmentedBiBufferBus* bus, size_t laneIndex, size_t* dataSize) {
+    *dataSize = 0;
+    if (laneIndex >= bus->ring.activeCount) return NULL;
+    BiBuffer* buf = &bus->ring.buffers[laneIndex];
+
+    for (;;) {
+        size_t size;
+        MessageCapsule* cap = (MessageCapsule*)bi_buffer_read(buf, &size);
+        if (!cap) return NULL;
+
+        if (!capsule_validate(cap)) {
+            // The payload pointer of a corrupt capsule is not trusted, so it
+            // is skipped rather than handed out or freed
+            FeedbackEntry fb = {
+                .sequence = cap->header.sequence,
+                .timestamp = (uint64_t)time(NULL),
+                .note = "Checksum mismatch - state machine integrity failure",
+                .type = FEEDBACK_CORRUPTED
+            };
+            feedback_push(&bus->feedback, fb);
+            bi_buffer_release(buf);
+            continue;
+        }
+
+        void* payload = cap->payload;
+        *dataSize = cap->size;
+        bi_buffer_release(buf);
+        bus->total_operations++;
+        return payload;
+    }
+}
+
 // Legacy drain_from function for compatibility
 void umsbb_drain_from_legacy(UniversalMultiSegmentedBiBufferBus* bus, size_t laneIndex) {
     size_t size;